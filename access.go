@@ -0,0 +1,110 @@
+package pak
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Returned by Set for id 0, which marks the end of the index
+var ErrReservedID = errors.New("pak: resource id 0 is reserved")
+
+// Returns resource data, for aliases the data of the aliased resource.
+// Lazy resources are loaded, reporting false if they cannot be read.
+func (p *PakFile) Get(id uint16) ([]byte, bool) {
+	data, err := p.Load(id)
+	return data, err == nil
+}
+
+// Returns id of resource given by number or by name in Symbols
+func (p *PakFile) Lookup(ref string) (uint16, error) {
+	if resId, err := strconv.ParseUint(ref, 10, 16); err == nil {
+		return uint16(resId), nil
+	}
+	resId, ok := p.Symbols[ref]
+	if !ok {
+		return 0, fmt.Errorf("unknown resource %s", ref)
+	}
+	return resId, nil
+}
+
+// Returns data of resource named in Symbols
+func (p *PakFile) GetByName(name string) ([]byte, bool) {
+	resId, ok := p.Symbols[name]
+	if !ok {
+		return nil, false
+	}
+	return p.Get(resId)
+}
+
+// Returns name of resource in Symbols, empty if it has none. For ids with
+// several names the least one alphabetically is returned.
+func (p *PakFile) Name(id uint16) string {
+	var found string
+	for name, resId := range p.Symbols {
+		if resId == id && (found == "" || name < found) {
+			found = name
+		}
+	}
+	return found
+}
+
+// Sets resource data. A resource that was an alias gets its own data.
+func (p *PakFile) Set(id uint16, data []byte) error {
+	if id == 0 {
+		return ErrReservedID
+	}
+	if p.Resourses == nil {
+		p.Resourses = make(map[uint16][]byte)
+	}
+	delete(p.Aliases, id)
+	delete(p.Lazy, id)
+	p.Resourses[id] = data
+	return nil
+}
+
+// Removes resource. Aliases of it keep the data as resources of their own.
+func (p *PakFile) Delete(id uint16) {
+	delete(p.Resourses, id)
+	delete(p.Aliases, id)
+	delete(p.Lazy, id)
+	for aliasId, target := range p.Aliases {
+		if target == id {
+			delete(p.Aliases, aliasId)
+		}
+	}
+}
+
+// Reports whether resource exists, lazy resources included
+func (p *PakFile) Has(id uint16) bool {
+	_, ok := p.Resourses[id]
+	if !ok {
+		_, ok = p.Lazy[id]
+	}
+	return ok
+}
+
+// Returns number of resources, aliases and lazy resources included
+func (p *PakFile) Len() int {
+	return len(p.Resourses) + len(p.Lazy)
+}
+
+// Returns size of resource data as stored in the pak, the cost over the wire
+func (p *PakFile) SizeStored(id uint16) (int64, bool) {
+	if lr, ok := p.Lazy[id]; ok {
+		return lr.Length, true
+	}
+	data, ok := p.Resourses[id]
+	return int64(len(data)), ok
+}
+
+// Returns size of resource data after decompression, the cost in memory,
+// taken from the compression header, see DecompressedSize. Lazy resources
+// are loaded, reporting false if they cannot be read.
+func (p *PakFile) SizeRaw(id uint16) (int64, bool) {
+	data, err := p.Load(id)
+	if err != nil {
+		return 0, false
+	}
+	return DecompressedSize(data), true
+}
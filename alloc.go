@@ -0,0 +1,55 @@
+package pak
+
+import (
+	"fmt"
+)
+
+// Hands out resource ids not used by any of the paks it knows about
+type Allocator struct {
+	used map[uint16]bool
+}
+
+// Returns allocator treating all resource ids of the given paks, and id 0
+// reserved for the index terminator, as used
+func NewAllocator(paks ...*PakFile) *Allocator {
+	a := &Allocator{used: map[uint16]bool{0: true}}
+	for _, p := range paks {
+		for resId := range p.Resourses {
+			a.used[resId] = true
+		}
+	}
+	return a
+}
+
+// Marks ids as used
+func (a *Allocator) Use(ids ...uint16) {
+	for _, id := range ids {
+		a.used[id] = true
+	}
+}
+
+// Reports whether id is used
+func (a *Allocator) Used(id uint16) bool {
+	return a.used[id]
+}
+
+// Returns n lowest free ids in range first..last inclusive and marks them used.
+// Fails without marking anything when the range has fewer free ids.
+func (a *Allocator) Next(first, last uint16, n int) ([]uint16, error) {
+	ids := make([]uint16, 0, n)
+	for id := uint32(first); id <= uint32(last) && len(ids) < n; id++ {
+		if !a.used[uint16(id)] {
+			ids = append(ids, uint16(id))
+		}
+	}
+	if len(ids) < n {
+		return nil, fmt.Errorf("error allocating ids: only %d of %d free in range %d..%d", len(ids), n, first, last)
+	}
+	a.Use(ids...)
+	return ids, nil
+}
+
+// Returns n lowest free ids in range allocated by GRIT and marks them used
+func (a *Allocator) NextIn(r IDRange, n int) ([]uint16, error) {
+	return a.Next(r.Start, r.Last, n)
+}
@@ -0,0 +1,157 @@
+package pak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+)
+
+// Returned by Append when resources cannot be added without moving existing data
+var ErrCannotAppend = errors.New("pak: resources cannot be appended in place")
+
+// Adds resources to a pak without rewriting existing resource data.
+// New data is appended to the end of the file and the index is rewritten in
+// the room left between index and data (see WriteOptions.Reserve). Data order
+// has to follow index order, which chromium expects sorted by id, so all new
+// ids must be greater than the existing ones. ErrCannotAppend is returned,
+// with f left unchanged, when any of these conditions does not hold or the
+// file has trailing data. ErrReservedID is returned for id 0 before f is
+// read.
+func Append(f io.ReadWriteSeeker, resources map[uint16][]byte) error {
+	if len(resources) == 0 {
+		return nil
+	}
+	if _, ok := resources[0]; ok {
+		return ErrReservedID
+	}
+
+	_, err := f.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	h, err := readHeader(f)
+	if err != nil {
+		return err
+	}
+	if h.version != 4 && h.version != 5 {
+		return ErrCannotAppend
+	}
+
+	resInfos, aliasInfos, err := readIndex(f, h)
+	if err != nil {
+		return err
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	end := resInfos[h.resources].offset
+	if int64(end) != size {
+		return ErrCannotAppend
+	}
+
+	var maxId uint16
+	for _, ri := range resInfos[:h.resources] {
+		if ri.id > maxId {
+			maxId = ri.id
+		}
+	}
+	for _, ai := range aliasInfos {
+		if ai.id > maxId {
+			maxId = ai.id
+		}
+	}
+
+	ids := make([]uint16, 0, len(resources))
+	for resId := range resources {
+		if h.resources+h.aliases > 0 && resId <= maxId {
+			return ErrCannotAppend
+		}
+		ids = append(ids, resId)
+	}
+	sortIds(ids)
+
+	nh := h
+	nh.resources += uint32(len(ids))
+	if h.version == 5 && nh.resources > math.MaxUint16 {
+		return ErrCannotAppend
+	}
+	if nh.indexEnd() > uint64(resInfos[0].offset) {
+		return ErrCannotAppend
+	}
+
+	// Build new index: existing entries, new entries, terminator and aliases
+	entries := append([]resourceInfo(nil), resInfos[:h.resources]...)
+	offset := uint64(end)
+	for _, resId := range ids {
+		entries = append(entries, resourceInfo{id: resId, offset: uint32(offset)})
+		offset += uint64(len(resources[resId]))
+	}
+	if offset > math.MaxUint32 {
+		return ErrCannotAppend
+	}
+	entries = append(entries, resourceInfo{id: 0, offset: uint32(offset)})
+
+	var index bytes.Buffer
+	err = writeHeader(&index, nh)
+	if err != nil {
+		return err
+	}
+	for _, ri := range entries {
+		binary.Write(&index, binary.LittleEndian, ri.id)
+		binary.Write(&index, binary.LittleEndian, ri.offset)
+	}
+	for _, ai := range aliasInfos {
+		binary.Write(&index, binary.LittleEndian, ai.id)
+		binary.Write(&index, binary.LittleEndian, ai.index)
+	}
+
+	// Append data first, so a failure leaves the old index valid
+	for _, resId := range ids {
+		_, err = f.Write(resources[resId])
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(index.Bytes())
+	return err
+}
+
+// Adds resources to pak file, appending in place when possible (see Append)
+// and rewriting the whole file otherwise.
+func AppendFile(name string, resources map[uint16][]byte) error {
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	err = Append(f, resources)
+	if err != ErrCannotAppend {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+		return err
+	}
+	f.Close()
+
+	p, err := ReadFile(name)
+	if err != nil {
+		return err
+	}
+	for resId, resData := range resources {
+		p.Resourses[resId] = resData
+	}
+	return WriteFile(name, p)
+}
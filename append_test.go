@@ -0,0 +1,145 @@
+package pak_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+// Writes sample pak with room for reserve index entries to a temporary file
+func writeReserved(t *testing.T, reserve uint32, trailer []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	err := pak.WriteWithOptions(&buf, paktest.Sample(5, pak.EncodingUTF8), &pak.WriteOptions{Reserve: reserve})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "test.pak")
+	err = os.WriteFile(name, append(buf.Bytes(), trailer...), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// Appends resources to pak file in place
+func appendTo(t *testing.T, name string, resources map[uint16][]byte) error {
+	t.Helper()
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return pak.Append(f, resources)
+}
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name    string
+		reserve uint32
+		trailer []byte
+		batches []map[uint16][]byte
+		err     error // of the last batch
+	}{
+		{"within reserve", 2, nil, []map[uint16][]byte{{300: []byte("a"), 301: []byte("b")}}, nil},
+		{"reserve used in batches", 3, nil, []map[uint16][]byte{{300: []byte("a")}, {301: []byte("b"), 302: []byte("c")}}, nil},
+		{"no reserve", 0, nil, []map[uint16][]byte{{300: []byte("a")}}, pak.ErrCannotAppend},
+		{"reserve exhausted", 2, nil, []map[uint16][]byte{{300: []byte("a"), 301: []byte("b"), 302: []byte("c")}}, pak.ErrCannotAppend},
+		{"reserve exhausted later", 2, nil, []map[uint16][]byte{{300: []byte("a"), 301: []byte("b")}, {302: []byte("c")}}, pak.ErrCannotAppend},
+		{"id not greater", 2, nil, []map[uint16][]byte{{150: []byte("a")}}, pak.ErrCannotAppend},
+		{"trailing data", 2, []byte("trailer"), []map[uint16][]byte{{300: []byte("a")}}, pak.ErrCannotAppend},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := writeReserved(t, tt.reserve, tt.trailer)
+			want := paktest.Sample(5, pak.EncodingUTF8)
+
+			var err error
+			for i, batch := range tt.batches {
+				before, rerr := os.ReadFile(name)
+				if rerr != nil {
+					t.Fatal(rerr)
+				}
+				err = appendTo(t, name, batch)
+				if i < len(tt.batches)-1 && err != nil {
+					t.Fatalf("batch %d: %v", i, err)
+				}
+				if err != nil {
+					// Failed appends leave the file unchanged
+					after, rerr := os.ReadFile(name)
+					if rerr != nil {
+						t.Fatal(rerr)
+					}
+					if !bytes.Equal(before, after) {
+						t.Error("failed Append modified the file")
+					}
+					break
+				}
+				for resId, resData := range batch {
+					want.Resourses[resId] = resData
+				}
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Append error = %v, want %v", err, tt.err)
+			}
+
+			p, err := pak.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			paktest.RequireEqual(t, want, p)
+		})
+	}
+}
+
+func TestAppendFileRewrites(t *testing.T) {
+	name := writeReserved(t, 1, nil)
+	resources := map[uint16][]byte{300: []byte("a"), 301: []byte("b")}
+
+	err := pak.AppendFile(name, resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := paktest.Sample(5, pak.EncodingUTF8)
+	for resId, resData := range resources {
+		want.Resourses[resId] = resData
+	}
+	p, err := pak.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paktest.RequireEqual(t, want, p)
+}
+
+func TestAppendReservedID(t *testing.T) {
+	var buf bytes.Buffer
+	err := pak.WriteWithOptions(&buf, &pak.PakFile{Version: 5, Encoding: pak.EncodingUTF8}, &pak.WriteOptions{Reserve: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "empty.pak")
+	err = os.WriteFile(name, buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = appendTo(t, name, map[uint16][]byte{0: []byte("zero")})
+	if err != pak.ErrReservedID {
+		t.Errorf("Append error = %v, want %v", err, pak.ErrReservedID)
+	}
+	err = pak.AppendFile(name, map[uint16][]byte{0: []byte("zero"), 1: []byte("one")})
+	if err != pak.ErrReservedID {
+		t.Errorf("AppendFile error = %v, want %v", err, pak.ErrReservedID)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil || !bytes.Equal(data, buf.Bytes()) {
+		t.Errorf("failed Append modified the file")
+	}
+}
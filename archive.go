@@ -0,0 +1,243 @@
+package pak
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name of archive entry holding pak metadata
+const archiveMetaName = "pak.json"
+
+// Pak metadata stored in archives along with resource files
+type archiveMeta struct {
+	Version  uint32            `json:"version"`
+	Encoding uint8             `json:"encoding"`
+	Aliases  map[uint16]uint16 `json:"aliases,omitempty"`
+}
+
+// Returns archive entry names and metadata of pak, entries are named by
+// resource id with an extension guessed from content
+func archiveEntries(p *PakFile) ([]uint16, []string, []byte, error) {
+	ids := sortedIds(p)
+	names := make([]string, len(ids))
+	for i, resId := range ids {
+		names[i] = strconv.Itoa(int(resId)) + GuessExtension(p.Resourses[resId], p.Encoding)
+	}
+
+	meta := archiveMeta{Version: uint32(p.Version), Encoding: uint8(p.Encoding)}
+	if p.Version == 5 {
+		for _, ai := range p.plan(nil).aliases {
+			if meta.Aliases == nil {
+				meta.Aliases = make(map[uint16]uint16)
+			}
+			meta.Aliases[ai.id] = p.Aliases[ai.id]
+		}
+	}
+	metaData, err := json.MarshalIndent(meta, "", "\t")
+	return ids, names, metaData, err
+}
+
+// Writes every resource of pak, aliases included, to zip archive as a file
+// named by its id, e.g. 1234.png, plus pak.json with version, encoding and
+// aliases. Resource data is stored as is, lazy resources are loaded.
+func ToZip(w io.Writer, p *PakFile) error {
+	p, err := p.loaded()
+	if err != nil {
+		return err
+	}
+	ids, names, meta, err := archiveEntries(p)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create(archiveMetaName)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(meta)
+	if err != nil {
+		return err
+	}
+
+	for i, resId := range ids {
+		f, err := zw.Create(names[i])
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(p.Resourses[resId])
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// Writes pak to tar archive, see ToZip
+func ToTar(w io.Writer, p *PakFile) error {
+	p, err := p.loaded()
+	if err != nil {
+		return err
+	}
+	ids, names, meta, err := archiveEntries(p)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	now := time.Now()
+
+	write := func(name string, data []byte) error {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+
+	err = write(archiveMetaName, meta)
+	if err != nil {
+		return err
+	}
+	for i, resId := range ids {
+		err = write(names[i], p.Resourses[resId])
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// Collects archive entries into pak struct
+type archiveReader struct {
+	p       *PakFile
+	meta    *archiveMeta
+	sources map[uint16]string
+}
+
+func newArchiveReader() *archiveReader {
+	return &archiveReader{
+		p:       &PakFile{Version: 5, Encoding: EncodingUTF8, Resourses: make(map[uint16][]byte)},
+		sources: make(map[uint16]string),
+	}
+}
+
+// Adds archive entry, skipping entries in subdirectories and hidden files
+func (ar *archiveReader) add(name string, r io.Reader) error {
+	if name == archiveMetaName {
+		ar.meta = &archiveMeta{}
+		err := json.NewDecoder(r).Decode(ar.meta)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", archiveMetaName, err)
+		}
+		return nil
+	}
+
+	name = strings.TrimPrefix(name, "./")
+	if strings.Contains(name, "/") || strings.HasPrefix(path.Base(name), ".") {
+		return nil
+	}
+
+	resId, err := idFromFileName(name)
+	if err != nil {
+		return err
+	}
+	if other, ok := ar.sources[resId]; ok {
+		return fmt.Errorf("error reading %s: resource id=%d already read from %s", name, resId, other)
+	}
+	ar.sources[resId] = name
+
+	ar.p.Resourses[resId], err = io.ReadAll(r)
+	return err
+}
+
+// Returns pak struct built from entries. Without metadata the pak is version
+// 5 with UTF-8 encoding and identical resources aliased, see Canonicalize.
+func (ar *archiveReader) pak() (*PakFile, error) {
+	if ar.meta == nil {
+		err := Canonicalize(ar.p)
+		if err != nil {
+			return nil, err
+		}
+		return ar.p, nil
+	}
+
+	if ar.meta.Version != 4 && ar.meta.Version != 5 {
+		return nil, fmt.Errorf("error reading %s: unsupported version %d", archiveMetaName, ar.meta.Version)
+	}
+	ar.p.Version, ar.p.Encoding = Version(ar.meta.Version), Encoding(ar.meta.Encoding)
+
+	for aliasId, target := range ar.meta.Aliases {
+		targetData, ok := ar.p.Resourses[target]
+		if !ok {
+			return nil, fmt.Errorf("error reading %s: alias id=%d points to missing resource id=%d", archiveMetaName, aliasId, target)
+		}
+		if ar.p.Aliases == nil {
+			ar.p.Aliases = make(map[uint16]uint16)
+		}
+		ar.p.Aliases[aliasId] = target
+		ar.p.Resourses[aliasId] = targetData
+	}
+	return ar.p, nil
+}
+
+// Reads pak struct from zip archive written by ToZip or any zip archive of
+// files named by resource id
+func FromZip(r io.ReaderAt, size int64) (*PakFile, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	ar := newArchiveReader()
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = ar.add(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ar.pak()
+}
+
+// Reads pak struct from tar archive, see FromZip
+func FromTar(r io.Reader) (*PakFile, error) {
+	tr := tar.NewReader(r)
+
+	ar := newArchiveReader()
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		err = ar.add(hdr.Name, tr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ar.pak()
+}
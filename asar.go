@@ -0,0 +1,272 @@
+package pak
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Block size used for integrity hashes of asar entries lacking one
+const asarBlockSize = 4 << 20
+
+// Electron asar archive: pickled JSON header describing files, followed by
+// their concatenated data
+type asarArchive struct {
+	header    map[string]any
+	dataStart int64
+}
+
+// Reads header of asar archive. Header is a pickle holding the size of a
+// second pickle, which holds the JSON string.
+func readAsarHeader(r io.ReaderAt) (*asarArchive, error) {
+	var sizePickle [8]byte
+	_, err := r.ReadAt(sizePickle[:], 0)
+	if err != nil {
+		return nil, fmt.Errorf("error reading asar header: %v", err)
+	}
+	headerSize := binary.LittleEndian.Uint32(sizePickle[4:])
+	if binary.LittleEndian.Uint32(sizePickle[:4]) != 4 || headerSize < 8 {
+		return nil, fmt.Errorf("error reading asar header: not an asar archive")
+	}
+
+	headerPickle := make([]byte, headerSize)
+	_, err = r.ReadAt(headerPickle, 8)
+	if err != nil {
+		return nil, fmt.Errorf("error reading asar header: %v", err)
+	}
+	jsonLength := binary.LittleEndian.Uint32(headerPickle[4:])
+	if uint64(jsonLength) > uint64(headerSize)-8 {
+		return nil, fmt.Errorf("error reading asar header: bad header length %d", jsonLength)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(headerPickle[8 : 8+jsonLength]))
+	dec.UseNumber()
+	a := &asarArchive{dataStart: 8 + int64(headerSize)}
+	err = dec.Decode(&a.header)
+	if err != nil {
+		return nil, fmt.Errorf("error reading asar header: %v", err)
+	}
+	return a, nil
+}
+
+// Returns encoded header of asar archive
+func (a *asarArchive) encodeHeader() ([]byte, error) {
+	var js bytes.Buffer
+	enc := json.NewEncoder(&js)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(a.header)
+	if err != nil {
+		return nil, err
+	}
+	s := bytes.TrimSuffix(js.Bytes(), []byte("\n"))
+
+	padded := (len(s) + 3) &^ 3
+	out := make([]byte, 16+padded)
+	binary.LittleEndian.PutUint32(out[0:], 4)
+	binary.LittleEndian.PutUint32(out[4:], uint32(8+padded))
+	binary.LittleEndian.PutUint32(out[8:], uint32(4+padded))
+	binary.LittleEndian.PutUint32(out[12:], uint32(len(s)))
+	copy(out[16:], s)
+	return out, nil
+}
+
+// Returns header entry of file at slash separated path inside archive
+func (a *asarArchive) entry(innerPath string) (map[string]any, error) {
+	node := a.header
+	for _, elem := range strings.Split(strings.Trim(filepath.ToSlash(innerPath), "/"), "/") {
+		files, _ := node["files"].(map[string]any)
+		next, ok := files[elem].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("error opening %s in asar: file not found", innerPath)
+		}
+		node = next
+	}
+	if _, ok := node["files"]; ok {
+		return nil, fmt.Errorf("error opening %s in asar: is a directory", innerPath)
+	}
+	if _, ok := node["link"]; ok {
+		return nil, fmt.Errorf("error opening %s in asar: symbolic links are not supported", innerPath)
+	}
+	return node, nil
+}
+
+// Returns data offset and size of file entry
+func asarRange(e map[string]any) (offset, size uint64, err error) {
+	offsetStr, _ := e["offset"].(string)
+	offset, err = strconv.ParseUint(offsetStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading asar entry: bad offset %q", offsetStr)
+	}
+	sizeNum, _ := e["size"].(json.Number)
+	size, err = strconv.ParseUint(sizeNum.String(), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading asar entry: bad size %q", sizeNum)
+	}
+	return offset, size, nil
+}
+
+// Reads pak struct from file inside Electron asar archive. Files stored
+// unpacked next to the archive, in the .asar.unpacked directory, are read from
+// there.
+func OpenInAsar(asarPath, innerPath string) (*PakFile, error) {
+	f, err := os.Open(asarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a, err := readAsarHeader(f)
+	if err != nil {
+		return nil, err
+	}
+	e, err := a.entry(innerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if unpacked, _ := e["unpacked"].(bool); unpacked {
+		return ReadFile(filepath.Join(asarPath+".unpacked", filepath.FromSlash(innerPath)))
+	}
+
+	offset, size, err := asarRange(e)
+	if err != nil {
+		return nil, err
+	}
+	return Read(io.NewSectionReader(f, a.dataStart+int64(offset), int64(size)))
+}
+
+// Writes pak struct to file inside Electron asar archive, rewriting the
+// archive atomically. Offsets of files stored after it are adjusted and
+// integrity hashes of the entry are recomputed. Apps that validate the asar
+// header hash at startup need it updated separately.
+func WriteInAsar(asarPath, innerPath string, p *PakFile) error {
+	var buf bytes.Buffer
+	err := Write(&buf, p)
+	if err != nil {
+		return err
+	}
+	data := buf.Bytes()
+
+	f, err := os.Open(asarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	a, err := readAsarHeader(f)
+	if err != nil {
+		return err
+	}
+	e, err := a.entry(innerPath)
+	if err != nil {
+		return err
+	}
+
+	if unpacked, _ := e["unpacked"].(bool); unpacked {
+		setAsarIntegrity(e, data)
+		e["size"] = json.Number(strconv.Itoa(len(data)))
+		err = WriteFileWithOptions(filepath.Join(asarPath+".unpacked", filepath.FromSlash(innerPath)), p, &WriteOptions{Atomic: true})
+		if err != nil {
+			return err
+		}
+		return rewriteAsar(asarPath, f, a, 0, 0, nil)
+	}
+
+	offset, size, err := asarRange(e)
+	if err != nil {
+		return err
+	}
+	shiftAsarOffsets(a.header, offset, int64(len(data))-int64(size))
+	e["size"] = json.Number(strconv.Itoa(len(data)))
+	setAsarIntegrity(e, data)
+
+	return rewriteAsar(asarPath, f, a, offset, size, data)
+}
+
+// Writes archive with new header, replacing size bytes of old data at offset
+// with data
+func rewriteAsar(name string, old io.ReaderAt, a *asarArchive, offset, size uint64, data []byte) error {
+	header, err := a.encodeHeader()
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	dataEnd := fi.Size()
+
+	return writeFileAtomic(name, func(w io.Writer) error {
+		_, err := w.Write(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, io.NewSectionReader(old, a.dataStart, int64(offset)))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		if err != nil {
+			return err
+		}
+		rest := a.dataStart + int64(offset+size)
+		_, err = io.Copy(w, io.NewSectionReader(old, rest, dataEnd-rest))
+		return err
+	})
+}
+
+// Moves data offsets of packed files stored after offset by delta
+func shiftAsarOffsets(node map[string]any, offset uint64, delta int64) {
+	files, _ := node["files"].(map[string]any)
+	for _, child := range files {
+		e, ok := child.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, ok := e["files"]; ok {
+			shiftAsarOffsets(e, offset, delta)
+			continue
+		}
+		offsetStr, _ := e["offset"].(string)
+		o, err := strconv.ParseUint(offsetStr, 10, 64)
+		if err == nil && o > offset {
+			e["offset"] = strconv.FormatUint(uint64(int64(o)+delta), 10)
+		}
+	}
+}
+
+// Recomputes integrity hashes of entry for new data, if it has any
+func setAsarIntegrity(e map[string]any, data []byte) {
+	integrity, ok := e["integrity"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	blockSize := asarBlockSize
+	if n, ok := integrity["blockSize"].(json.Number); ok {
+		if v, err := strconv.Atoi(n.String()); err == nil && v > 0 {
+			blockSize = v
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	blocks := []any{}
+	for start := 0; start < len(data); start += blockSize {
+		blockSum := sha256.Sum256(data[start:min(start+blockSize, len(data))])
+		blocks = append(blocks, hex.EncodeToString(blockSum[:]))
+	}
+
+	integrity["algorithm"] = "SHA256"
+	integrity["hash"] = hex.EncodeToString(sum[:])
+	integrity["blockSize"] = json.Number(strconv.Itoa(blockSize))
+	integrity["blocks"] = blocks
+}
@@ -0,0 +1,76 @@
+package pak
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Writes file through a temporary file in the same directory that is synced
+// and renamed over name. Permissions of an existing file are preserved.
+func writeFileAtomic(name string, write func(w io.Writer) error) (err error) {
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if fi, statErr := os.Stat(name); statErr == nil {
+		err = tmp.Chmod(fi.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = write(tmp)
+	if err != nil {
+		return err
+	}
+	err = tmp.Sync()
+	if err != nil {
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return err
+	}
+
+	// Persist the rename itself, not supported on every platform
+	if d, derr := os.Open(dir); derr == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// Copies file to name+".bak" replacing an older backup, missing file is not an error
+func backupFile(name string) error {
+	src, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return writeFileAtomic(name+".bak", func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
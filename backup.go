@@ -0,0 +1,227 @@
+package pak
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Content-addressed store of original pak files, so that patched files can
+// always be rolled back. Originals are kept as Dir/objects/<sha256 in hex>,
+// Dir/index.json maps absolute paths of backed up paks to the digests of
+// their originals. Identical originals, e.g. of the same browser version
+// installed twice, are stored once.
+type BackupStore struct {
+	Dir string
+}
+
+// Returns backup store in the user configuration directory, e.g.
+// ~/.config/pak/backups on Linux
+func DefaultBackupStore() (*BackupStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &BackupStore{Dir: filepath.Join(dir, "pak", "backups")}, nil
+}
+
+// Backed up pak
+type BackupEntry struct {
+	Name   string // absolute path of the pak
+	Digest string // SHA-256 of the original in hex
+}
+
+// Returns backed up paks sorted by name
+func (s *BackupStore) Entries() ([]BackupEntry, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]BackupEntry, 0, len(index))
+	for name, digest := range index {
+		entries = append(entries, BackupEntry{Name: name, Digest: digest})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Backs up pak file unless a backup of it exists already: the first backup
+// is the original and later ones would store patched contents. Returns the
+// digest of the backup.
+func (s *BackupStore) Backup(name string) (string, error) {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+	index, err := s.readIndex()
+	if err != nil {
+		return "", err
+	}
+	if digest, ok := index[name]; ok {
+		return digest, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	object := s.object(digest)
+	if _, err := os.Stat(object); os.IsNotExist(err) {
+		err = os.MkdirAll(filepath.Dir(object), 0755)
+		if err != nil {
+			return "", err
+		}
+		err = writeFileAtomic(object, func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	index[name] = digest
+	return digest, s.writeIndex(index)
+}
+
+// Restores pak file to its backed up original, verifying the backup first.
+// The backup is kept, so the file can be patched and restored again.
+func (s *BackupStore) Restore(name string) error {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	index, err := s.readIndex()
+	if err != nil {
+		return err
+	}
+	digest, ok := index[name]
+	if !ok {
+		return fmt.Errorf("error restoring %s: no backup", name)
+	}
+
+	data, err := os.ReadFile(s.object(digest))
+	if err != nil {
+		return fmt.Errorf("error restoring %s: %v", name, err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != digest {
+		return fmt.Errorf("error restoring %s: backup %s is corrupt", name, digest)
+	}
+	return writeFileAtomic(name, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Drops backup of pak file, e.g. after a browser update replaced it, and
+// removes the stored original unless other paks share it
+func (s *BackupStore) Forget(name string) error {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	index, err := s.readIndex()
+	if err != nil {
+		return err
+	}
+	digest, ok := index[name]
+	if !ok {
+		return nil
+	}
+	delete(index, name)
+	err = s.writeIndex(index)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range index {
+		if d == digest {
+			return nil
+		}
+	}
+	err = os.Remove(s.object(digest))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Backs up pak file, then reads it, modifies it with fn and writes it back
+// atomically
+func (s *BackupStore) Patch(name string, fn func(p *PakFile) error) error {
+	_, err := s.Backup(name)
+	if err != nil {
+		return err
+	}
+	p, err := ReadFile(name)
+	if err != nil {
+		return err
+	}
+	err = fn(p)
+	if err != nil {
+		return err
+	}
+	return WriteFileWithOptions(name, p, &WriteOptions{Atomic: true})
+}
+
+func (s *BackupStore) object(digest string) string {
+	return filepath.Join(s.Dir, "objects", digest)
+}
+
+func (s *BackupStore) readIndex() (map[string]string, error) {
+	index := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(s.Dir, "index.json"))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &index)
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup index: %v", err)
+	}
+	return index, nil
+}
+
+func (s *BackupStore) writeIndex(index map[string]string) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(s.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.Dir, "index.json"), func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// Backs up pak file to the default store, see BackupStore.Backup
+func Backup(name string) error {
+	s, err := DefaultBackupStore()
+	if err != nil {
+		return err
+	}
+	_, err = s.Backup(name)
+	return err
+}
+
+// Restores pak file from the default store, see BackupStore.Restore
+func Restore(name string) error {
+	s, err := DefaultBackupStore()
+	if err != nil {
+		return err
+	}
+	return s.Restore(name)
+}
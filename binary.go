@@ -0,0 +1,216 @@
+package pak
+
+import (
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Pak found inside an executable or library
+type EmbeddedPak struct {
+	Location string // resource path like "BINDATA/101/1033" or section like "__DATA,__pak"
+	Candidate
+}
+
+// Index of resource table in PE data directories
+const peResourceDirectory = 2
+
+// Resource directories nest type, name and language, deeper trees are malformed
+const peMaxResourceDepth = 3
+
+// Finds paks stored as resources of a PE (Windows) executable or DLL, e.g.
+// compiled in with a resource script. Every resource whose data is a
+// plausible pak is returned, see Carve for the checks made.
+func FindInPE(r io.ReaderAt) ([]EmbeddedPak, error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var dir pe.DataDirectory
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if oh.NumberOfRvaAndSizes > peResourceDirectory {
+			dir = oh.DataDirectory[peResourceDirectory]
+		}
+	case *pe.OptionalHeader64:
+		if oh.NumberOfRvaAndSizes > peResourceDirectory {
+			dir = oh.DataDirectory[peResourceDirectory]
+		}
+	}
+	if dir.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	var sec *pe.Section
+	for _, s := range f.Sections {
+		if dir.VirtualAddress >= s.VirtualAddress && dir.VirtualAddress < s.VirtualAddress+s.Size {
+			sec = s
+			break
+		}
+	}
+	if sec == nil {
+		return nil, fmt.Errorf("error reading PE resources: no section holds resource directory")
+	}
+
+	rsrc, err := sec.Data()
+	if err != nil {
+		return nil, err
+	}
+	base := dir.VirtualAddress - sec.VirtualAddress
+
+	w := &peResourceWalker{r: r, rsrc: rsrc, sections: f.Sections}
+	err = w.walk(base, uint64(base), "", 0)
+	return w.found, err
+}
+
+// Walks resource directory tree of a PE file
+type peResourceWalker struct {
+	r        io.ReaderAt
+	rsrc     []byte // resource section data
+	sections []*pe.Section
+	found    []EmbeddedPak
+}
+
+// Walks directory at offset in resource section, base is the offset of the
+// root directory that entry offsets are relative to
+func (w *peResourceWalker) walk(base uint32, offset uint64, path string, depth int) error {
+	if depth >= peMaxResourceDepth || offset+16 > uint64(len(w.rsrc)) {
+		return fmt.Errorf("error reading PE resources: bad directory at %d", offset)
+	}
+	d := w.rsrc[offset:]
+	count := uint64(binary.LittleEndian.Uint16(d[12:])) + uint64(binary.LittleEndian.Uint16(d[14:]))
+	if offset+16+8*count > uint64(len(w.rsrc)) {
+		return fmt.Errorf("error reading PE resources: bad directory at %d", offset)
+	}
+
+	for i := uint64(0); i < count; i++ {
+		e := d[16+8*i:]
+		name := w.name(base, binary.LittleEndian.Uint32(e))
+		if path != "" {
+			name = path + "/" + name
+		}
+
+		target := binary.LittleEndian.Uint32(e[4:])
+		if target&0x80000000 != 0 {
+			err := w.walk(base, uint64(base)+uint64(target&^0x80000000), name, depth+1)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		entry := uint64(base) + uint64(target)
+		if entry+16 > uint64(len(w.rsrc)) {
+			return fmt.Errorf("error reading PE resources: bad data entry of %s", name)
+		}
+		rva := binary.LittleEndian.Uint32(w.rsrc[entry:])
+		size := binary.LittleEndian.Uint32(w.rsrc[entry+4:])
+		w.check(name, rva, size)
+	}
+	return nil
+}
+
+// Returns name of directory entry, a number or a string
+func (w *peResourceWalker) name(base, id uint32) string {
+	if id&0x80000000 == 0 {
+		return strconv.Itoa(int(id))
+	}
+	offset := uint64(base) + uint64(id&^0x80000000)
+	if offset+2 > uint64(len(w.rsrc)) {
+		return "?"
+	}
+	n := uint64(binary.LittleEndian.Uint16(w.rsrc[offset:]))
+	if offset+2+2*n > uint64(len(w.rsrc)) {
+		return "?"
+	}
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(w.rsrc[offset+2+2*uint64(i):])
+	}
+	return string(utf16.Decode(units))
+}
+
+// Records resource data at rva if it holds a pak
+func (w *peResourceWalker) check(name string, rva, size uint32) {
+	for _, s := range w.sections {
+		if rva < s.VirtualAddress || uint64(rva)+uint64(size) > uint64(s.VirtualAddress)+uint64(s.Size) {
+			continue
+		}
+		offset := int64(s.Offset) + int64(rva-s.VirtualAddress)
+		if c, ok := carveAt(io.NewSectionReader(w.r, offset, int64(size)), 0, int64(size)); ok {
+			c.Offset = offset
+			w.found = append(w.found, EmbeddedPak{Location: name, Candidate: c})
+		}
+		return
+	}
+}
+
+// Finds paks stored in sections of a Mach-O (macOS) executable or library,
+// universal binaries included, by carving section data, see Carve
+func FindInMachO(r io.ReaderAt) ([]EmbeddedPak, error) {
+	if ff, err := macho.NewFatFile(r); err == nil {
+		defer ff.Close()
+		var found []EmbeddedPak
+		for _, arch := range ff.Arches {
+			archFound, err := findInMachOFile(r, arch.File, int64(arch.Offset), arch.Cpu.String()+":")
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, archFound...)
+		}
+		return found, nil
+	}
+
+	f, err := macho.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return findInMachOFile(r, f, 0, "")
+}
+
+func findInMachOFile(r io.ReaderAt, f *macho.File, base int64, prefix string) ([]EmbeddedPak, error) {
+	var found []EmbeddedPak
+	for _, s := range f.Sections {
+		// Zero filled sections have no data in the file
+		if s.Offset == 0 || s.Size == 0 {
+			continue
+		}
+		offset := base + int64(s.Offset)
+		candidates, err := Carve(io.NewSectionReader(r, offset, int64(s.Size)), int64(s.Size))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candidates {
+			c.Offset += offset
+			found = append(found, EmbeddedPak{Location: prefix + s.Seg + "," + s.Name, Candidate: c})
+		}
+	}
+	return found, nil
+}
+
+// Finds paks embedded in PE or Mach-O file, see FindInPE and FindInMachO
+func FindInBinaryFile(name string) ([]EmbeddedPak, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var magic [4]byte
+	_, err = f.ReadAt(magic[:], 0)
+	if err != nil {
+		return nil, err
+	}
+	if magic[0] == 'M' && magic[1] == 'Z' {
+		return FindInPE(f)
+	}
+	return FindInMachO(f)
+}
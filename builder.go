@@ -0,0 +1,182 @@
+package pak
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Assembles a pak step by step, e.g.
+//
+//	p, err := pak.NewBuilder().
+//		AddFile(100, "index.html").
+//		AddString(101, "body { margin: 0 }").
+//		Compress(100, 101).
+//		Build()
+//
+// The first error is kept and returned by Build, later calls do nothing.
+type Builder struct {
+	p        *PakFile
+	compress map[uint16]Compression
+	aliases  map[uint16]uint16
+	err      error
+}
+
+// Returns builder of a version 5 pak with UTF-8 encoding
+func NewBuilder() *Builder {
+	return &Builder{
+		p:        &PakFile{Version: 5, Encoding: EncodingUTF8, Resourses: make(map[uint16][]byte)},
+		compress: make(map[uint16]Compression),
+		aliases:  make(map[uint16]uint16),
+	}
+}
+
+// Sets pak format version
+func (b *Builder) Version(version Version) *Builder {
+	if err := version.Validate(); b.err == nil && err != nil {
+		b.err = fmt.Errorf("error building pak: %v", err)
+	}
+	b.p.Version = version
+	return b
+}
+
+// Sets text encoding declared by the pak
+func (b *Builder) Encoding(encoding Encoding) *Builder {
+	if err := encoding.Validate(); b.err == nil && err != nil {
+		b.err = fmt.Errorf("error building pak: %v", err)
+	}
+	b.p.Encoding = encoding
+	return b
+}
+
+// Checks that id is usable and not taken
+func (b *Builder) claim(id uint16) bool {
+	if b.err != nil {
+		return false
+	}
+	if id == 0 {
+		b.err = ErrReservedID
+		return false
+	}
+	_, taken := b.p.Resourses[id]
+	if _, alias := b.aliases[id]; taken || alias {
+		b.err = fmt.Errorf("error building pak: resource id=%d added twice", id)
+		return false
+	}
+	return true
+}
+
+// Adds resource with data
+func (b *Builder) AddBytes(id uint16, data []byte) *Builder {
+	if b.claim(id) {
+		b.p.Resourses[id] = data
+	}
+	return b
+}
+
+// Adds resource with text
+func (b *Builder) AddString(id uint16, s string) *Builder {
+	return b.AddBytes(id, []byte(s))
+}
+
+// Adds resource with contents of file
+func (b *Builder) AddFile(id uint16, name string) *Builder {
+	if !b.claim(id) {
+		return b
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.p.Resourses[id] = data
+	return b
+}
+
+// Adds alias sharing data of target, which may be added later
+func (b *Builder) Alias(id, target uint16) *Builder {
+	if b.claim(id) {
+		b.aliases[id] = target
+	}
+	return b
+}
+
+// Marks resources to be gzip compressed when built
+func (b *Builder) Compress(ids ...uint16) *Builder {
+	return b.CompressWith(CompressionGzip, ids...)
+}
+
+// Marks resources to be compressed with c when built. Brotli needs a codec,
+// see RegisterBrotli.
+func (b *Builder) CompressWith(c Compression, ids ...uint16) *Builder {
+	for _, resId := range ids {
+		b.compress[resId] = c
+	}
+	return b
+}
+
+// Returns the built pak. Data of resources marked for compression is
+// compressed unless it already is.
+func (b *Builder) Build() (*PakFile, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	p := &PakFile{Version: b.p.Version, Encoding: b.p.Encoding, Resourses: make(map[uint16][]byte, len(b.p.Resourses)+len(b.aliases))}
+	for resId, resData := range b.p.Resourses {
+		p.Resourses[resId] = resData
+	}
+
+	compressIds := make([]uint16, 0, len(b.compress))
+	for resId := range b.compress {
+		compressIds = append(compressIds, resId)
+	}
+	sortIds(compressIds)
+
+	for _, resId := range compressIds {
+		resData, ok := p.Resourses[resId]
+		if !ok {
+			return nil, fmt.Errorf("error building pak: resource id=%d to compress not added", resId)
+		}
+		if DetectCompression(resData) != CompressionNone {
+			continue
+		}
+		compressed, err := Compress(resData, b.compress[resId])
+		if err != nil {
+			return nil, fmt.Errorf("error compressing resource id=%d: %v", resId, err)
+		}
+		p.Resourses[resId] = compressed
+	}
+
+	for aliasId, target := range b.aliases {
+		targetData, ok := p.Resourses[target]
+		if !ok {
+			return nil, fmt.Errorf("error building pak: alias id=%d points to missing resource id=%d", aliasId, target)
+		}
+		if p.Aliases == nil {
+			p.Aliases = make(map[uint16]uint16)
+		}
+		p.Aliases[aliasId] = target
+		p.Resourses[aliasId] = targetData
+	}
+
+	return p, nil
+}
+
+// Builds pak and writes it to io.Writer
+func (b *Builder) Write(w io.Writer) error {
+	p, err := b.Build()
+	if err != nil {
+		return err
+	}
+	return Write(w, p)
+}
+
+// Builds pak and writes it to file
+func (b *Builder) WriteFile(name string) error {
+	p, err := b.Build()
+	if err != nil {
+		return err
+	}
+	return WriteFile(name, p)
+}
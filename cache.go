@@ -0,0 +1,54 @@
+package pak
+
+import (
+	"container/list"
+)
+
+// Least recently used cache of resource data bounded by total data size
+type lruCache struct {
+	limit   int64
+	size    int64
+	order   *list.List // most recently used first
+	entries map[uint16]*list.Element
+}
+
+type lruEntry struct {
+	id   uint16
+	data []byte
+}
+
+func newLRUCache(limit int64) *lruCache {
+	return &lruCache{limit: limit, order: list.New(), entries: make(map[uint16]*list.Element)}
+}
+
+// Returns cached data, marking it recently used
+func (c *lruCache) get(id uint16) ([]byte, bool) {
+	e, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).data, true
+}
+
+// Caches data, evicting least recently used entries over the limit. Data
+// larger than the limit is not cached.
+func (c *lruCache) put(id uint16, data []byte) {
+	if int64(len(data)) > c.limit {
+		return
+	}
+	if e, ok := c.entries[id]; ok {
+		c.size -= int64(len(e.Value.(*lruEntry).data))
+		c.order.Remove(e)
+	}
+	c.entries[id] = c.order.PushFront(&lruEntry{id, data})
+	c.size += int64(len(data))
+
+	for c.size > c.limit {
+		e := c.order.Back()
+		old := e.Value.(*lruEntry)
+		c.order.Remove(e)
+		delete(c.entries, old.id)
+		c.size -= int64(len(old.data))
+	}
+}
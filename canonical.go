@@ -0,0 +1,102 @@
+package pak
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Converts pak struct to its normal form in place: resources are written in
+// ascending id order without padding or trailing data, and for version 5
+// every group of resources with identical data is stored once, the lowest id
+// holding the data and the others aliasing it. Aliases are dropped for
+// version 4.
+//
+// Writing a canonicalized pak is deterministic: equal contents always produce
+// identical bytes, and reading such a file back and canonicalizing it again
+// yields the same bytes on the next write.
+//
+// Lazy resources are loaded into Resourses first.
+func Canonicalize(p *PakFile) error {
+	err := p.LoadAll()
+	if err != nil {
+		return err
+	}
+	p.Layout = nil
+	p.Aliases = nil
+
+	if p.Version != 5 {
+		return nil
+	}
+
+	ids := sortedIds(p)
+
+	// Groups of ids with equal data hash, lowest id first
+	groups := make(map[[sha256.Size]byte][]uint16)
+	for _, resId := range ids {
+		data := p.Resourses[resId]
+		sum := sha256.Sum256(data)
+
+		target, found := uint16(0), false
+		for _, candidate := range groups[sum] {
+			if bytes.Equal(p.Resourses[candidate], data) {
+				target, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			groups[sum] = append(groups[sum], resId)
+			continue
+		}
+
+		if p.Aliases == nil {
+			p.Aliases = make(map[uint16]uint16)
+		}
+		p.Aliases[resId] = target
+		p.Resourses[resId] = p.Resourses[target]
+	}
+	return nil
+}
+
+// Returns SHA-256 digest of pak contents: version, encoding and data of every
+// resource by id. It does not depend on layout or on which resources are
+// aliases, so paks that Canonicalize makes byte-identical have equal hashes.
+// Lazy resources are read from their source, ones that cannot be read are
+// hashed by location instead.
+func (p *PakFile) Hash() [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte("pak hash 1\x00"))
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint32(buf[:4], uint32(p.Version))
+	buf[4] = uint8(p.Encoding)
+	h.Write(buf[:5])
+
+	ids, _ := p.ListIDs()
+	for _, resId := range ids {
+		data, err := p.Load(resId)
+		if err != nil {
+			// Lazy resource that cannot be read, tagged apart from data
+			lr := p.Lazy[resId]
+			binary.LittleEndian.PutUint16(buf[:2], resId)
+			h.Write(buf[:2])
+			h.Write([]byte{1})
+			binary.LittleEndian.PutUint64(buf[:], uint64(lr.Offset))
+			h.Write(buf[:])
+			binary.LittleEndian.PutUint64(buf[:], uint64(lr.Length))
+			h.Write(buf[:])
+			continue
+		}
+
+		binary.LittleEndian.PutUint16(buf[:2], resId)
+		h.Write(buf[:2])
+		h.Write([]byte{0})
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(data)))
+		h.Write(buf[:])
+		h.Write(data)
+	}
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
@@ -0,0 +1,142 @@
+package pak
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// Largest zero padding accepted between index and data of a carved pak
+const carveMaxPadding = 64 << 10
+
+// Pak found inside a larger blob
+type Candidate struct {
+	Offset    int64 // position of pak in blob
+	Size      int64 // length of pak up to the end of its last resource
+	Version   Version
+	Encoding  Encoding
+	Resources int // number of stored resources
+	Aliases   int
+}
+
+// Reads candidate pak from blob it was found in
+func (c Candidate) Read(r io.ReaderAt) (*PakFile, error) {
+	return Read(io.NewSectionReader(r, c.Offset, c.Size))
+}
+
+// Scans blob of the given size, e.g. a firmware image, installer or memory
+// dump, for embedded paks. Paks have no magic number, so a candidate is
+// reported where a version 4 or 5 header is followed by an index with strictly
+// ascending ids and ascending offsets, a terminator entry and data that fits
+// in the blob. Paks written with custom resource order are not recognized.
+// Scanning resumes after the end of each candidate, paks nested in resources
+// of another pak are not reported.
+func Carve(r io.ReaderAt, size int64) ([]Candidate, error) {
+	var candidates []Candidate
+
+	const chunk = 64 << 10
+	buf := make([]byte, chunk+3)
+
+	for pos := int64(0); pos < size; {
+		n, err := r.ReadAt(buf[:min(int64(len(buf)), size-pos)], pos)
+		if err != nil && err != io.EOF {
+			return candidates, err
+		}
+		window := buf[:n]
+
+		next := pos + int64(max(n-3, 1))
+		for i := 0; i+4 <= len(window); i++ {
+			if (window[i] != 4 && window[i] != 5) || window[i+1] != 0 || window[i+2] != 0 || window[i+3] != 0 {
+				continue
+			}
+			c, ok := carveAt(r, pos+int64(i), size)
+			if !ok {
+				continue
+			}
+			candidates = append(candidates, c)
+			next = c.Offset + c.Size
+			break
+		}
+		pos = next
+	}
+
+	return candidates, nil
+}
+
+// Scans file for embedded paks, see Carve
+func CarveFile(name string) ([]Candidate, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Carve(f, fi.Size())
+}
+
+// Checks whether a plausible pak starts at offset of blob
+func carveAt(r io.ReaderAt, offset, size int64) (Candidate, bool) {
+	avail := uint64(size - offset)
+	br := bufio.NewReaderSize(io.NewSectionReader(r, offset, size-offset), 4096)
+
+	h, err := readHeader(br)
+	if err != nil || Encoding(h.encoding) > EncodingUTF16 || h.padding != [3]byte{} {
+		return Candidate{}, false
+	}
+	if h.resources == 0 || h.indexEnd() > avail {
+		return Candidate{}, false
+	}
+
+	var first, prev resourceInfo
+	for i := uint32(0); i <= h.resources; i++ {
+		ri, err := readResourceInfo(br)
+		if err != nil {
+			return Candidate{}, false
+		}
+		switch {
+		case i == 0:
+			if uint64(ri.offset) < h.indexEnd() || uint64(ri.offset) > h.indexEnd()+carveMaxPadding {
+				return Candidate{}, false
+			}
+			first = ri
+		case i == h.resources:
+			if ri.id != 0 || ri.offset < prev.offset || uint64(ri.offset) > avail {
+				return Candidate{}, false
+			}
+		default:
+			if ri.id <= prev.id || ri.offset < prev.offset {
+				return Candidate{}, false
+			}
+		}
+		prev = ri
+	}
+	end := prev.offset
+
+	var prevAlias aliasInfo
+	for i := uint32(0); i < h.aliases; i++ {
+		ai, err := readAliasInfo(br)
+		if err != nil || uint32(ai.index) >= h.resources || i > 0 && ai.id <= prevAlias.id {
+			return Candidate{}, false
+		}
+		prevAlias = ai
+	}
+
+	padding, err := readBytes(br, uint64(first.offset)-h.indexEnd())
+	if err != nil || len(bytes.Trim(padding, "\x00")) > 0 {
+		return Candidate{}, false
+	}
+
+	return Candidate{
+		Offset:    offset,
+		Size:      int64(end),
+		Version:   Version(h.version),
+		Encoding:  Encoding(h.encoding),
+		Resources: int(h.resources),
+		Aliases:   int(h.aliases),
+	}, true
+}
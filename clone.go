@@ -0,0 +1,77 @@
+package pak
+
+// Returns a deep copy of pak struct. Resource data is copied, aliases in the
+// copy share data with their targets just like in the original.
+func (p *PakFile) Clone() *PakFile {
+	if p == nil {
+		return nil
+	}
+
+	c := &PakFile{
+		Version:   p.Version,
+		Encoding:  p.Encoding,
+		Resourses: make(map[uint16][]byte, len(p.Resourses)),
+	}
+
+	for resId, resData := range p.Resourses {
+		if _, ok := p.Aliases[resId]; ok {
+			continue
+		}
+		c.Resourses[resId] = cloneBytes(resData)
+	}
+
+	if p.Aliases != nil {
+		c.Aliases = make(map[uint16]uint16, len(p.Aliases))
+		for aliasId, target := range p.Aliases {
+			c.Aliases[aliasId] = target
+			resData, ok := p.Resourses[aliasId]
+			if !ok {
+				continue
+			}
+			if targetData, ok := c.Resourses[target]; ok && sameSlice(resData, p.Resourses[target]) {
+				c.Resourses[aliasId] = targetData
+			} else {
+				c.Resourses[aliasId] = cloneBytes(resData)
+			}
+		}
+	}
+
+	if p.Lazy != nil {
+		// Placeholders hold no data, they are shared
+		c.Lazy = make(map[uint16]*LazyResource, len(p.Lazy))
+		for resId, lr := range p.Lazy {
+			c.Lazy[resId] = lr
+		}
+	}
+
+	if p.Symbols != nil {
+		c.Symbols = make(SymbolTable, len(p.Symbols))
+		for name, resId := range p.Symbols {
+			c.Symbols[name] = resId
+		}
+	}
+
+	if l := p.Layout; l != nil {
+		c.Layout = &Layout{
+			Order:         append([]uint16(nil), l.Order...),
+			AliasOrder:    append([]uint16(nil), l.AliasOrder...),
+			HeaderPadding: l.HeaderPadding,
+			Padding:       cloneBytes(l.Padding),
+			Trailer:       cloneBytes(l.Trailer),
+		}
+	}
+
+	return c
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(b)), b...)
+}
+
+// Reports whether a and b refer to the same backing array
+func sameSlice(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/symtab"
+)
+
+func init() {
+	register(&command{
+		name:  "annotate",
+		args:  "file.pak",
+		short: "list or extract resources labeled from the bundled symbol table of the pak's milestone",
+		run:   runAnnotate,
+		json:  true,
+	})
+}
+
+func runAnnotate(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	chromium := fs.Int("chromium", 0, "use bundled table of chromium `milestone` instead of guessing it")
+	cef := fs.Int("cef", 0, "use bundled table of CEF `milestone`")
+	symbols := fs.String("symbols", "", "use grit resource header `file` or bundled table name instead of guessing")
+	dir := fs.String("x", "", "extract resources to `dir`, naming files by symbol")
+	noExt := fs.Bool("no-ext", false, "do not append extensions guessed from content to extracted files")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *chromium != 0 && *cef != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPak(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var table string
+	switch {
+	case *symbols != "":
+		table = *symbols
+		p.Symbols, err = readSymbols(*symbols)
+	case *cef != 0:
+		table = "cef-" + strconv.Itoa(*cef)
+		p.Symbols, err = symtab.Load(table)
+	default:
+		if *chromium == 0 {
+			g, ok := pak.GuessChromiumVersion(p)
+			if !ok {
+				return fmt.Errorf("no known milestone matches %s, set -symbols, -chromium or -cef", fs.Arg(0))
+			}
+			*chromium = g.Milestone
+			fmt.Fprintf(os.Stderr, "guessed m%d (similarity %.2f)\n", g.Milestone, g.Score)
+		}
+		table = "chromium-" + strconv.Itoa(*chromium)
+		p.Symbols, err = symtab.Load(table)
+	}
+	if errors.Is(err, symtab.ErrNoTable) {
+		available := strings.Join(symtab.Versions(), ", ")
+		if available == "" {
+			available = "none"
+		}
+		return fmt.Errorf("no symbol table for %s (available: %s), set -symbols to the grit header of the build", table, available)
+	}
+	if err != nil {
+		return err
+	}
+
+	names := p.Symbols.Names()
+	named := 0
+	for resId := range p.IDs() {
+		if _, ok := names[resId]; ok {
+			named++
+		}
+	}
+
+	if *dir != "" {
+		naming := func(id uint16, data []byte) string {
+			name, ok := names[id]
+			if !ok {
+				name = strconv.Itoa(int(id))
+			}
+			if !*noExt {
+				name += pak.GuessExtension(data, p.Encoding)
+			}
+			return name
+		}
+		err = pak.ExtractDir(p, *dir, &pak.ExtractOptions{Naming: naming})
+		if err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		a := jsonAnnotate{Table: table, Named: named, Resources: []jsonResource{}}
+		for resId := range p.IDs() {
+			a.Resources = append(a.Resources, newJSONResource(p, resId))
+		}
+		return writeJSON(a)
+	}
+
+	if *dir == "" {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(tw, "id\tname\tsize\tcompression\ttype\n")
+		for resId, resData := range p.All() {
+			name, ok := names[resId]
+			if !ok {
+				name = "?"
+			}
+			typ := p.ContentType(resId)
+			if target, ok := p.Aliases[resId]; ok {
+				typ = fmt.Sprintf("alias of %s", label(p, target))
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n", resId, name, len(resData), pak.DetectCompression(resData), typ)
+		}
+		err = tw.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d resources named by %s\n", named, p.Len(), table)
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+	"github.com/disintegration/pak/symtab"
+)
+
+const sampleHeader = "testdata/sample_resources.h"
+
+// Writes paktest sample to a temporary file and returns its name
+func writeSample(t *testing.T) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "resources.pak")
+	err := os.WriteFile(name, paktest.SampleBytes(5, pak.EncodingUTF8), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// Runs annotate with args, returning what it wrote to standard output
+func annotate(t *testing.T, args ...string) string {
+	t.Helper()
+	stdout, stderr := os.Stdout, os.Stderr
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	os.Stdout, os.Stderr = out, out
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		jsonOutput = false
+	}()
+
+	err = runAnnotate(commands["annotate"], args)
+	if err != nil {
+		t.Fatalf("annotate %v: %v", args, err)
+	}
+	out.Seek(0, io.SeekStart)
+	b, err := io.ReadAll(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestAnnotateSymbols(t *testing.T) {
+	out := annotate(t, "-symbols", sampleHeader, writeSample(t))
+	for _, want := range []string{"IDR_SAMPLE_HTML", "IDS_SAMPLE_TEXT", "alias of 100 (IDR_SAMPLE_HTML)", "6 of 8 resources named by " + sampleHeader} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestAnnotateExtract(t *testing.T) {
+	dir := t.TempDir()
+	annotate(t, "-symbols", sampleHeader, "-x", dir, writeSample(t))
+
+	p := paktest.Sample(5, pak.EncodingUTF8)
+	for name, resId := range map[string]uint16{
+		"IDR_SAMPLE_HTML.html": paktest.IDHTML,
+		"IDR_SAMPLE_CSS.txt":   paktest.IDCSS,
+		"IDR_SAMPLE_JS.txt.gz": paktest.IDScript,
+		"IDR_SAMPLE_PNG.png":   paktest.IDImage,
+		"IDS_SAMPLE_TEXT.txt":  paktest.IDText,
+		"105":                  paktest.IDEmpty,
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(data) != string(p.Resourses[resId]) {
+			t.Errorf("%s = %q, want %q", name, data, p.Resourses[resId])
+		}
+	}
+}
+
+func TestAnnotateJSON(t *testing.T) {
+	out := annotate(t, "-json", "-symbols", sampleHeader, writeSample(t))
+
+	var a jsonAnnotate
+	err := json.Unmarshal([]byte(out), &a)
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+	if a.Table != sampleHeader || a.Named != 6 || len(a.Resources) != 8 {
+		t.Errorf("table %q, named %d, %d resources, want %q, 6, 8", a.Table, a.Named, len(a.Resources), sampleHeader)
+	}
+	for _, r := range a.Resources {
+		if r.ID == paktest.IDCSS && r.Name != "IDR_SAMPLE_CSS" {
+			t.Errorf("resource %d named %q, want IDR_SAMPLE_CSS", r.ID, r.Name)
+		}
+	}
+}
+
+func TestAnnotateGuessed(t *testing.T) {
+	const milestone = 9001
+	table, err := pak.ReadSymbolsFile(sampleHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = symtab.Register("chromium-9001", table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pak.RegisterSignature(pak.NewSignature(milestone, paktest.Sample(5, pak.EncodingUTF8)))
+
+	out := annotate(t, writeSample(t))
+	for _, want := range []string{"guessed m9001", "IDR_SAMPLE_PNG", "6 of 8 resources named by chromium-9001"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestAnnotateNoTable(t *testing.T) {
+	name := writeSample(t)
+	for _, args := range [][]string{{"-chromium", "9999", name}, {"-cef", "9999", name}} {
+		err := runAnnotate(commands["annotate"], args)
+		if err == nil || !strings.Contains(err.Error(), "no symbol table for") {
+			t.Errorf("annotate %v: error %v, want no symbol table error", args, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "apply",
+		args:  "file.pak ops.json",
+		short: "apply a JSON list of add/remove/replace operations",
+		run:   runApply,
+	})
+}
+
+func runApply(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	out := fs.String("o", "", "write result to `file` instead of modifying file.pak")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	in, opsName := fs.Arg(0), fs.Arg(1)
+	if *out == "" {
+		*out = in
+	}
+
+	p, err := readPak(in)
+	if err != nil {
+		return err
+	}
+
+	ops, err := readOps(opsName)
+	if err != nil {
+		return err
+	}
+
+	err = pak.ApplyOps(p, ops)
+	if err != nil {
+		return err
+	}
+
+	return writePak(*out, p)
+}
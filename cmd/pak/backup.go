@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "backup",
+		args:  "file.pak...",
+		short: "keep originals of paks before patching them, see restore",
+		run:   runBackup,
+	})
+	register(&command{
+		name:  "restore",
+		args:  "[file.pak...]",
+		short: "restore paks to the originals kept by backup, or list backups",
+		run:   runRestore,
+	})
+}
+
+// Adds -store flag, returning function that opens the chosen backup store
+func storeFlag(fs *flag.FlagSet) func() (*pak.BackupStore, error) {
+	dir := fs.String("store", "", "backup store `dir` (default in the user configuration directory)")
+	return func() (*pak.BackupStore, error) {
+		if *dir != "" {
+			return &pak.BackupStore{Dir: *dir}, nil
+		}
+		return pak.DefaultBackupStore()
+	}
+}
+
+func runBackup(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	openStore := storeFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	for _, name := range fs.Args() {
+		digest, err := s.Backup(name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", digest[:12], name)
+	}
+	return nil
+}
+
+func runRestore(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	openStore := storeFlag(fs)
+	forget := fs.Bool("forget", false, "drop backups instead of restoring, e.g. after a browser update")
+	fs.Parse(args)
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		entries, err := s.Entries()
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Printf("%s %s\n", e.Digest[:12], e.Name)
+		}
+		return nil
+	}
+
+	for _, name := range fs.Args() {
+		if *forget {
+			err = s.Forget(name)
+		} else {
+			err = s.Restore(name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
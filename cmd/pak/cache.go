@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "cache",
+		args:  "[file.pak...]",
+		short: "print directories of decompressed extractions cached by pak content",
+		run:   runCache,
+	})
+}
+
+func runCache(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	dir := fs.String("dir", "", "cache `dir` (default in the user cache directory)")
+	prune := fs.Duration("prune", 0, "first remove extractions unused for `duration`, e.g. 720h")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 && *prune == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	c := &pak.ExtractCache{Dir: *dir}
+	if *dir == "" {
+		var err error
+		c, err = pak.DefaultExtractCache()
+		if err != nil {
+			return err
+		}
+	}
+
+	if *prune != 0 {
+		err := c.Prune(*prune)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range fs.Args() {
+		start := time.Now()
+		cp, err := c.Open(name)
+		if err != nil {
+			return err
+		}
+		if fs.NArg() == 1 {
+			fmt.Println(cp.Dir)
+		} else {
+			fmt.Printf("%s %s (%v)\n", cp.Dir, name, time.Since(start).Round(time.Millisecond))
+		}
+	}
+	return nil
+}
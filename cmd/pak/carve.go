@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "carve",
+		args:  "blob",
+		short: "find paks embedded in a binary such as a firmware image or memory dump",
+		run:   runCarve,
+	})
+}
+
+func runCarve(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	out := fs.String("o", "", "save found paks to `dir`, named by offset")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	r, err := openInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// Carving needs random access, standard input is read into memory
+	f, ok := r.(*os.File)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return carve(bytes.NewReader(data), int64(len(data)), *out)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return carve(f, fi.Size(), *out)
+}
+
+// Prints paks found in r, saving them to dir unless empty
+func carve(r io.ReaderAt, size int64, dir string) error {
+	candidates, err := pak.Carve(r, size)
+	if err != nil {
+		return err
+	}
+
+	if dir != "" {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range candidates {
+		fmt.Printf("offset %d\tsize %d\tversion %d\tresources %d\taliases %d\n", c.Offset, c.Size, c.Version, c.Resources, c.Aliases)
+		if dir == "" {
+			continue
+		}
+		data, err := io.ReadAll(io.NewSectionReader(r, c.Offset, c.Size))
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.pak", c.Offset)), data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
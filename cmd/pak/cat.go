@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "cat",
+		args:  "file.pak id|name",
+		short: "write resource to standard output",
+		run:   runCat,
+	})
+}
+
+func runCat(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	symbols := symbolsFlag(fs)
+	decompress := fs.Bool("d", false, "decompress gzip or brotli compressed resource")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPakWithSymbols(fs.Arg(0), *symbols)
+	if err != nil {
+		return err
+	}
+	resId, err := p.Lookup(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	data, ok := p.Get(resId)
+	if !ok {
+		return fmt.Errorf("resource %s not found", label(p, resId))
+	}
+	if *decompress {
+		data, err = pak.Decompress(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = os.Stdout.Write(data)
+	return err
+}
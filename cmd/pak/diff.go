@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+func init() {
+	register(&command{
+		name:  "diff",
+		args:  "old.pak new.pak",
+		short: "list resources added, removed and changed between two paks",
+		run:   runDiff,
+		json:  true,
+	})
+}
+
+func runDiff(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	symbols := symbolsFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	old, err := readPakWithSymbols(fs.Arg(0), *symbols)
+	if err != nil {
+		return err
+	}
+	new, err := readPakWithSymbols(fs.Arg(1), *symbols)
+	if err != nil {
+		return err
+	}
+
+	d := jsonDiff{Added: []jsonResource{}, Removed: []jsonResource{}, Changed: []jsonChange{}}
+	for resId := range old.IDs() {
+		data, ok := new.Get(resId)
+		if !ok {
+			d.Removed = append(d.Removed, newJSONResource(old, resId))
+			continue
+		}
+		if oldData, _ := old.Get(resId); !bytes.Equal(oldData, data) {
+			d.Changed = append(d.Changed, jsonChange{ID: resId, Name: old.Name(resId), OldSize: len(oldData), NewSize: len(data)})
+		}
+	}
+	for resId := range new.IDs() {
+		if !old.Has(resId) {
+			d.Added = append(d.Added, newJSONResource(new, resId))
+		}
+	}
+
+	if jsonOutput {
+		return writeJSON(d)
+	}
+	for _, r := range d.Removed {
+		fmt.Printf("- %s\n", label(old, r.ID))
+	}
+	for _, c := range d.Changed {
+		fmt.Printf("~ %s: %d -> %d bytes\n", label(old, c.ID), c.OldSize, c.NewSize)
+	}
+	for _, r := range d.Added {
+		fmt.Printf("+ %s: %d bytes %s\n", label(new, r.ID), r.Size, r.Type)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "doctor",
+		args:  "[install-dir]",
+		short: "validate all paks of a Chrome, Chromium, Edge or Electron installation",
+		run:   runDoctor,
+		json:  true,
+	})
+}
+
+func runDoctor(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	var dirs []string
+	switch fs.NArg() {
+	case 0:
+		for _, inst := range pak.FindInstallations() {
+			dirs = append(dirs, inst.Dir)
+		}
+		if len(dirs) == 0 {
+			return errors.New("no installation found, pass its directory")
+		}
+	case 1:
+		dirs = []string{fs.Arg(0)}
+	default:
+		fs.Usage()
+		return errUsage
+	}
+
+	ok := true
+	var reports []jsonDoctor
+	for _, dir := range dirs {
+		r, err := pak.Doctor(dir)
+		if err != nil {
+			return err
+		}
+		ok = ok && r.OK()
+
+		if jsonOutput {
+			reports = append(reports, newJSONDoctor(dir, r))
+			continue
+		}
+		printDoctor(dir, r)
+	}
+
+	if jsonOutput {
+		err := writeJSON(reports)
+		if err != nil {
+			return err
+		}
+	}
+	if !ok {
+		return errors.New("problems found")
+	}
+	return nil
+}
+
+func printDoctor(dir string, r *pak.DoctorReport) {
+	fmt.Printf("%s: %d paks\n", dir, len(r.Paks))
+	errs, warnings := 0, 0
+	for _, path := range append([]string{""}, r.Paks...) {
+		for _, f := range r.Findings[path] {
+			switch f.Severity {
+			case pak.SeverityError:
+				errs++
+			case pak.SeverityWarning:
+				warnings++
+			}
+			if path == "" {
+				fmt.Printf("  %s\n", f)
+			} else {
+				fmt.Printf("  %s: %s\n", path, f)
+			}
+		}
+	}
+	if errs == 0 && warnings == 0 {
+		fmt.Printf("  all paks healthy\n")
+	} else {
+		fmt.Printf("  %d errors, %d warnings\n", errs, warnings)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "dump",
+		args:  "file.pak",
+		short: "print header, index with offsets, alias table and padding",
+		run:   runDump,
+	})
+}
+
+func runDump(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	hexBytes := fs.Int("hex", 0, "show first `n` bytes of each resource in hex")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPak(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return p.Dump(os.Stdout, &pak.DumpOptions{Hex: *hexBytes})
+}
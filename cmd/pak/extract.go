@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "extract",
+		args:  "file.pak dir",
+		short: "extract resources to files named by id",
+		run:   runExtract,
+	})
+}
+
+func runExtract(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	noExt := fs.Bool("no-ext", false, "do not append extensions guessed from content")
+	namesFile := fs.String("names", "", "name files from `file` with \"id name\" lines, other ids are named by id")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPak(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	opts := &pak.ExtractOptions{NoExtensions: *noExt}
+	if *namesFile != "" {
+		names, err := readNames(*namesFile)
+		if err != nil {
+			return err
+		}
+		fallback := pak.NameByContent
+		if *noExt {
+			fallback = pak.NameByID
+		}
+		opts.Naming = pak.NameFromMap(names, fallback)
+	}
+
+	// Carry sidecar metadata over to the directory
+	meta, err := pak.ReadMetadataFile(pak.SidecarName(fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+	if len(meta) > 0 {
+		opts.Metadata = meta
+	}
+
+	return pak.ExtractDir(p, fs.Arg(1), opts)
+}
+
+// Reads "id name" lines, blank lines and lines starting with # are ignored
+func readNames(name string) (map[uint16]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	names := make(map[uint16]string)
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		fields := strings.Fields(s)
+		id, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil || len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"id name\"", name, line)
+		}
+		names[uint16(id)] = fields[1]
+	}
+	return names, sc.Err()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "fingerprint",
+		args:  "file.pak",
+		short: "guess Chromium milestone of a pak or print its signature",
+		run:   runFingerprint,
+		json:  true,
+	})
+}
+
+func runFingerprint(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	milestone := fs.Int("m", 0, "print signature of reference pak for `milestone` instead of guessing")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPak(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *milestone != 0 {
+		s := pak.NewSignature(*milestone, p)
+		if jsonOutput {
+			return writeJSON(jsonFingerprint{Milestone: *milestone, Signature: s.String()})
+		}
+		fmt.Println(s)
+		return nil
+	}
+
+	g, ok := pak.GuessChromiumVersion(p)
+	if !ok {
+		return fmt.Errorf("no known milestone matches %s", fs.Arg(0))
+	}
+	if jsonOutput {
+		return writeJSON(jsonFingerprint{Milestone: g.Milestone, Score: g.Score})
+	}
+	fmt.Printf("m%d (similarity %.2f)\n", g.Milestone, g.Score)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "grd",
+		args:  "file.pak file.grd resources.h",
+		short: "compare pak with the .grd it was built from",
+		run:   runGrd,
+		json:  true,
+	})
+}
+
+func runGrd(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPak(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	g, err := pak.ReadGrdFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	symbols, err := pak.ReadSymbolsFile(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+
+	r := pak.CompareGrd(p, g, symbols)
+	if jsonOutput {
+		err = writeGrdJSON(r)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, name := range r.Missing {
+			fmt.Printf("missing: %s (%d)\n", name, symbols[name])
+		}
+		for _, name := range r.MissingConditional {
+			fmt.Printf("missing conditional: %s (%d)\n", name, symbols[name])
+		}
+		for _, name := range r.Unresolved {
+			fmt.Printf("unresolved: %s\n", name)
+		}
+		for _, resId := range r.Unexpected {
+			fmt.Printf("unexpected: %d\n", resId)
+		}
+		for _, m := range r.Compression {
+			fmt.Printf("compression: %s (%d) is %s, want %s\n", m.Name, m.Id, m.Got, m.Want)
+		}
+	}
+	if !r.OK() {
+		return fmt.Errorf("%s does not match %s", fs.Arg(0), fs.Arg(1))
+	}
+	return nil
+}
+
+func writeGrdJSON(r *pak.GrdReport) error {
+	j := jsonGrd{
+		OK:                 r.OK(),
+		Missing:            nonNil(r.Missing),
+		MissingConditional: nonNil(r.MissingConditional),
+		Unresolved:         nonNil(r.Unresolved),
+		Unexpected:         nonNil(r.Unexpected),
+		Compression:        []jsonGrdMismatch{},
+	}
+	for _, m := range r.Compression {
+		j.Compression = append(j.Compression, jsonGrdMismatch{ID: m.Id, Name: m.Name, Got: m.Got.String(), Want: m.Want.String()})
+	}
+	return writeJSON(j)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "ids",
+		args:  "-r resource_ids -grd file.grd [-grd file.grd...] file.pak",
+		short: "check resource ids of a pak against ranges allocated by GRIT resource_ids",
+		run:   runIds,
+		json:  true,
+	})
+}
+
+// Repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func runIds(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	resourceIDs := fs.String("r", "", "GRIT resource_ids `file`, e.g. tools/gritsettings/resource_ids.spec")
+	var grds stringList
+	fs.Var(&grds, "grd", ".grd `path` the pak is built from, matched as path suffix, repeatable")
+	symbols := symbolsFlag(fs)
+	fs.Parse(args)
+
+	if *resourceIDs == "" || len(grds) == 0 || fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	ids, err := pak.ReadResourceIDsFile(*resourceIDs)
+	if err != nil {
+		return err
+	}
+	p, err := readPakWithSymbols(fs.Arg(0), *symbols)
+	if err != nil {
+		return err
+	}
+
+	r := ids.Check(p, grds...)
+	if jsonOutput {
+		err = writeIdsJSON(r)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, c := range r.Collisions {
+			var owners []string
+			for _, rg := range c {
+				owners = append(owners, rg.Grd+" "+rg.Kind)
+			}
+			fmt.Printf("collision: %d allocated to %s\n", c[0].Start, strings.Join(owners, ", "))
+		}
+		for _, v := range r.Outside {
+			if v.Owner.Grd == "" {
+				fmt.Printf("outside: %s not allocated\n", label(p, v.Id))
+			} else {
+				fmt.Printf("outside: %s in range %d-%d of %s %s\n", label(p, v.Id), v.Owner.Start, v.Owner.Last, v.Owner.Grd, v.Owner.Kind)
+			}
+		}
+	}
+	if !r.OK() {
+		return fmt.Errorf("%s does not match allocations of %s", fs.Arg(0), *resourceIDs)
+	}
+	return nil
+}
+
+func writeIdsJSON(r *pak.AllocationReport) error {
+	j := jsonAllocation{OK: r.OK(), Outside: []jsonViolation{}, Collisions: [][]jsonRange{}}
+	for _, v := range r.Outside {
+		jv := jsonViolation{ID: v.Id, Name: v.Name}
+		if v.Owner.Grd != "" {
+			owner := newJSONRange(v.Owner)
+			jv.Owner = &owner
+		}
+		j.Outside = append(j.Outside, jv)
+	}
+	for _, c := range r.Collisions {
+		var jc []jsonRange
+		for _, rg := range c {
+			jc = append(jc, newJSONRange(rg))
+		}
+		j.Collisions = append(j.Collisions, jc)
+	}
+	return writeJSON(j)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "init",
+		args:  "dir",
+		short: "write resources.grd and resources.h including every file of dir, for pak pack",
+		run:   runInit,
+	})
+}
+
+func runInit(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	symbols := symbolsFlag(fs)
+	start := fs.Uint("start", 1000, "first `id` assigned to files without a symbol")
+	force := fs.Bool("f", false, "overwrite existing resources.grd and resources.h")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *start > 0xffff {
+		fs.Usage()
+		return errUsage
+	}
+	dir := fs.Arg(0)
+
+	var known pak.SymbolTable
+	if *symbols != "" {
+		var err error
+		known, err = readSymbols(*symbols)
+		if err != nil {
+			return err
+		}
+	}
+
+	g, t, err := pak.ScaffoldGrd(dir, known, uint16(*start))
+	if err != nil {
+		return err
+	}
+
+	var grd, header bytes.Buffer
+	pak.WriteGrd(&grd, g)
+	pak.WriteSymbols(&header, t)
+
+	grdName := filepath.Join(dir, "resources.grd")
+	headerName := filepath.Join(dir, "resources.h")
+	if !*force {
+		for _, name := range []string{grdName, headerName} {
+			if _, err := os.Stat(name); err == nil {
+				return fmt.Errorf("%s exists, use -f to overwrite", name)
+			}
+		}
+	}
+	err = os.WriteFile(grdName, grd.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(headerName, header.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d resources, pack with:\npak pack %s %s out.pak\n", len(g.Entries), grdName, headerName)
+	return nil
+}
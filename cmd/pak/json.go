@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/disintegration/pak"
+)
+
+// Set by --json before the command name or by -json of commands supporting it
+var jsonOutput bool
+
+// Writes v to standard output as indented JSON. Field names of the values
+// written are a stable schema, slices are empty rather than null.
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// Resource as listed by list and diff
+type jsonResource struct {
+	ID          uint16  `json:"id"`
+	Name        string  `json:"name,omitempty"`
+	Size        int     `json:"size"`
+	Raw         int64   `json:"raw"`
+	Compression string  `json:"compression"`
+	Type        string  `json:"type"`
+	AliasOf     *uint16 `json:"alias_of,omitempty"`
+}
+
+// Returns resource of p as written by list and diff
+func newJSONResource(p *pak.PakFile, resId uint16) jsonResource {
+	resData := p.Resourses[resId]
+	raw, _ := p.SizeRaw(resId)
+	r := jsonResource{
+		ID:          resId,
+		Name:        p.Name(resId),
+		Size:        len(resData),
+		Raw:         raw,
+		Compression: pak.DetectCompression(resData).String(),
+		Type:        p.ContentType(resId),
+	}
+	if target, ok := p.Aliases[resId]; ok {
+		r.AliasOf = &target
+	}
+	return r
+}
+
+type jsonList struct {
+	Version   uint32         `json:"version"`
+	Encoding  string         `json:"encoding"`
+	Resources []jsonResource `json:"resources"`
+}
+
+type jsonChange struct {
+	ID      uint16 `json:"id"`
+	Name    string `json:"name,omitempty"`
+	OldSize int    `json:"old_size"`
+	NewSize int    `json:"new_size"`
+}
+
+type jsonDiff struct {
+	Added   []jsonResource `json:"added"`
+	Removed []jsonResource `json:"removed"`
+	Changed []jsonChange   `json:"changed"`
+}
+
+type jsonManifestCheck struct {
+	OK       bool     `json:"ok"`
+	Modified []uint16 `json:"modified"`
+	Missing  []uint16 `json:"missing"`
+	Extra    []uint16 `json:"extra"`
+}
+
+type jsonGrdMismatch struct {
+	ID   uint16 `json:"id"`
+	Name string `json:"name"`
+	Got  string `json:"got"`
+	Want string `json:"want"`
+}
+
+type jsonGrd struct {
+	OK                 bool              `json:"ok"`
+	Missing            []string          `json:"missing"`
+	MissingConditional []string          `json:"missing_conditional"`
+	Unresolved         []string          `json:"unresolved"`
+	Unexpected         []uint16          `json:"unexpected"`
+	Compression        []jsonGrdMismatch `json:"compression"`
+}
+
+type jsonFingerprint struct {
+	Milestone int     `json:"milestone"`
+	Score     float64 `json:"score,omitempty"`
+	Signature string  `json:"signature,omitempty"`
+}
+
+// Returns s, or empty slice for nil s so it encodes as []
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+type jsonRange struct {
+	Grd   string `json:"grd"`
+	Kind  string `json:"kind"`
+	Start uint16 `json:"start"`
+	Last  uint16 `json:"last"`
+}
+
+func newJSONRange(r pak.IDRange) jsonRange {
+	return jsonRange{Grd: r.Grd, Kind: r.Kind, Start: r.Start, Last: r.Last}
+}
+
+type jsonViolation struct {
+	ID    uint16     `json:"id"`
+	Name  string     `json:"name,omitempty"`
+	Owner *jsonRange `json:"owner,omitempty"`
+}
+
+type jsonAllocation struct {
+	OK         bool            `json:"ok"`
+	Outside    []jsonViolation `json:"outside"`
+	Collisions [][]jsonRange   `json:"collisions"`
+}
+
+type jsonLocaleDiff struct {
+	Locale  string   `json:"locale"`
+	Missing []uint16 `json:"missing"`
+	Extra   []uint16 `json:"extra"`
+	Same    []uint16 `json:"same"`
+}
+
+type jsonPlaceholderMismatch struct {
+	ID      uint16   `json:"id"`
+	Missing []string `json:"missing"`
+	Extra   []string `json:"extra"`
+}
+
+type jsonLocaleSize struct {
+	Locale  string `json:"locale"`
+	Count   int    `json:"count"`
+	Stored  int64  `json:"stored"`
+	Written int64  `json:"written"`
+}
+
+// Returns keys of m, sorted
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type jsonDoctor struct {
+	Dir      string              `json:"dir"`
+	OK       bool                `json:"ok"`
+	Paks     []string            `json:"paks"`
+	Findings map[string][]string `json:"findings"`
+}
+
+func newJSONDoctor(dir string, r *pak.DoctorReport) jsonDoctor {
+	j := jsonDoctor{Dir: dir, OK: r.OK(), Paks: nonNil(r.Paks), Findings: map[string][]string{}}
+	for path, fs := range r.Findings {
+		for _, f := range fs {
+			j.Findings[path] = append(j.Findings[path], f.String())
+		}
+	}
+	return j
+}
+
+type jsonInstallation struct {
+	Product   string            `json:"product"`
+	Dir       string            `json:"dir"`
+	Resources string            `json:"resources"`
+	Scale100  string            `json:"scale_100,omitempty"`
+	Scale200  string            `json:"scale_200,omitempty"`
+	Locales   map[string]string `json:"locales"`
+}
+
+type jsonAnnotate struct {
+	Table     string         `json:"table"`
+	Named     int            `json:"named"`
+	Resources []jsonResource `json:"resources"`
+}
+
+type jsonVerify struct {
+	File     string   `json:"file"`
+	OK       bool     `json:"ok"`
+	Findings []string `json:"findings"`
+}
+
+func newJSONVerify(name string, fs []pak.Finding) jsonVerify {
+	j := jsonVerify{File: name, OK: !pak.HasErrors(fs), Findings: []string{}}
+	for _, f := range fs {
+		j.Findings = append(j.Findings, f.String())
+	}
+	return j
+}
+
+type jsonResourceSize struct {
+	ID     uint16 `json:"id"`
+	Stored int64  `json:"stored"`
+	Raw    int64  `json:"raw"`
+}
+
+type jsonTypeStats struct {
+	Type    string             `json:"type"`
+	Count   int                `json:"count"`
+	Stored  int64              `json:"stored"`
+	Raw     int64              `json:"raw"`
+	Largest []jsonResourceSize `json:"largest"`
+}
+
+type jsonStats struct {
+	Count   int             `json:"count"`
+	Aliases int             `json:"aliases"`
+	Stored  int64           `json:"stored"`
+	Raw     int64           `json:"raw"`
+	Types   []jsonTypeStats `json:"types"`
+}
+
+func newJSONStats(r *pak.SizeReport) jsonStats {
+	j := jsonStats{Count: r.Count, Aliases: r.Aliases, Stored: r.Stored, Raw: r.Raw, Types: []jsonTypeStats{}}
+	for _, ts := range r.Types {
+		jt := jsonTypeStats{Type: ts.Type, Count: ts.Count, Stored: ts.Stored, Raw: ts.Raw, Largest: []jsonResourceSize{}}
+		for _, rs := range ts.Largest {
+			jt.Largest = append(jt.Largest, jsonResourceSize{ID: rs.Id, Stored: rs.Stored, Raw: rs.Raw})
+		}
+		j.Types = append(j.Types, jt)
+	}
+	return j
+}
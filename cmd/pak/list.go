@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "list",
+		args:  "file.pak",
+		short: "list resources with stored and decompressed sizes and content types",
+		run:   runList,
+		json:  true,
+	})
+}
+
+func runList(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	symbols := symbolsFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPakWithSymbols(fs.Arg(0), *symbols)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int, 0, len(p.Resourses))
+	for resId := range p.Resourses {
+		ids = append(ids, int(resId))
+	}
+	sort.Ints(ids)
+
+	if jsonOutput {
+		l := jsonList{Version: uint32(p.Version), Encoding: p.Encoding.String(), Resources: []jsonResource{}}
+		for _, id := range ids {
+			l.Resources = append(l.Resources, newJSONResource(p, uint16(id)))
+		}
+		return writeJSON(l)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "id\tsize\traw\tcompression\ttype")
+	if p.Symbols != nil {
+		fmt.Fprintf(tw, "\tname")
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, id := range ids {
+		resId := uint16(id)
+		resData := p.Resourses[resId]
+		typ := p.ContentType(resId)
+		if target, ok := p.Aliases[resId]; ok {
+			typ = fmt.Sprintf("alias of %d", target)
+		}
+		raw, _ := p.SizeRaw(resId)
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s", resId, len(resData), raw, pak.DetectCompression(resData), typ)
+		if p.Symbols != nil {
+			fmt.Fprintf(tw, "\t%s", p.Name(resId))
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+	return tw.Flush()
+}
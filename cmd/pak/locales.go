@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "locales",
+		args:  "(diff | verify | placeholders | icu | set | sizes) [flags] dir",
+		short: "compare, verify, edit and measure every pak of a locales directory at once",
+		run:   runLocales,
+		json:  true,
+	})
+}
+
+func runLocales(cmd *command, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "diff":
+			sub := &command{name: "locales diff", args: "dir", short: "list strings each locale lacks, adds or leaves as in the base locale", json: true}
+			return runLocalesDiff(sub, args[1:])
+		case "verify":
+			sub := &command{name: "locales verify", args: "dir", short: "check structure of every locale pak", json: true}
+			return runLocalesVerify(sub, args[1:])
+		case "placeholders":
+			sub := &command{name: "locales placeholders", args: "dir", short: "list strings whose placeholders differ from the base locale", json: true}
+			return runLocalesPlaceholders(sub, args[1:])
+		case "icu":
+			sub := &command{name: "locales icu", args: "dir", short: "check ICU plural and select syntax of strings of every locale", json: true}
+			return runLocalesICU(sub, args[1:])
+		case "set":
+			sub := &command{name: "locales set", args: "dir id text", short: "set string in every locale pak"}
+			return runLocalesSet(sub, args[1:])
+		case "sizes":
+			sub := &command{name: "locales sizes", args: "dir", short: "print resource count and size of every locale pak", json: true}
+			return runLocalesSizes(sub, args[1:])
+		}
+	}
+	fs := cmd.flagSet()
+	fs.Parse(args)
+	fs.Usage()
+	return errUsage
+}
+
+func runLocalesDiff(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	base := fs.String("base", "en-US", "`locale` others are compared with")
+	same := fs.Bool("same", false, "list strings identical to the base locale too")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	l, err := pak.ReadLocales(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	diffs, err := l.Diff(*base)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		j := []jsonLocaleDiff{}
+		for _, d := range diffs {
+			j = append(j, jsonLocaleDiff{Locale: d.Locale, Missing: nonNil(d.Missing), Extra: nonNil(d.Extra), Same: nonNil(d.Same)})
+		}
+		return writeJSON(j)
+	}
+	for _, d := range diffs {
+		for _, resId := range d.Missing {
+			fmt.Printf("%s: missing %d\n", d.Locale, resId)
+		}
+		for _, resId := range d.Extra {
+			fmt.Printf("%s: extra %d\n", d.Locale, resId)
+		}
+		if *same {
+			for _, resId := range d.Same {
+				fmt.Printf("%s: same %d\n", d.Locale, resId)
+			}
+		}
+	}
+	return nil
+}
+
+func runLocalesVerify(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	findings, err := pak.ValidateLocales(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, lf := range findings {
+		if pak.HasErrors(lf) {
+			failed++
+		}
+	}
+	return printLocaleFindings(findings, failed)
+}
+
+// Prints findings by locale, returning an error when failed is not zero
+func printLocaleFindings(findings map[string][]pak.Finding, failed int) error {
+	if jsonOutput {
+		j := map[string][]string{}
+		for locale, lf := range findings {
+			j[locale] = []string{}
+			for _, f := range lf {
+				j[locale] = append(j[locale], f.String())
+			}
+		}
+		err := writeJSON(j)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, locale := range sortedKeys(findings) {
+			for _, f := range findings[locale] {
+				fmt.Printf("%s: %s\n", locale, f)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d locales failed", failed)
+	}
+	return nil
+}
+
+func runLocalesPlaceholders(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	base := fs.String("base", "en-US", "`locale` others are compared with")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	l, err := pak.ReadLocales(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	mismatches, err := l.CheckPlaceholders(*base)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	j := map[string][]jsonPlaceholderMismatch{}
+	for _, locale := range sortedKeys(mismatches) {
+		for _, m := range mismatches[locale] {
+			total++
+			if jsonOutput {
+				j[locale] = append(j[locale], jsonPlaceholderMismatch{ID: m.Id, Missing: nonNil(m.Missing), Extra: nonNil(m.Extra)})
+				continue
+			}
+			for _, ph := range m.Missing {
+				fmt.Printf("%s: %d: missing %s\n", locale, m.Id, ph)
+			}
+			for _, ph := range m.Extra {
+				fmt.Printf("%s: %d: extra %s\n", locale, m.Id, ph)
+			}
+		}
+	}
+	if jsonOutput {
+		err = writeJSON(j)
+		if err != nil {
+			return err
+		}
+	}
+	if total > 0 {
+		return fmt.Errorf("%d strings with mismatched placeholders", total)
+	}
+	return nil
+}
+
+func runLocalesICU(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	l, err := pak.ReadLocales(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	findings := l.CheckICU()
+	return printLocaleFindings(findings, len(findings))
+}
+
+func runLocalesSet(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	out := fs.String("o", "", "write paks to `dir` instead of replacing them")
+	fs.Parse(args)
+
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return errUsage
+	}
+	resId, err := strconv.ParseUint(fs.Arg(1), 10, 16)
+	if err != nil {
+		return fmt.Errorf("bad resource id %s", fs.Arg(1))
+	}
+
+	l, err := pak.ReadLocales(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	err = l.SetString(uint16(resId), fs.Arg(2))
+	if err != nil {
+		return err
+	}
+
+	dir := fs.Arg(0)
+	if *out != "" {
+		dir = *out
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	return l.WriteDir(dir)
+}
+
+func runLocalesSizes(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	l, err := pak.ReadLocales(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sizes, err := l.Sizes()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		j := []jsonLocaleSize{}
+		for _, s := range sizes {
+			j = append(j, jsonLocaleSize{Locale: s.Locale, Count: s.Count, Stored: s.Stored, Written: s.Written})
+		}
+		return writeJSON(j)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "locale\tresources\tstored\tfile\t\n")
+	var total int64
+	for _, s := range sizes {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t\n", s.Locale, s.Count, s.Stored, s.Written)
+		total += s.Written
+	}
+	fmt.Fprintf(tw, "total\t\t\t%d\t\n", total)
+	return tw.Flush()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "locate",
+		args:  "[install-dir]",
+		short: "print paths of resources.pak, scale paks and locale paks of installations",
+		run:   runLocate,
+		json:  true,
+	})
+}
+
+func runLocate(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	var found []*pak.Installation
+	switch fs.NArg() {
+	case 0:
+		found = pak.FindInstallations()
+		if len(found) == 0 {
+			return errors.New("no installation found")
+		}
+	case 1:
+		inst, err := pak.LocateInstallation(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		found = append(found, inst)
+	default:
+		fs.Usage()
+		return errUsage
+	}
+
+	if jsonOutput {
+		j := []jsonInstallation{}
+		for _, inst := range found {
+			j = append(j, jsonInstallation{
+				Product:   inst.Product,
+				Dir:       inst.Dir,
+				Resources: inst.Resources,
+				Scale100:  inst.Scale100,
+				Scale200:  inst.Scale200,
+				Locales:   inst.Locales,
+			})
+		}
+		return writeJSON(j)
+	}
+
+	for _, inst := range found {
+		product := inst.Product
+		if product == "" {
+			product = "unknown"
+		}
+		fmt.Printf("%s in %s\n", product, inst.Dir)
+		fmt.Printf("  resources: %s\n", inst.Resources)
+		if inst.Scale100 != "" {
+			fmt.Printf("  100%%:      %s\n", inst.Scale100)
+		}
+		if inst.Scale200 != "" {
+			fmt.Printf("  200%%:      %s\n", inst.Scale200)
+		}
+		fmt.Printf("  locales:   %d\n", len(inst.Locales))
+	}
+	return nil
+}
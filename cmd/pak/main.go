@@ -0,0 +1,117 @@
+// Command pak inspects and modifies chromium .pak resource files.
+//
+// Usage:
+//
+//	pak [--json] <command> [flags] [arguments]
+//
+// With --json, commands that support it (list, diff, manifest, grd, ids,
+// locales, doctor, locate, fingerprint and annotate) print JSON instead of
+// text.
+//
+// A "-" in place of a pak, ops, whitelist or file list argument reads it from
+// standard input, a "-" output pak is written to standard output, e.g.
+//
+//	find res -type f | pak pack -filelist - - | pak recompress - out.pak
+//
+// Run "pak help <command>" for details on a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	name  string
+	args  string // arguments synopsis
+	short string // one line description
+	json  bool   // supports --json
+	run   func(cmd *command, args []string) error
+}
+
+// Returned by commands called with wrong arguments after printing usage
+var errUsage = errors.New("usage")
+
+var commands = map[string]*command{}
+
+func register(cmd *command) {
+	commands[cmd.name] = cmd
+}
+
+// Returns flag set printing command usage
+func (cmd *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pak %s [flags] %s\n\n%s.\n", cmd.name, cmd.args, cmd.short)
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if cmd.json {
+		fs.BoolVar(&jsonOutput, "json", jsonOutput, "print JSON instead of text")
+	}
+	return fs
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "Usage: pak [--json] <command> [flags] [arguments]\n\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].short)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun \"pak help <command>\" for details on a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+	if name == "--json" || name == "-json" {
+		if len(args) == 0 {
+			usage()
+			os.Exit(2)
+		}
+		jsonOutput = true
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" || name == "-h" || name == "--help" {
+		if len(args) > 0 && commands[args[0]] != nil {
+			cmd := commands[args[0]]
+			cmd.run(cmd, []string{"-h"}) // prints usage with flags and exits
+			return
+		}
+		usage()
+		return
+	}
+
+	cmd := commands[name]
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "pak: unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+	if jsonOutput && !cmd.json {
+		fmt.Fprintf(os.Stderr, "pak %s: --json is not supported\n", cmd.name)
+		os.Exit(2)
+	}
+
+	err := cmd.run(cmd, args)
+	if err == errUsage {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pak %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
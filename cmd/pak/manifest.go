@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "manifest",
+		args:  "file.pak",
+		short: "print SHA-256 digests of resources or check them against a manifest",
+		run:   runManifest,
+		json:  true,
+	})
+}
+
+func runManifest(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	check := fs.String("c", "", "check resources against manifest `file` instead of printing")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPak(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *check == "" {
+		m, err := pak.NewManifest(p)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			digests := make(map[uint16]string, len(m))
+			for resId, sum := range m {
+				digests[resId] = hex.EncodeToString(sum[:])
+			}
+			return writeJSON(digests)
+		}
+		return pak.WriteManifest(os.Stdout, m)
+	}
+
+	m, err := pak.ReadManifestFile(*check)
+	if err != nil {
+		return err
+	}
+
+	r, err := pak.VerifyManifest(p, m)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		err = writeJSON(jsonManifestCheck{OK: r.OK(), Modified: nonNil(r.Modified), Missing: nonNil(r.Missing), Extra: nonNil(r.Extra)})
+		if err != nil {
+			return err
+		}
+		if !r.OK() {
+			return fmt.Errorf("%s does not match manifest", fs.Arg(0))
+		}
+		return nil
+	}
+	for _, resId := range r.Modified {
+		fmt.Printf("%d modified\n", resId)
+	}
+	for _, resId := range r.Missing {
+		fmt.Printf("%d missing\n", resId)
+	}
+	for _, resId := range r.Extra {
+		fmt.Printf("%d extra\n", resId)
+	}
+	if !r.OK() {
+		return fmt.Errorf("%s does not match manifest", fs.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "meta",
+		args:  "file.pak [id]",
+		short: "show or edit names, tags and comments of resources kept next to the pak",
+		run:   runMeta,
+	})
+}
+
+func runMeta(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	name := fs.String("name", "", "set resource `name`")
+	tags := fs.String("tag", "", "add comma separated `tags`")
+	comment := fs.String("comment", "", "set resource `comment`")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 && fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	sidecar := pak.SidecarName(fs.Arg(0))
+
+	meta, err := pak.ReadMetadataFile(sidecar)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 1 {
+		p, err := readPak(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return printMeta(p, meta)
+	}
+
+	resId, err := strconv.ParseUint(fs.Arg(1), 10, 16)
+	if err != nil {
+		return fmt.Errorf("bad resource id %q", fs.Arg(1))
+	}
+	id := uint16(resId)
+
+	if *name == "" && *tags == "" && *comment == "" {
+		rm := meta[id]
+		if rm == nil {
+			return nil
+		}
+		fmt.Printf("name: %s\ntags: %s\ncomment: %s\n", rm.Name, strings.Join(rm.Tags, ", "), rm.Comment)
+		return nil
+	}
+
+	rm := meta.Get(id)
+	if *name != "" {
+		rm.Name = *name
+	}
+	if *comment != "" {
+		rm.Comment = *comment
+	}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			meta.Tag(id, tag)
+		}
+	}
+	return pak.WriteMetadataFile(sidecar, meta)
+}
+
+// Prints annotations of resources of pak
+func printMeta(p *pak.PakFile, meta pak.Metadata) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "id\tname\ttags\tcomment\n")
+	for resId := range p.IDs() {
+		rm := meta[resId]
+		if rm == nil {
+			continue
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", resId, rm.Name, strings.Join(rm.Tags, ","), rm.Comment)
+	}
+	return tw.Flush()
+}
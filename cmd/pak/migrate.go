@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "migrate",
+		args:  "file.pak",
+		short: "renumber resources from one milestone's ids to another's",
+		run:   runMigrate,
+	})
+}
+
+func runMigrate(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	out := fs.String("o", "", "write result to `file` instead of modifying file.pak")
+	tableName := fs.String("table", "", "read translation table with \"NAME old new\" lines from `file`")
+	from := fs.String("from", "", "grit resource header or bundled table of the old milestone, used with -to instead of -table")
+	to := fs.String("to", "", "grit resource header or bundled table of the new milestone")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || (*tableName == "") == (*from == "" || *to == "") {
+		fs.Usage()
+		return errUsage
+	}
+	in := fs.Arg(0)
+	if *out == "" {
+		*out = in
+	}
+
+	var table pak.TranslationTable
+	if *tableName != "" {
+		t, err := pak.ReadTranslationTableFile(*tableName)
+		if err != nil {
+			return err
+		}
+		table = t
+	} else {
+		fromSymbols, err := readSymbols(*from)
+		if err != nil {
+			return err
+		}
+		toSymbols, err := readSymbols(*to)
+		if err != nil {
+			return err
+		}
+		table = pak.NewTranslationTable(fromSymbols, toSymbols)
+	}
+
+	p, err := readPak(in)
+	if err != nil {
+		return err
+	}
+
+	r, err := pak.Migrate(p, table)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d moved, %d removed, %d unknown kept\n", r.Moved, len(r.Removed), len(r.Unknown))
+
+	return writePak(*out, p)
+}
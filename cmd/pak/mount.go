@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "mount",
+		args:  "file.pak mountpoint",
+		short: "mount pak as read-only file system until interrupted (built with -tags fuse)",
+		run:   runMount,
+	})
+}
+
+func runMount(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	symbols := symbolsFlag(fs)
+	raw := fs.Bool("raw", false, "serve stored data instead of decompressing resources")
+	noExt := fs.Bool("no-ext", false, "do not append extensions guessed from content")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPakWithSymbols(fs.Arg(0), *symbols)
+	if err != nil {
+		return err
+	}
+	files := newMountFiles(p, *raw, *noExt)
+
+	m, err := mountFUSE(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		m.unmount()
+	}()
+
+	fmt.Fprintf(os.Stderr, "mounted %s on %s, interrupt to unmount\n", fs.Arg(0), fs.Arg(1))
+	return m.serve(files)
+}
+
+// Resource exposed as file of mounted pak
+type mountFile struct {
+	id   uint16
+	name string
+	data []byte
+}
+
+// Files of mounted pak in ascending id order, looked up by name
+type mountFiles struct {
+	list   []*mountFile
+	byName map[string]*mountFile
+	byId   map[uint16]*mountFile
+}
+
+// Names files by symbol or id with extension guessed from the served data.
+// Resources that fail to decompress, e.g. brotli ones without a codec or
+// encrypted ones, are served as stored.
+func newMountFiles(p *pak.PakFile, raw, noExt bool) *mountFiles {
+	naming := pak.NameByContent
+	if noExt {
+		naming = pak.NameByID
+	}
+	naming = pak.NameFromMap(p.Symbols.Names(), naming)
+
+	m := &mountFiles{byName: make(map[string]*mountFile), byId: make(map[uint16]*mountFile)}
+	for resId, resData := range p.All() {
+		if !raw {
+			if data, err := pak.Decompress(resData); err == nil {
+				resData = data
+			}
+		}
+		name := naming(resId, resData)
+		if _, ok := m.byName[name]; ok {
+			name = fmt.Sprintf("%s.%d", name, resId)
+		}
+		f := &mountFile{id: resId, name: name, data: resData}
+		m.list = append(m.list, f)
+		m.byName[name] = f
+		m.byId[resId] = f
+	}
+	return m
+}
@@ -0,0 +1,121 @@
+//go:build fuse && darwin
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// macFUSE adds creation time and file flags to fuse_attr
+const fuseAttrLength = 104
+
+// Mount helpers of macFUSE and of its predecessor osxfuse
+var macFUSEHelpers = []string{
+	"/Library/Filesystems/macfuse.fs/Contents/Resources/mount_macfuse",
+	"/Library/Filesystems/osxfuse.fs/Contents/Resources/mount_osxfuse",
+}
+
+// Mounts empty FUSE file system on dir through the mount helper of macFUSE,
+// which opens the device and passes its descriptor back over the socket
+// named by $_FUSE_COMMFD. The helper exits once the file system answered
+// INIT, so it is waited for in the background.
+func mountFUSE(dir string) (*fuseMount, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var helper string
+	for _, name := range macFUSEHelpers {
+		if _, err := os.Stat(name); err == nil {
+			helper = name
+			break
+		}
+	}
+	if helper == "" {
+		return nil, fmt.Errorf("error mounting %s: macFUSE not installed", dir)
+	}
+
+	pair, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	local, remote := os.NewFile(uintptr(pair[0]), "fuse"), os.NewFile(uintptr(pair[1]), "mount_macfuse")
+	defer local.Close()
+
+	c := exec.Command(helper, "-o", "ro,fsname=pak,volname=pak", dir)
+	c.ExtraFiles = []*os.File{remote}
+	c.Env = append(os.Environ(),
+		"_FUSE_CALL_BY_LIB=",
+		"_FUSE_DAEMON_PATH="+os.Args[0],
+		"_FUSE_COMMFD=3",
+		"_FUSE_COMMVERS=2",
+		"MOUNT_OSXFUSE_CALL_BY_LIB=",
+		"MOUNT_OSXFUSE_DAEMON_PATH="+os.Args[0],
+	)
+	c.Stderr = os.Stderr
+	err = c.Start()
+	remote.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error mounting %s: %v", dir, err)
+	}
+
+	buf, oob := make([]byte, 4), make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := syscall.Recvmsg(int(local.Fd()), buf, oob, 0)
+	if err != nil {
+		c.Wait()
+		return nil, fmt.Errorf("error mounting %s: %v", dir, err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) == 0 {
+		c.Wait()
+		return nil, fmt.Errorf("error mounting %s: no descriptor from %s", dir, filepath.Base(helper))
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) == 0 {
+		c.Wait()
+		return nil, fmt.Errorf("error mounting %s: no descriptor from %s", dir, filepath.Base(helper))
+	}
+	syscall.CloseOnExec(fds[0])
+
+	go func() {
+		if err := c.Wait(); err != nil {
+			fmt.Fprintf(os.Stderr, "error mounting %s: %s: %v\n", dir, filepath.Base(helper), err)
+		}
+	}()
+	return &fuseMount{fd: fds[0], dir: dir, helper: helper, started: time.Now()}, nil
+}
+
+// Unmounts file system, making serve return. Safe to call more than once.
+func (m *fuseMount) unmount() {
+	m.once.Do(func() {
+		syscall.Unmount(m.dir, 0)
+	})
+}
+
+// Fills fuse_attr of root directory for nil f, of file otherwise
+func (m *fuseMount) putAttr(b []byte, node uint64, f *mountFile) {
+	mode, nlink, size := uint32(syscall.S_IFDIR|0555), uint32(2), uint64(0)
+	if f != nil {
+		mode, nlink, size = syscall.S_IFREG|0444, 1, uint64(len(f.data))
+	}
+	t := uint64(m.started.Unix())
+	binary.LittleEndian.PutUint64(b[0:], node)
+	binary.LittleEndian.PutUint64(b[8:], size)
+	binary.LittleEndian.PutUint64(b[16:], (size+511)/512)
+	binary.LittleEndian.PutUint64(b[24:], t)
+	binary.LittleEndian.PutUint64(b[32:], t)
+	binary.LittleEndian.PutUint64(b[40:], t)
+	binary.LittleEndian.PutUint64(b[48:], t) // crtime
+	binary.LittleEndian.PutUint32(b[72:], mode)
+	binary.LittleEndian.PutUint32(b[76:], nlink)
+	binary.LittleEndian.PutUint32(b[80:], uint32(os.Getuid()))
+	binary.LittleEndian.PutUint32(b[84:], uint32(os.Getgid()))
+	binary.LittleEndian.PutUint32(b[96:], 4096)
+}
@@ -0,0 +1,263 @@
+//go:build fuse && (linux || darwin)
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Minimal server of the kernel FUSE protocol, enough for a read-only flat
+// directory. Layouts follow include/uapi/linux/fuse.h, macFUSE speaks the
+// same protocol with a longer fuse_attr, see putAttr.
+
+const (
+	fuseLookup      = 1
+	fuseForget      = 2
+	fuseGetattr     = 3
+	fuseOpen        = 14
+	fuseRead        = 15
+	fuseStatfs      = 17
+	fuseRelease     = 18
+	fuseFlush       = 25
+	fuseInit        = 26
+	fuseOpendir     = 27
+	fuseReaddir     = 28
+	fuseReleasedir  = 29
+	fuseAccess      = 34
+	fuseInterrupt   = 36
+	fuseDestroy     = 38
+	fuseBatchForget = 42
+)
+
+const (
+	fuseInHeaderLength  = 40
+	fuseOutHeaderLength = 16
+	fuseMaxWrite        = 128 << 10
+	fuseRootNode        = 1
+	fuseKeepCache       = 1 << 1 // FOPEN_KEEP_CACHE
+	fuseAttrValid       = uint64(time.Hour / time.Second)
+)
+
+// Mounted FUSE file system
+type fuseMount struct {
+	fd      int
+	dir     string
+	helper  string // mount helper, empty for mount(2)
+	once    sync.Once
+	started time.Time
+}
+
+// Answers kernel requests with files until the file system is unmounted
+func (m *fuseMount) serve(files *mountFiles) error {
+	defer syscall.Close(m.fd)
+	defer m.unmount()
+
+	buf := make([]byte, fuseMaxWrite+4096)
+	for {
+		n, err := syscall.Read(m.fd, buf)
+		switch {
+		case err == syscall.EINTR || err == syscall.EAGAIN || err == syscall.ENOENT:
+			continue
+		case err == syscall.ENODEV:
+			return nil
+		case err != nil:
+			return fmt.Errorf("error reading FUSE request: %v", err)
+		case n < fuseInHeaderLength:
+			return fmt.Errorf("error reading FUSE request: short header")
+		}
+		if !m.handle(buf[:n], files) {
+			return nil
+		}
+	}
+}
+
+// Answers request, returning false when the kernel tears the mount down
+func (m *fuseMount) handle(req []byte, files *mountFiles) bool {
+	opcode := binary.LittleEndian.Uint32(req[4:])
+	unique := binary.LittleEndian.Uint64(req[8:])
+	node := binary.LittleEndian.Uint64(req[16:])
+	body := req[fuseInHeaderLength:]
+
+	switch opcode {
+	case fuseForget, fuseBatchForget, fuseInterrupt:
+		// no reply expected
+
+	case fuseInit:
+		if len(body) < 16 {
+			m.reply(unique, syscall.EIO, nil)
+			break
+		}
+		major, minor := binary.LittleEndian.Uint32(body), binary.LittleEndian.Uint32(body[4:])
+		if major < 7 {
+			m.reply(unique, syscall.EPROTO, nil)
+			break
+		}
+		out := make([]byte, 64)
+		binary.LittleEndian.PutUint32(out[0:], 7)
+		binary.LittleEndian.PutUint32(out[4:], min(minor, 31))
+		copy(out[8:12], body[8:12]) // max_readahead
+		binary.LittleEndian.PutUint32(out[20:], fuseMaxWrite)
+		if major == 7 && minor < 23 {
+			out = out[:24]
+		}
+		m.reply(unique, 0, out)
+
+	case fuseLookup:
+		name, _, _ := bytes.Cut(body, []byte{0})
+		f, ok := files.byName[string(name)]
+		if node != fuseRootNode || !ok {
+			m.reply(unique, syscall.ENOENT, nil)
+			break
+		}
+		out := make([]byte, 40+fuseAttrLength)
+		binary.LittleEndian.PutUint64(out[0:], fileNode(f))
+		binary.LittleEndian.PutUint64(out[16:], fuseAttrValid)
+		binary.LittleEndian.PutUint64(out[24:], fuseAttrValid)
+		m.putAttr(out[40:], fileNode(f), f)
+		m.reply(unique, 0, out)
+
+	case fuseGetattr:
+		var f *mountFile
+		if node != fuseRootNode {
+			f = files.node(node)
+			if f == nil {
+				m.reply(unique, syscall.ENOENT, nil)
+				break
+			}
+		}
+		out := make([]byte, 16+fuseAttrLength)
+		binary.LittleEndian.PutUint64(out[0:], fuseAttrValid)
+		m.putAttr(out[16:], node, f)
+		m.reply(unique, 0, out)
+
+	case fuseOpen:
+		if files.node(node) == nil {
+			m.reply(unique, syscall.EISDIR, nil)
+			break
+		}
+		if len(body) >= 4 && binary.LittleEndian.Uint32(body)&syscall.O_ACCMODE != syscall.O_RDONLY {
+			m.reply(unique, syscall.EROFS, nil)
+			break
+		}
+		out := make([]byte, 16)
+		binary.LittleEndian.PutUint32(out[8:], fuseKeepCache)
+		m.reply(unique, 0, out)
+
+	case fuseOpendir:
+		if node != fuseRootNode {
+			m.reply(unique, syscall.ENOTDIR, nil)
+			break
+		}
+		m.reply(unique, 0, make([]byte, 16))
+
+	case fuseRead:
+		f := files.node(node)
+		if f == nil || len(body) < 20 {
+			m.reply(unique, syscall.EIO, nil)
+			break
+		}
+		off := binary.LittleEndian.Uint64(body[8:])
+		size := uint64(binary.LittleEndian.Uint32(body[16:]))
+		if off >= uint64(len(f.data)) {
+			m.reply(unique, 0, nil)
+			break
+		}
+		m.reply(unique, 0, f.data[off:min(off+size, uint64(len(f.data)))])
+
+	case fuseReaddir:
+		if node != fuseRootNode || len(body) < 20 {
+			m.reply(unique, syscall.ENOTDIR, nil)
+			break
+		}
+		off := binary.LittleEndian.Uint64(body[8:])
+		size := int(binary.LittleEndian.Uint32(body[16:]))
+		m.reply(unique, 0, files.dirents(off, size))
+
+	case fuseStatfs:
+		var total uint64
+		for _, f := range files.list {
+			total += uint64(len(f.data))
+		}
+		out := make([]byte, 80)
+		binary.LittleEndian.PutUint64(out[0:], (total+4095)/4096)
+		binary.LittleEndian.PutUint64(out[24:], uint64(len(files.list)+1))
+		binary.LittleEndian.PutUint32(out[40:], 4096)
+		binary.LittleEndian.PutUint32(out[44:], 255)
+		binary.LittleEndian.PutUint32(out[48:], 4096)
+		m.reply(unique, 0, out)
+
+	case fuseAccess:
+		if len(body) >= 4 && binary.LittleEndian.Uint32(body)&2 != 0 { // W_OK
+			m.reply(unique, syscall.EROFS, nil)
+			break
+		}
+		m.reply(unique, 0, nil)
+
+	case fuseRelease, fuseReleasedir, fuseFlush:
+		m.reply(unique, 0, nil)
+
+	case fuseDestroy:
+		m.reply(unique, 0, nil)
+		return false
+
+	default:
+		m.reply(unique, syscall.ENOSYS, nil)
+	}
+	return true
+}
+
+// Writes reply to request, errors of interrupted requests are dropped
+func (m *fuseMount) reply(unique uint64, errno syscall.Errno, data []byte) {
+	out := make([]byte, fuseOutHeaderLength, fuseOutHeaderLength+len(data))
+	binary.LittleEndian.PutUint32(out[0:], uint32(fuseOutHeaderLength+len(data)))
+	binary.LittleEndian.PutUint32(out[4:], uint32(-int32(errno)))
+	binary.LittleEndian.PutUint64(out[8:], unique)
+	syscall.Write(m.fd, append(out, data...))
+}
+
+// Node ids of files follow the root's, id 0 being reserved in paks too
+func fileNode(f *mountFile) uint64 {
+	return uint64(f.id) + fuseRootNode + 1
+}
+
+// Returns file of node id, nil for the root or unknown nodes
+func (files *mountFiles) node(node uint64) *mountFile {
+	if node <= fuseRootNode || node > fuseRootNode+1+0xffff {
+		return nil
+	}
+	return files.byId[uint16(node-fuseRootNode-1)]
+}
+
+// Returns fuse_dirent records of the root directory from entry off on,
+// fitting in size bytes. Entries 0 and 1 are "." and "..".
+func (files *mountFiles) dirents(off uint64, size int) []byte {
+	var out []byte
+	for i := off; i < uint64(len(files.list))+2; i++ {
+		node, name, typ := uint64(fuseRootNode), ".", uint32(syscall.DT_DIR)
+		switch {
+		case i == 1:
+			name = ".."
+		case i > 1:
+			f := files.list[i-2]
+			node, name, typ = fileNode(f), f.name, syscall.DT_REG
+		}
+
+		n := (24 + len(name) + 7) &^ 7
+		if len(out)+n > size {
+			break
+		}
+		ent := make([]byte, n)
+		binary.LittleEndian.PutUint64(ent[0:], node)
+		binary.LittleEndian.PutUint64(ent[8:], i+1)
+		binary.LittleEndian.PutUint32(ent[16:], uint32(len(name)))
+		binary.LittleEndian.PutUint32(ent[20:], typ)
+		copy(ent[24:], name)
+		out = append(out, ent...)
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+//go:build fuse && linux
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const fuseAttrLength = 88
+
+// Mounts empty FUSE file system on dir. Root mounts with mount(2), other
+// users through the setuid fusermount helper of the fuse package.
+func mountFUSE(dir string) (*fuseMount, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := syscall.Open("/dev/fuse", syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err == nil {
+		opts := fmt.Sprintf("fd=%d,rootmode=40000,user_id=%d,group_id=%d", fd, os.Getuid(), os.Getgid())
+		err = syscall.Mount("pak", dir, "fuse.pak", syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, opts)
+		if err == nil {
+			return &fuseMount{fd: fd, dir: dir, started: time.Now()}, nil
+		}
+		syscall.Close(fd)
+		if err != syscall.EPERM {
+			return nil, fmt.Errorf("error mounting %s: %v", dir, err)
+		}
+	}
+	return mountFusermount(dir)
+}
+
+// Mounts with fusermount, which passes the /dev/fuse descriptor back over a
+// socket named by $_FUSE_COMMFD
+func mountFusermount(dir string) (*fuseMount, error) {
+	helper, err := exec.LookPath("fusermount3")
+	if err != nil {
+		helper, err = exec.LookPath("fusermount")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error mounting %s: no permission and no fusermount found", dir)
+	}
+
+	pair, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	local, remote := os.NewFile(uintptr(pair[0]), "fuse"), os.NewFile(uintptr(pair[1]), "fusermount")
+	defer local.Close()
+
+	c := exec.Command(helper, "-o", "ro,nosuid,nodev,fsname=pak,subtype=pak", "--", dir)
+	c.ExtraFiles = []*os.File{remote}
+	c.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	c.Stderr = os.Stderr
+	err = c.Run()
+	remote.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error mounting %s: %s: %v", dir, filepath.Base(helper), err)
+	}
+
+	buf, oob := make([]byte, 1), make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := syscall.Recvmsg(int(local.Fd()), buf, oob, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error mounting %s: %v", dir, err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) == 0 {
+		return nil, fmt.Errorf("error mounting %s: no descriptor from %s", dir, filepath.Base(helper))
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) == 0 {
+		return nil, fmt.Errorf("error mounting %s: no descriptor from %s", dir, filepath.Base(helper))
+	}
+	syscall.CloseOnExec(fds[0])
+	return &fuseMount{fd: fds[0], dir: dir, helper: helper, started: time.Now()}, nil
+}
+
+// Unmounts file system, making serve return. Safe to call more than once.
+func (m *fuseMount) unmount() {
+	m.once.Do(func() {
+		if m.helper != "" {
+			exec.Command(m.helper, "-u", "-z", m.dir).Run()
+		} else {
+			syscall.Unmount(m.dir, syscall.MNT_DETACH)
+		}
+	})
+}
+
+// Fills fuse_attr of root directory for nil f, of file otherwise
+func (m *fuseMount) putAttr(b []byte, node uint64, f *mountFile) {
+	mode, nlink, size := uint32(syscall.S_IFDIR|0555), uint32(2), uint64(0)
+	if f != nil {
+		mode, nlink, size = syscall.S_IFREG|0444, 1, uint64(len(f.data))
+	}
+	t := uint64(m.started.Unix())
+	binary.LittleEndian.PutUint64(b[0:], node)
+	binary.LittleEndian.PutUint64(b[8:], size)
+	binary.LittleEndian.PutUint64(b[16:], (size+511)/512)
+	binary.LittleEndian.PutUint64(b[24:], t)
+	binary.LittleEndian.PutUint64(b[32:], t)
+	binary.LittleEndian.PutUint64(b[40:], t)
+	binary.LittleEndian.PutUint32(b[60:], mode)
+	binary.LittleEndian.PutUint32(b[64:], nlink)
+	binary.LittleEndian.PutUint32(b[68:], uint32(os.Getuid()))
+	binary.LittleEndian.PutUint32(b[72:], uint32(os.Getgid()))
+	binary.LittleEndian.PutUint32(b[80:], 4096)
+}
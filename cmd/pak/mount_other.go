@@ -0,0 +1,21 @@
+//go:build !fuse || !(linux || darwin)
+
+package main
+
+import (
+	"fmt"
+)
+
+// Mounting is opt-in: pak is built with -tags fuse on linux or macOS, the
+// latter needing macFUSE installed
+type fuseMount struct{}
+
+func mountFUSE(dir string) (*fuseMount, error) {
+	return nil, fmt.Errorf("error mounting %s: pak built without FUSE support, rebuild with -tags fuse on linux or macOS", dir)
+}
+
+func (m *fuseMount) unmount() {}
+
+func (m *fuseMount) serve(files *mountFiles) error {
+	return nil
+}
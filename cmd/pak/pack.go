@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "pack",
+		args:  "(dir | file.grd resources.h | -filelist list) out.pak",
+		short: "build pak from directory or list of files named by resource id or from a .grd",
+		run:   runPack,
+	})
+}
+
+func runPack(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	opts := &pak.PackOptions{Version: 5, Encoding: pak.EncodingUTF8}
+	fs.TextVar(&opts.Version, "version", opts.Version, "pak format `version`")
+	fs.TextVar(&opts.Encoding, "encoding", opts.Encoding, "pak `encoding`: binary, utf-8 or utf-16")
+	depfile := fs.String("d", "", "write Make/Ninja depfile listing inputs to `file`")
+	fileList := fs.String("filelist", "", "pack files named by resource id listed one per line in `file` (\"-\" for stdin)")
+	fs.Parse(args)
+
+	grd := fs.NArg() == 3 && strings.HasSuffix(fs.Arg(0), ".grd")
+	if *fileList != "" && fs.NArg() != 1 || *fileList == "" && fs.NArg() != 2 && !grd {
+		fs.Usage()
+		return errUsage
+	}
+	out := fs.Arg(fs.NArg() - 1)
+
+	var inputs []string
+	opts.OnInput = func(name string) {
+		inputs = append(inputs, name)
+	}
+
+	var p *pak.PakFile
+	var meta pak.Metadata
+	var err error
+	switch {
+	case *fileList != "":
+		var names []string
+		names, err = readFileList(*fileList)
+		if err == nil {
+			p, err = pak.PackFiles(names, opts)
+		}
+	case grd:
+		p, err = packGrd(fs.Arg(0), fs.Arg(1), opts)
+		inputs = append(inputs, fs.Arg(0), fs.Arg(1))
+	default:
+		p, err = pak.PackDir(fs.Arg(0), opts)
+		if err == nil {
+			meta, err = pak.ReadDirMetadata(fs.Arg(0))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	err = writePak(out, p)
+	if err != nil {
+		return err
+	}
+
+	// Carry metadata of extracted directory over to sidecar of the pak
+	if len(meta) > 0 && out != stdio {
+		inputs = append(inputs, filepath.Join(fs.Arg(0), pak.MetadataFileName))
+		err = pak.WriteMetadataFile(pak.SidecarName(out), meta)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *depfile == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	err = pak.WriteDepfile(&buf, out, inputs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*depfile, buf.Bytes(), 0644)
+}
+
+// Packs files of .grd, paths are relative to its directory
+func packGrd(grdName, symbolsName string, opts *pak.PackOptions) (*pak.PakFile, error) {
+	g, err := pak.ReadGrdFile(grdName)
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := pak.ReadSymbolsFile(symbolsName)
+	if err != nil {
+		return nil, err
+	}
+	return pak.PackGrd(g, symbols, filepath.Dir(grdName), opts)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "recompress",
+		args:  "file.pak [out.pak]",
+		short: "convert resources between none, gzip and brotli compression by a size policy",
+		run:   runRecompress,
+	})
+}
+
+func runRecompress(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	spec := fs.String("policy", "gzip>1K", "`policy` of form compression[>size], compression being none, gzip or brotli")
+	symbols := symbolsFlag(fs)
+	dryRun := fs.Bool("n", false, "print size deltas without writing")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errUsage
+	}
+	in, out := fs.Arg(0), fs.Arg(0)
+	if fs.NArg() == 2 {
+		out = fs.Arg(1)
+	}
+
+	policy, err := pak.ParseCompressionPolicy(*spec)
+	if err != nil {
+		return err
+	}
+	p, err := readPakWithSymbols(in, *symbols)
+	if err != nil {
+		return err
+	}
+
+	report, err := pak.Recompress(p, policy)
+	if err != nil {
+		return err
+	}
+	for _, c := range report.Changes {
+		fmt.Fprintf(messages(out), "%s\t%s -> %s\t%d -> %d\t%+d\n", label(p, c.Id), c.From, c.To, c.Before, c.After, c.After-c.Before)
+	}
+	fmt.Fprintf(messages(out), "%d resources changed, %d -> %d bytes (%+d)\n", len(report.Changes), report.Before, report.After, report.After-report.Before)
+
+	if *dryRun {
+		return nil
+	}
+	return writePak(out, p)
+}
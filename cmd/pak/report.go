@@ -0,0 +1,72 @@
+package main
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "report",
+		args:  "file.pak",
+		short: "generate HTML report with sizes, types and previews of all resources",
+		run:   runReport,
+	})
+}
+
+func runReport(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	out := fs.String("o", "", "write report to `file` instead of stdout (\"-\")")
+	tmplName := fs.String("t", "", "execute template `file` with pak.ReportData instead, as html/template for .html files and text/template otherwise")
+	symbols := symbolsFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	name := fs.Arg(0)
+
+	p, err := readPakWithSymbols(name, *symbols)
+	if err != nil {
+		return err
+	}
+
+	var tmpl pak.Template
+	if *tmplName != "" {
+		tmpl, err = parseTemplate(*tmplName)
+		if err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" && *out != stdio {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if tmpl != nil {
+		return pak.ReportTemplate(p, tmpl, w)
+	}
+	return pak.WriteHTMLReport(w, p, filepath.Base(name))
+}
+
+// Parses template file, escaping output for HTML if it is named *.html
+func parseTemplate(name string) (pak.Template, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".html" || ext == ".htm" {
+		return htmltemplate.ParseFiles(name)
+	}
+	return template.ParseFiles(name)
+}
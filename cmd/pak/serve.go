@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "serve",
+		args:  "file.pak",
+		short: "serve resources over HTTP for previewing in a browser",
+		run:   runServe,
+	})
+}
+
+// Polls generation counter and reloads the page when the pak changes
+const reloadScript = `<script>
+(function() {
+	var gen = null;
+	setInterval(function() {
+		fetch("/__pak/generation").then(function(r) { return r.text(); }).then(function(g) {
+			if (gen !== null && g !== gen) location.reload();
+			gen = g;
+		}).catch(function() {});
+	}, 1000);
+})();
+</script>
+`
+
+func runServe(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	addr := fs.String("addr", "localhost:8080", "listen on `address`")
+	reload := fs.Bool("reload", false, "reload pak when it changes and refresh open HTML pages")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	name := fs.Arg(0)
+
+	var live pak.Live
+	err := live.ReloadFile(name)
+	if err != nil {
+		return err
+	}
+
+	var handler http.Handler = &live
+	if *reload {
+		var generation atomic.Int64
+		go watchFile(name, func() {
+			err := live.ReloadFile(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "pak serve: %v\n", err)
+				return
+			}
+			generation.Add(1)
+			fmt.Fprintf(os.Stderr, "reloaded %s\n", name)
+		})
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/__pak/generation", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-store")
+			fmt.Fprint(w, generation.Load())
+		})
+		mux.Handle("/", injectReload(&live))
+		handler = mux
+	}
+
+	fmt.Fprintf(os.Stderr, "serving %s on http://%s/\n", name, *addr)
+	return http.ListenAndServe(*addr, handler)
+}
+
+// Calls changed whenever modification time or size of file changes
+func watchFile(name string, changed func()) {
+	var last os.FileInfo
+	for range time.Tick(time.Second) {
+		fi, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		if last != nil && (!fi.ModTime().Equal(last.ModTime()) || fi.Size() != last.Size()) {
+			changed()
+		}
+		last = fi
+	}
+}
+
+// Buffers response so HTML pages can be modified
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// Appends reload script to HTML pages served by h
+func injectReload(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Pages must come uncompressed to be modified
+		r.Header.Del("Accept-Encoding")
+		r.Header.Del("Range")
+
+		b := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		h.ServeHTTP(b, r)
+
+		body := b.body.Bytes()
+		if strings.HasPrefix(b.header.Get("Content-Type"), "text/html") {
+			body = append(body, reloadScript...)
+			b.header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		b.header.Set("Cache-Control", "no-store")
+
+		for k, v := range b.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(b.status)
+		w.Write(body)
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "stats",
+		args:  "file.pak",
+		short: "print resource count and sizes per content type",
+		run:   runStats,
+		json:  true,
+	})
+}
+
+func runStats(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := readPak(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	r, err := pak.Report(p)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return writeJSON(newJSONStats(r))
+	}
+	fmt.Print(r)
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/disintegration/pak"
+)
+
+// File argument standing for standard input or output
+const stdio = "-"
+
+// Set once standard input is read, it cannot be read twice
+var stdinUsed bool
+
+// Opens file for reading, standard input for "-"
+func openInput(name string) (io.ReadCloser, error) {
+	if name != stdio {
+		return os.Open(name)
+	}
+	if stdinUsed {
+		return nil, errors.New("standard input given more than once")
+	}
+	stdinUsed = true
+	return io.NopCloser(os.Stdin), nil
+}
+
+// Reads pak file, pak from standard input for "-"
+func readPak(name string) (*pak.PakFile, error) {
+	if name != stdio {
+		return pak.ReadFile(name)
+	}
+	r, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return pak.Read(bufio.NewReader(r))
+}
+
+// Writes pak file atomically, pak to standard output for "-"
+func writePak(name string, p *pak.PakFile) error {
+	if name != stdio {
+		return pak.WriteFileWithOptions(name, p, &pak.WriteOptions{Atomic: true})
+	}
+	bw := bufio.NewWriter(os.Stdout)
+	err := pak.Write(bw, p)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Returns where commands writing their result to out print messages:
+// standard error when the result goes to standard output
+func messages(out string) io.Writer {
+	if out == stdio {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Reads file list with one name per line, empty lines are skipped
+func readFileList(name string) ([]string, error) {
+	r, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var names []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, sc.Err()
+}
+
+// Reads ops file, ops from standard input for "-"
+func readOps(name string) ([]pak.Op, error) {
+	r, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return pak.ReadOps(r)
+}
+
+// Reads whitelist file, whitelist from standard input for "-"
+func readWhitelist(name string) ([]uint16, error) {
+	r, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return pak.ReadWhitelist(r)
+}
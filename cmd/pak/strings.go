@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "strings",
+		args:  "(export | import) [flags] arguments",
+		short: "export strings of locale paks to per-locale JSON files and import them back",
+		run:   runStrings,
+	})
+}
+
+func runStrings(cmd *command, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "export":
+			sub := &command{name: "strings export", args: "locale.pak...", short: "write strings of each locale pak to dir/<locale>.json"}
+			return runStringsExport(sub, args[1:])
+		case "import":
+			sub := &command{name: "strings import", args: "dir locale.pak...", short: "set strings of each locale pak from dir/<locale>.json"}
+			return runStringsImport(sub, args[1:])
+		}
+	}
+	fs := cmd.flagSet()
+	fs.Parse(args)
+	fs.Usage()
+	return errUsage
+}
+
+func runStringsExport(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	dir := fs.String("o", ".", "output `dir`, \"-\" writes the catalog of a single pak to stdout")
+	check := fs.Bool("check", false, "fail when a locale lacks strings of the base locale")
+	base := fs.String("base", "en-US", "`locale` other locales are checked against")
+	stdinLocale := localeFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || *dir == stdio && fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	if *dir != stdio {
+		err := os.MkdirAll(*dir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	catalogs := make(map[string]pak.Catalog)
+	var locales []string
+	for _, name := range fs.Args() {
+		p, err := readPak(name)
+		if err != nil {
+			return err
+		}
+		locale, err := localeOf(name, *stdinLocale)
+		if err != nil {
+			return err
+		}
+		c := pak.ExportStrings(p)
+		if *dir == stdio {
+			err = pak.WriteCatalog(os.Stdout, c)
+		} else {
+			err = pak.WriteCatalogFile(filepath.Join(*dir, locale+".json"), c)
+		}
+		if err != nil {
+			return err
+		}
+		catalogs[locale] = c
+		locales = append(locales, locale)
+	}
+
+	if !*check {
+		return nil
+	}
+	ref, ok := catalogs[*base]
+	if !ok {
+		return fmt.Errorf("base locale %s is not among the paks", *base)
+	}
+	return checkCatalogs(ref, catalogs, locales)
+}
+
+func runStringsImport(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	out := fs.String("o", "", "write paks to `dir` instead of replacing them, \"-\" writes a single pak to stdout")
+	check := fs.Bool("check", false, "fail without writing when a catalog lacks strings of the base locale catalog")
+	base := fs.String("base", "en-US", "`locale` other catalogs are checked against")
+	stdinLocale := localeFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() < 2 || *out == stdio && fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	dir := fs.Arg(0)
+
+	catalogs := make(map[string]pak.Catalog)
+	var locales []string
+	for _, name := range fs.Args()[1:] {
+		locale, err := localeOf(name, *stdinLocale)
+		if err != nil {
+			return err
+		}
+		c, err := pak.ReadCatalogFile(filepath.Join(dir, locale+".json"))
+		if err != nil {
+			return err
+		}
+		catalogs[locale] = c
+		locales = append(locales, locale)
+	}
+
+	if *check {
+		ref, err := pak.ReadCatalogFile(filepath.Join(dir, *base+".json"))
+		if err != nil {
+			return err
+		}
+		err = checkCatalogs(ref, catalogs, locales)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *out != "" && *out != stdio {
+		err := os.MkdirAll(*out, 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range fs.Args()[1:] {
+		p, err := readPak(name)
+		if err != nil {
+			return err
+		}
+		locale, _ := localeOf(name, *stdinLocale)
+		err = pak.ImportStrings(p, catalogs[locale])
+		if err != nil {
+			return err
+		}
+		dst := name
+		switch *out {
+		case "":
+		case stdio:
+			dst = stdio
+		default:
+			dst = filepath.Join(*out, filepath.Base(name))
+		}
+		err = writePak(dst, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds -locale flag naming the locale of a pak read from stdin
+func localeFlag(fs *flag.FlagSet) *string {
+	return fs.String("locale", "", "`locale` of the pak read from stdin (\"-\")")
+}
+
+// Returns locale of locale pak, the -locale flag for stdin
+func localeOf(name, stdinLocale string) (string, error) {
+	if name != stdio {
+		return pak.LocaleName(name), nil
+	}
+	if stdinLocale == "" {
+		return "", errors.New("-locale is required for a pak read from stdin")
+	}
+	return stdinLocale, nil
+}
+
+// Prints strings of ref missing from each catalog, failing if any are
+func checkCatalogs(ref pak.Catalog, catalogs map[string]pak.Catalog, locales []string) error {
+	total := 0
+	for _, locale := range locales {
+		missing := pak.MissingStrings(ref, catalogs[locale])
+		for _, resId := range missing {
+			fmt.Printf("%s: missing %d\n", locale, resId)
+		}
+		total += len(missing)
+	}
+	if total > 0 {
+		return fmt.Errorf("%d translations missing", total)
+	}
+	return nil
+}
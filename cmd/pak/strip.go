@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+)
+
+func init() {
+	register(&command{
+		name:  "strip",
+		args:  "-keep whitelist.txt file.pak [out.pak]",
+		short: "remove resources missing from a whitelist recorded by pak.Tracker",
+		run:   runStrip,
+	})
+}
+
+func runStrip(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	keep := fs.String("keep", "", "whitelist `file` with one resource id per line")
+	dryRun := fs.Bool("n", false, "print ids that would be removed without writing")
+	fs.Parse(args)
+
+	if *keep == "" || fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errUsage
+	}
+	in, out := fs.Arg(0), fs.Arg(0)
+	if fs.NArg() == 2 {
+		out = fs.Arg(1)
+	}
+
+	ids, err := readWhitelist(*keep)
+	if err != nil {
+		return err
+	}
+	p, err := readPak(in)
+	if err != nil {
+		return err
+	}
+
+	removed := p.Retain(ids)
+	if *dryRun {
+		for _, resId := range removed {
+			fmt.Println(resId)
+		}
+		return nil
+	}
+	fmt.Fprintf(messages(out), "removed %d of %d resources\n", len(removed), len(removed)+p.Len())
+	return writePak(out, p)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/symtab"
+)
+
+// Adds -symbols flag naming resources, defaulting to $PAK_SYMBOLS
+func symbolsFlag(fs *flag.FlagSet) *string {
+	return fs.String("symbols", os.Getenv("PAK_SYMBOLS"), "name resources with grit resource header `file` or bundled table, e.g. chromium-120 (default $PAK_SYMBOLS)")
+}
+
+// Reads pak file, attaching symbol table read from symbols unless empty
+func readPakWithSymbols(name, symbols string) (*pak.PakFile, error) {
+	p, err := readPak(name)
+	if err != nil {
+		return nil, err
+	}
+	if symbols != "" {
+		p.Symbols, err = readSymbols(symbols)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Reads symbol table from grit resource header, or from the table bundled
+// for a browser version when no such file exists
+func readSymbols(name string) (pak.SymbolTable, error) {
+	_, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		if _, nerr := symtab.Name(name); nerr == nil {
+			return symtab.Load(name)
+		}
+	}
+	return pak.ReadSymbolsFile(name)
+}
+
+// Returns resource id followed by its name if known
+func label(p *pak.PakFile, id uint16) string {
+	if name := p.Name(id); name != "" {
+		return fmt.Sprintf("%d (%s)", id, name)
+	}
+	return fmt.Sprint(id)
+}
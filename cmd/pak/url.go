@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "url",
+		args:  "-grd host=file.grd... chrome://host/path...",
+		short: "print ids of resources served at chrome:// URLs",
+		run:   runURL,
+	})
+}
+
+func runURL(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	var grds stringList
+	fs.Var(&grds, "grd", "`host=file.grd` served by WebUI host, e.g. settings=settings_resources.grd, repeatable")
+	symbols := symbolsFlag(fs)
+	fs.Parse(args)
+
+	if len(grds) == 0 || fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *symbols == "" {
+		return errors.New("symbol table is required, set -symbols or PAK_SYMBOLS")
+	}
+
+	t, err := readSymbols(*symbols)
+	if err != nil {
+		return err
+	}
+	r := pak.NewURLResolver()
+	for _, arg := range grds {
+		host, name, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("bad -grd %s, want host=file.grd", arg)
+		}
+		g, err := pak.ReadGrdFile(name)
+		if err != nil {
+			return err
+		}
+		r.AddGrd(host, g, t)
+	}
+
+	names := t.Names()
+	for _, u := range fs.Args() {
+		resId, err := r.ResolveURL(u)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d %s\n", resId, names[resId])
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "verify",
+		args:  "file.pak...",
+		short: "check structure of paks, reading only their headers and indexes",
+		run:   runVerify,
+		json:  true,
+	})
+}
+
+func runVerify(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	ok := true
+	reports := []jsonVerify{}
+	for _, name := range fs.Args() {
+		findings, err := pak.ValidateFile(name)
+		if err != nil {
+			return err
+		}
+		ok = ok && !pak.HasErrors(findings)
+
+		if jsonOutput {
+			reports = append(reports, newJSONVerify(name, findings))
+			continue
+		}
+		if len(findings) == 0 {
+			fmt.Printf("%s: ok\n", name)
+		}
+		for _, f := range findings {
+			fmt.Printf("%s: %s\n", name, f)
+		}
+	}
+
+	if jsonOutput {
+		err := writeJSON(reports)
+		if err != nil {
+			return err
+		}
+	}
+	if !ok {
+		return errors.New("problems found")
+	}
+	return nil
+}
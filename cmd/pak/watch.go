@@ -0,0 +1,56 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "watch",
+		args:  "dir out.pak",
+		short: "pack directory of files named by resource id and repack on changes",
+		run:   runWatch,
+	})
+}
+
+func runWatch(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	interval := fs.Duration("interval", time.Second, "polling `interval`")
+	opts := &pak.PackOptions{Version: 5, Encoding: pak.EncodingUTF8}
+	fs.TextVar(&opts.Version, "version", opts.Version, "pak format `version`")
+	fs.TextVar(&opts.Encoding, "encoding", opts.Encoding, "pak `encoding`: binary, utf-8 or utf-16")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	dir, out := fs.Arg(0), fs.Arg(1)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := pak.Watch(ctx, dir, out, &pak.WatchOptions{
+		Interval: *interval,
+		Pack:     opts,
+		OnBuild: func(err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", time.Now().Format(time.TimeOnly), err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%s: wrote %s\n", time.Now().Format(time.TimeOnly), out)
+		},
+	})
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
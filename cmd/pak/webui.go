@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/disintegration/pak"
+)
+
+func init() {
+	register(&command{
+		name:  "webui",
+		args:  "-grd file.grd -root id|name... file.pak",
+		short: "find resources reachable from WebUI roots and prune the rest",
+		run:   runWebUI,
+	})
+}
+
+func runWebUI(cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	grd := fs.String("grd", "", "`file` defining the WebUI resources, paths are relative to it")
+	shared := fs.String("shared", "", ".grd `file` of chrome://resources")
+	symbols := symbolsFlag(fs)
+	i18n := fs.String("i18n", "", "`file` of \"key id\" lines mapping $i18n{} keys to string ids")
+	var roots stringList
+	fs.Var(&roots, "root", "resource `id` or name to start from, repeatable")
+	out := fs.String("o", "", "write pruned pak to `file` instead of printing reachable ids")
+	stringsOut := fs.String("strings", "", "write whitelist of strings used to `file`, for pruning locale paks")
+	fs.Parse(args)
+
+	if *grd == "" || len(roots) == 0 || fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if *symbols == "" {
+		return errors.New("symbol table is required, set -symbols or PAK_SYMBOLS")
+	}
+
+	p, err := readPakWithSymbols(fs.Arg(0), *symbols)
+	if err != nil {
+		return err
+	}
+	g, err := pak.ReadGrdFile(*grd)
+	if err != nil {
+		return err
+	}
+	var sg *pak.Grd
+	if *shared != "" {
+		sg, err = pak.ReadGrdFile(*shared)
+		if err != nil {
+			return err
+		}
+	}
+
+	m := pak.NewWebUIMap(g, sg, p.Symbols)
+	if *i18n != "" {
+		keys, err := pak.ReadSymbolsFile(*i18n)
+		if err != nil {
+			return err
+		}
+		for key, resId := range keys {
+			m.Strings[key] = resId
+		}
+	}
+
+	var rootIds []uint16
+	for _, ref := range roots {
+		resId, err := p.Lookup(ref)
+		if err != nil {
+			return err
+		}
+		rootIds = append(rootIds, resId)
+	}
+
+	r := m.Reachable(p, rootIds)
+	for _, ref := range r.Unresolved {
+		fmt.Fprintf(os.Stderr, "unresolved: %s\n", ref)
+	}
+
+	if *stringsOut != "" {
+		f, err := os.Create(*stringsOut)
+		if err != nil {
+			return err
+		}
+		err = pak.WriteWhitelist(f, r.Strings, p.Symbols.Names())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if *out == "" {
+		return pak.WriteWhitelist(os.Stdout, r.Resources, p.Symbols.Names())
+	}
+	removed := p.Retain(r.Resources)
+	fmt.Fprintf(messages(*out), "removed %d of %d resources\n", len(removed), len(removed)+p.Len())
+	return writePak(*out, p)
+}
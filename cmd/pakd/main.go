@@ -0,0 +1,554 @@
+// Method and wildcard routing patterns need the Go 1.22 mux
+//
+//go:debug httpmuxgo121=0
+
+// Command pakd serves the pak library over HTTP with JSON responses, so paks
+// can be inspected and modified without writing Go.
+//
+// Usage:
+//
+//	pakd [-addr host:port] [-max-size bytes] [-max-paks n] [-max-stored bytes]
+//
+// Uploaded paks are kept in memory until deleted or the daemon exits. Each
+// pak, as uploaded or modified, and each decompressed resource is at most
+// -max-size bytes, and the daemon keeps at most -max-paks paks taking
+// -max-stored bytes together.
+//
+//	GET    /paks                         list uploaded paks
+//	POST   /paks?name=file.pak           upload pak sent as request body
+//	GET    /paks/{pak}                   pak summary with list of resources
+//	DELETE /paks/{pak}                   forget pak
+//	GET    /paks/{pak}/file              download pak
+//	GET    /paks/{pak}/zip               download resources as zip archive
+//	GET    /paks/{pak}/diff/{other}      ids added, removed and changed going to another pak
+//	POST   /paks/{pak}/ops               apply JSON list of ops, see pak.Op
+//	GET    /paks/{pak}/resources/{id}    download resource, ?decompress=1 to decompress
+//	PUT    /paks/{pak}/resources/{id}    set resource data to request body
+//	DELETE /paks/{pak}/resources/{id}    remove resource
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/disintegration/pak"
+)
+
+// Uploaded pak
+type entry struct {
+	mu      sync.Mutex
+	id      string
+	name    string
+	created time.Time
+	p       *pak.PakFile
+	size    int64 // stored size of p, counted in server.stored
+	removed bool
+}
+
+type server struct {
+	maxSize   int64
+	maxPaks   int
+	maxStored int64
+
+	mu     sync.Mutex
+	paks   map[string]*entry
+	stored int64 // stored size of all paks
+}
+
+type pakSummary struct {
+	Id        string       `json:"id"`
+	Name      string       `json:"name"`
+	Created   time.Time    `json:"created"`
+	Version   pak.Version  `json:"version"`
+	Encoding  pak.Encoding `json:"encoding"`
+	Resources int          `json:"resources"`
+	Aliases   int          `json:"aliases"`
+}
+
+type resourceSummary struct {
+	Id          uint16 `json:"id"`
+	Size        int64  `json:"size"`
+	Raw         int64  `json:"raw"`
+	Compression string `json:"compression"`
+	Type        string `json:"type"`
+	AliasOf     uint16 `json:"aliasOf,omitempty"`
+}
+
+type pakDetails struct {
+	pakSummary
+	List []resourceSummary `json:"list"`
+}
+
+type pakDiff struct {
+	Added   []uint16 `json:"added"`
+	Removed []uint16 `json:"removed"`
+	Changed []uint16 `json:"changed"`
+}
+
+// Returns summary of pak, e.mu must be held
+func (e *entry) summary() pakSummary {
+	return pakSummary{
+		Id:        e.id,
+		Name:      e.name,
+		Created:   e.created,
+		Version:   e.p.Version,
+		Encoding:  e.p.Encoding,
+		Resources: e.p.Len() - len(e.p.Aliases),
+		Aliases:   len(e.p.Aliases),
+	}
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "listen `address`")
+	maxSize := flag.Int64("max-size", 512<<20, "largest accepted upload, pak or decompressed resource in `bytes`")
+	maxPaks := flag.Int("max-paks", 64, "largest `number` of paks kept")
+	maxStored := flag.Int64("max-stored", 2<<30, "largest size of all paks kept in `bytes`")
+	flag.Parse()
+
+	s := &server{maxSize: *maxSize, maxPaks: *maxPaks, maxStored: *maxStored, paks: make(map[string]*entry)}
+	log.Printf("listening on http://%s/", *addr)
+	log.Fatal(http.ListenAndServe(*addr, s.handler()))
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /paks", s.list)
+	mux.HandleFunc("POST /paks", s.upload)
+	mux.HandleFunc("GET /paks/{pak}", s.withPak(s.details))
+	mux.HandleFunc("DELETE /paks/{pak}", s.remove)
+	mux.HandleFunc("GET /paks/{pak}/file", s.withPak(s.download))
+	mux.HandleFunc("GET /paks/{pak}/zip", s.withPak(s.zip))
+	mux.HandleFunc("GET /paks/{pak}/diff/{other}", s.diff)
+	mux.HandleFunc("POST /paks/{pak}/ops", s.withPak(s.ops))
+	mux.HandleFunc("GET /paks/{pak}/resources/{id}", s.withPak(s.getResource))
+	mux.HandleFunc("PUT /paks/{pak}/resources/{id}", s.withPak(s.putResource))
+	mux.HandleFunc("DELETE /paks/{pak}/resources/{id}", s.withPak(s.deleteResource))
+	return mux
+}
+
+// Error with HTTP status
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func errorf(status int, format string, a ...any) error {
+	return &httpError{status, fmt.Errorf(format, a...)}
+}
+
+// Writes JSON error response, status 400 unless err carries one or is a
+// pak.LimitError, reported as 413
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	var he *httpError
+	var le *pak.LimitError
+	switch {
+	case errors.As(err, &he):
+		status = he.status
+	case errors.As(err, &le):
+		status = http.StatusRequestEntityTooLarge
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func (s *server) lookup(id string) (*entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.paks[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "pak %s not found", id)
+	}
+	return e, nil
+}
+
+// Wraps handler of a single pak, holding its lock for the request
+func (s *server) withPak(h func(w http.ResponseWriter, r *http.Request, e *entry) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := s.lookup(r.PathValue("pak"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		e.mu.Lock()
+		if e.removed {
+			err = errorf(http.StatusNotFound, "pak %s not found", e.id)
+		} else {
+			err = h(w, r, e)
+		}
+		e.mu.Unlock()
+		if err != nil {
+			writeError(w, err)
+		}
+	}
+}
+
+// Returns size of resource data of pak, aliases counted once
+func storedSize(p *pak.PakFile) int64 {
+	var size int64
+	for resId, resData := range p.Resourses {
+		if _, ok := p.Aliases[resId]; !ok {
+			size += int64(len(resData))
+		}
+	}
+	return size
+}
+
+// Returns copy of pak sharing resource data, for changes that replace
+// resources rather than modify their data
+func shallowCopy(p *pak.PakFile) *pak.PakFile {
+	c := *p
+	c.Resourses = make(map[uint16][]byte, len(p.Resourses))
+	for resId, resData := range p.Resourses {
+		c.Resourses[resId] = resData
+	}
+	if p.Aliases != nil {
+		c.Aliases = make(map[uint16]uint16, len(p.Aliases))
+		for aliasId, target := range p.Aliases {
+			c.Aliases[aliasId] = target
+		}
+	}
+	return &c
+}
+
+// Replaces pak of entry with p, failing if p is larger than -max-size or the
+// store has no room for it. e.mu must be held.
+func (s *server) replace(e *entry, p *pak.PakFile) error {
+	size := storedSize(p)
+	if size > s.maxSize {
+		return errorf(http.StatusRequestEntityTooLarge, "pak larger than %d bytes", s.maxSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stored-e.size+size > s.maxStored {
+		return errorf(http.StatusInsufficientStorage, "paks would take more than %d bytes", s.maxStored)
+	}
+	s.stored += size - e.size
+	e.p, e.size = p, size
+	return nil
+}
+
+// Reads resource id from request path
+func resourceID(r *http.Request) (uint16, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 16)
+	if err != nil {
+		return 0, errorf(http.StatusBadRequest, "bad resource id %q", r.PathValue("id"))
+	}
+	return uint16(id), nil
+}
+
+// Returns limits paks are read and resources decompressed with
+func (s *server) limits() pak.Limits {
+	return pak.Limits{MaxResourceSize: uint32(min(s.maxSize, math.MaxUint32)), MaxTotalSize: uint64(s.maxSize)}
+}
+
+// Reads request body, at most maxSize bytes
+func (s *server) body(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxSize))
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return nil, errorf(http.StatusRequestEntityTooLarge, "request body larger than %d bytes", mbe.Limit)
+	}
+	return data, err
+}
+
+func (s *server) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	entries := make([]*entry, 0, len(s.paks))
+	for _, e := range s.paks {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].created.Before(entries[j].created)
+	})
+
+	list := make([]pakSummary, 0, len(entries))
+	for _, e := range entries {
+		e.mu.Lock()
+		list = append(list, e.summary())
+		e.mu.Unlock()
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *server) upload(w http.ResponseWriter, r *http.Request) {
+	data, err := s.body(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	p, err := pak.ReadWithOptions(bytes.NewReader(data), &pak.ReadOptions{Limits: s.limits()})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var buf [8]byte
+	rand.Read(buf[:])
+	e := &entry{id: hex.EncodeToString(buf[:]), name: r.URL.Query().Get("name"), created: time.Now(), p: p, size: storedSize(p)}
+	// Once published, e may be changed by other requests
+	summary := e.summary()
+
+	s.mu.Lock()
+	switch {
+	case len(s.paks) >= s.maxPaks:
+		err = errorf(http.StatusInsufficientStorage, "%d paks kept already, delete some first", len(s.paks))
+	case s.stored+e.size > s.maxStored:
+		err = errorf(http.StatusInsufficientStorage, "paks would take more than %d bytes", s.maxStored)
+	default:
+		s.paks[e.id] = e
+		s.stored += e.size
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, summary)
+}
+
+func (s *server) remove(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("pak")
+	s.mu.Lock()
+	e, ok := s.paks[id]
+	delete(s.paks, id)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, errorf(http.StatusNotFound, "pak %s not found", id))
+		return
+	}
+
+	// Handlers holding e finish first, later ones see it removed
+	e.mu.Lock()
+	e.removed = true
+	size := e.size
+	e.mu.Unlock()
+
+	s.mu.Lock()
+	s.stored -= size
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) details(w http.ResponseWriter, r *http.Request, e *entry) error {
+	d := pakDetails{pakSummary: e.summary(), List: []resourceSummary{}}
+	for resId := range e.p.IDs() {
+		resData := e.p.Resourses[resId]
+		rs := resourceSummary{
+			Id:          resId,
+			Size:        int64(len(resData)),
+			Raw:         pak.DecompressedSize(resData),
+			Compression: pak.DetectCompression(resData).String(),
+			Type:        e.p.ContentType(resId),
+		}
+		if target, ok := e.p.Aliases[resId]; ok {
+			rs.AliasOf = target
+		}
+		d.List = append(d.List, rs)
+	}
+	writeJSON(w, http.StatusOK, d)
+	return nil
+}
+
+func (s *server) download(w http.ResponseWriter, r *http.Request, e *entry) error {
+	var buf bytes.Buffer
+	err := pak.Write(&buf, e.p)
+	if err != nil {
+		return errorf(http.StatusInternalServerError, "%v", err)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(downloadName(e, ".pak")))
+	w.Write(buf.Bytes())
+	return nil
+}
+
+func (s *server) zip(w http.ResponseWriter, r *http.Request, e *entry) error {
+	var buf bytes.Buffer
+	err := pak.ToZip(&buf, e.p)
+	if err != nil {
+		return errorf(http.StatusInternalServerError, "%v", err)
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(downloadName(e, ".zip")))
+	w.Write(buf.Bytes())
+	return nil
+}
+
+// Returns file name for download of pak with given extension
+func downloadName(e *entry, ext string) string {
+	if e.name == "" {
+		return e.id + ext
+	}
+	if ext == ".pak" {
+		return e.name
+	}
+	return e.name + ext
+}
+
+// Returns copy of resources of pak taken under its lock, data is shared as
+// handlers replace resources rather than modify their data
+func (e *entry) resources() (map[uint16][]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.removed {
+		return nil, errorf(http.StatusNotFound, "pak %s not found", e.id)
+	}
+	return shallowCopy(e.p).Resourses, nil
+}
+
+// Compares snapshots of both paks taken one at a time, so requests diffing
+// two paks in opposite directions never wait on each other's locks
+func (s *server) diff(w http.ResponseWriter, r *http.Request) {
+	var snapshots [2]map[uint16][]byte
+	for i, id := range []string{r.PathValue("pak"), r.PathValue("other")} {
+		e, err := s.lookup(id)
+		if err == nil {
+			snapshots[i], err = e.resources()
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	ours, theirs := snapshots[0], snapshots[1]
+
+	d := pakDiff{Added: []uint16{}, Removed: []uint16{}, Changed: []uint16{}}
+	for resId, resData := range ours {
+		data, ok := theirs[resId]
+		if !ok {
+			d.Removed = append(d.Removed, resId)
+		} else if !bytes.Equal(data, resData) {
+			d.Changed = append(d.Changed, resId)
+		}
+	}
+	for resId := range theirs {
+		if _, ok := ours[resId]; !ok {
+			d.Added = append(d.Added, resId)
+		}
+	}
+	for _, ids := range [][]uint16{d.Added, d.Removed, d.Changed} {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *server) ops(w http.ResponseWriter, r *http.Request, e *entry) error {
+	data, err := s.body(w, r)
+	if err != nil {
+		return err
+	}
+	ops, err := pak.ReadOps(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	p := shallowCopy(e.p)
+	err = pak.ApplyOps(p, ops)
+	if err != nil {
+		return err
+	}
+	err = s.replace(e, p)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, e.summary())
+	return nil
+}
+
+func (s *server) getResource(w http.ResponseWriter, r *http.Request, e *entry) error {
+	resId, err := resourceID(r)
+	if err != nil {
+		return err
+	}
+	data, ok := e.p.Get(resId)
+	if !ok {
+		return errorf(http.StatusNotFound, "resource id=%d not found", resId)
+	}
+
+	typ := e.p.ContentType(resId)
+	if r.URL.Query().Get("decompress") == "1" {
+		data, err = pak.DecompressWithLimits(data, s.limits())
+		var le *pak.LimitError
+		if errors.As(err, &le) {
+			return fmt.Errorf("error decompressing resource id=%d: %w", resId, err)
+		}
+		if err != nil {
+			return errorf(http.StatusUnprocessableEntity, "error decompressing resource id=%d: %v", resId, err)
+		}
+	} else if pak.DetectCompression(data) != pak.CompressionNone {
+		typ = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", typ)
+	w.Write(data)
+	return nil
+}
+
+func (s *server) putResource(w http.ResponseWriter, r *http.Request, e *entry) error {
+	resId, err := resourceID(r)
+	if err != nil {
+		return err
+	}
+	data, err := s.body(w, r)
+	if err != nil {
+		return err
+	}
+	p := shallowCopy(e.p)
+	err = p.Set(resId, data)
+	if err != nil {
+		return err
+	}
+	err = s.replace(e, p)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *server) deleteResource(w http.ResponseWriter, r *http.Request, e *entry) error {
+	resId, err := resourceID(r)
+	if err != nil {
+		return err
+	}
+	if !e.p.Has(resId) {
+		return errorf(http.StatusNotFound, "resource id=%d not found", resId)
+	}
+	p := shallowCopy(e.p)
+	p.Delete(resId)
+	err = s.replace(e, p)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+// Starts daemon with given limits, zero ones taken as unlimited
+func startServer(t *testing.T, maxSize int64, maxPaks int, maxStored int64) *httptest.Server {
+	t.Helper()
+	s := &server{maxSize: 1 << 20, maxPaks: 64, maxStored: 1 << 30, paks: make(map[string]*entry)}
+	if maxSize != 0 {
+		s.maxSize = maxSize
+	}
+	if maxPaks != 0 {
+		s.maxPaks = maxPaks
+	}
+	if maxStored != 0 {
+		s.maxStored = maxStored
+	}
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// Sends request and returns response status and body, status 0 if it fails
+func do(t *testing.T, method, url string, body []byte) (int, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return 0, nil
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0, nil
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+		return 0, nil
+	}
+	return resp.StatusCode, data
+}
+
+// Uploads pak and returns its summary
+func upload(t *testing.T, ts *httptest.Server, data []byte) pakSummary {
+	t.Helper()
+	status, body := do(t, "POST", ts.URL+"/paks?name=sample.pak", data)
+	if status != http.StatusCreated {
+		t.Fatalf("upload status %d: %s", status, body)
+	}
+	var sum pakSummary
+	err := json.Unmarshal(body, &sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+func TestUploadList(t *testing.T) {
+	ts := startServer(t, 0, 0, 0)
+	sum := upload(t, ts, paktest.SampleBytes(5, pak.EncodingUTF8))
+	if sum.Name != "sample.pak" || sum.Version != 5 || sum.Resources != 6 || sum.Aliases != 2 {
+		t.Errorf("upload summary = %+v", sum)
+	}
+
+	status, body := do(t, "GET", ts.URL+"/paks", nil)
+	var list []pakSummary
+	err := json.Unmarshal(body, &list)
+	if status != http.StatusOK || err != nil || len(list) != 1 || list[0].Id != sum.Id {
+		t.Errorf("list status %d: %s", status, body)
+	}
+
+	status, body = do(t, "GET", ts.URL+"/paks/"+sum.Id+"/file", nil)
+	if status != http.StatusOK {
+		t.Fatalf("download status %d: %s", status, body)
+	}
+	p, err := pak.Read(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paktest.RequireEqual(t, paktest.Sample(5, pak.EncodingUTF8), p)
+
+	status, body = do(t, "POST", ts.URL+"/paks", []byte("not a pak"))
+	if status != http.StatusBadRequest {
+		t.Errorf("upload of bad pak status %d: %s", status, body)
+	}
+}
+
+func TestOps(t *testing.T) {
+	ts := startServer(t, 0, 0, 0)
+	sum := upload(t, ts, paktest.SampleBytes(5, pak.EncodingUTF8))
+	url := ts.URL + "/paks/" + sum.Id
+
+	status, body := do(t, "POST", url+"/ops", []byte(`[{"op": "replace", "id": 100, "text": "new"}, {"op": "add", "id": 300, "text": "added"}]`))
+	if status != http.StatusOK {
+		t.Fatalf("ops status %d: %s", status, body)
+	}
+	for id, want := range map[string]string{"100": "new", "300": "added"} {
+		status, body = do(t, "GET", url+"/resources/"+id, nil)
+		if status != http.StatusOK || string(body) != want {
+			t.Errorf("resource %s status %d: %q, want %q", id, status, body, want)
+		}
+	}
+
+	status, body = do(t, "POST", url+"/ops", []byte(`[{"op": "remove", "id": 999}]`))
+	if status != http.StatusBadRequest {
+		t.Errorf("failing ops status %d: %s", status, body)
+	}
+	status, body = do(t, "GET", url+"/resources/100", nil)
+	if string(body) != "new" {
+		t.Errorf("failing ops changed pak: resource 100 = %q", body)
+	}
+}
+
+// Run with -race: uploads, ops and lists of the same paks at once
+func TestConcurrent(t *testing.T) {
+	ts := startServer(t, 0, 0, 0)
+	sample := paktest.SampleBytes(5, pak.EncodingUTF8)
+	first := upload(t, ts, sample)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			do(t, "POST", ts.URL+"/paks", sample)
+		}()
+		go func() {
+			defer wg.Done()
+			do(t, "POST", ts.URL+"/paks/"+first.Id+"/ops", []byte(`[{"op": "replace", "id": 100, "text": "new"}]`))
+		}()
+		go func() {
+			defer wg.Done()
+			do(t, "GET", ts.URL+"/paks", nil)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLimits(t *testing.T) {
+	sample := paktest.SampleBytes(5, pak.EncodingUTF8)
+	bomb, err := pak.Compress(make([]byte, 1<<20), pak.CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = pak.Write(&buf, &pak.PakFile{Version: 5, Resourses: map[uint16][]byte{1: bomb}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bombPak := buf.Bytes()
+
+	t.Run("upload size", func(t *testing.T) {
+		ts := startServer(t, int64(len(sample)-1), 0, 0)
+		status, body := do(t, "POST", ts.URL+"/paks", sample)
+		if status != http.StatusRequestEntityTooLarge {
+			t.Errorf("status %d: %s", status, body)
+		}
+	})
+
+	t.Run("pak count", func(t *testing.T) {
+		ts := startServer(t, 0, 1, 0)
+		upload(t, ts, sample)
+		status, body := do(t, "POST", ts.URL+"/paks", sample)
+		if status != http.StatusInsufficientStorage {
+			t.Errorf("status %d: %s", status, body)
+		}
+	})
+
+	t.Run("stored size", func(t *testing.T) {
+		ts := startServer(t, 0, 0, int64(len(sample)))
+		sum := upload(t, ts, sample)
+		status, body := do(t, "POST", ts.URL+"/paks", sample)
+		if status != http.StatusInsufficientStorage {
+			t.Errorf("second upload status %d: %s", status, body)
+		}
+		status, body = do(t, "PUT", ts.URL+"/paks/"+sum.Id+"/resources/300", bytes.Repeat([]byte("x"), len(sample)))
+		if status != http.StatusInsufficientStorage {
+			t.Errorf("put status %d: %s", status, body)
+		}
+
+		status, body = do(t, "DELETE", ts.URL+"/paks/"+sum.Id, nil)
+		if status != http.StatusNoContent {
+			t.Fatalf("delete status %d: %s", status, body)
+		}
+		upload(t, ts, sample)
+	})
+
+	t.Run("decompressed size", func(t *testing.T) {
+		ts := startServer(t, 1<<16, 0, 0)
+		sum := upload(t, ts, bombPak)
+		status, body := do(t, "GET", ts.URL+"/paks/"+sum.Id+"/resources/1?decompress=1", nil)
+		if status != http.StatusRequestEntityTooLarge || !strings.Contains(string(body), "resource size") {
+			t.Errorf("status %d: %s", status, body)
+		}
+	})
+}
@@ -0,0 +1,336 @@
+package pak
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Compression wrapper of resource data
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionBrotli
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionBrotli:
+		return "brotli"
+	}
+	return fmt.Sprintf("compression(%d)", int(c))
+}
+
+// Chromium prefixes brotli compressed resources with two magic bytes and
+// 6 byte little endian size of decompressed data
+var brotliMagic = []byte{0x1e, 0x9b}
+
+const brotliHeaderLength = 2 + 6
+
+// Gzip header magic followed by deflate method
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// Returned when brotli data is processed but no codec is registered
+var ErrBrotliUnsupported = errors.New("pak: brotli codec not registered, see RegisterBrotli")
+
+var brotliCodec struct {
+	sync.RWMutex
+	decode func(compressed []byte) ([]byte, error)
+	encode func(data []byte) ([]byte, error)
+}
+
+// Registers brotli codec working on raw brotli streams. The standard library
+// has no brotli implementation, so programs handling brotli compressed
+// resources register one from a third party package, e.g.
+//
+//	pak.RegisterBrotli(
+//		func(b []byte) ([]byte, error) { return io.ReadAll(brotli.NewReader(bytes.NewReader(b))) },
+//		func(b []byte) ([]byte, error) { ... },
+//	)
+//
+// Either function may be nil.
+func RegisterBrotli(decode func(compressed []byte) ([]byte, error), encode func(data []byte) ([]byte, error)) {
+	brotliCodec.Lock()
+	brotliCodec.decode, brotliCodec.encode = decode, encode
+	brotliCodec.Unlock()
+}
+
+// Detects compression wrapper of resource data
+func DetectCompression(data []byte) Compression {
+	switch {
+	case len(data) >= brotliHeaderLength && bytes.HasPrefix(data, brotliMagic):
+		return CompressionBrotli
+	case len(data) >= 18 && bytes.HasPrefix(data, gzipMagic):
+		return CompressionGzip
+	}
+	return CompressionNone
+}
+
+// Returns size of data after decompression as recorded in the compression
+// wrapper, without decompressing. For gzip the size is only known modulo 4 GiB.
+func DecompressedSize(data []byte) int64 {
+	switch DetectCompression(data) {
+	case CompressionBrotli:
+		var size [8]byte
+		copy(size[:], data[2:brotliHeaderLength])
+		return int64(binary.LittleEndian.Uint64(size[:]))
+	case CompressionGzip:
+		return int64(binary.LittleEndian.Uint32(data[len(data)-4:]))
+	}
+	return int64(len(data))
+}
+
+// Returns decompressed resource data, data without compression wrapper is
+// returned as is
+func Decompress(data []byte) ([]byte, error) {
+	out, err := decompress(data)
+	if err == nil && DetectCompression(data) != CompressionNone {
+		countMetric(MetricBytesDecompressed, int64(len(out)))
+	}
+	return out, err
+}
+
+// Same as Decompress, giving up with LimitError once decompressed data
+// exceeds MaxResourceSize or MaxTotalSize of limits. The size recorded in the
+// compression wrapper is not trusted.
+func DecompressWithLimits(data []byte, limits Limits) ([]byte, error) {
+	left := int64(-1)
+	if limits.MaxTotalSize != 0 {
+		left = int64(min(limits.MaxTotalSize, math.MaxInt64))
+	}
+	return decompressResource(0, data, limits, left)
+}
+
+func decompress(data []byte) ([]byte, error) {
+	return decompressLimited(data, -1)
+}
+
+// Returned by decompressLimited when output exceeds the limit
+var errDecompressLimit = errors.New("pak: decompressed data exceeds limit")
+
+// Decompresses data, giving up with errDecompressLimit once output exceeds
+// max bytes, negative max meaning no limit. Gzip output is read through a
+// limited reader, brotli data is checked against its header before decoding
+// and against the limit after, as the codec decodes whole buffers.
+func decompressLimited(data []byte, max int64) ([]byte, error) {
+	switch DetectCompression(data) {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if max < 0 {
+			return io.ReadAll(zr)
+		}
+		out, err := io.ReadAll(io.LimitReader(zr, max+1))
+		if err == nil && int64(len(out)) > max {
+			err = errDecompressLimit
+		}
+		return out, err
+
+	case CompressionBrotli:
+		if max >= 0 && DecompressedSize(data) > max {
+			return nil, errDecompressLimit
+		}
+		brotliCodec.RLock()
+		decode := brotliCodec.decode
+		brotliCodec.RUnlock()
+		if decode == nil {
+			return nil, ErrBrotliUnsupported
+		}
+		out, err := decode(data[brotliHeaderLength:])
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(out)) != DecompressedSize(data) {
+			return nil, fmt.Errorf("pak: brotli data decompressed to %d bytes, header says %d", len(out), DecompressedSize(data))
+		}
+		return out, nil
+	}
+	return data, nil
+}
+
+// Returns data wrapped the way chromium expects compressed resources
+func Compress(data []byte, c Compression) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		zw.Write(data)
+		err := zw.Close()
+		return buf.Bytes(), err
+
+	case CompressionBrotli:
+		brotliCodec.RLock()
+		encode := brotliCodec.encode
+		brotliCodec.RUnlock()
+		if encode == nil {
+			return nil, ErrBrotliUnsupported
+		}
+		compressed, err := encode(data)
+		if err != nil {
+			return nil, err
+		}
+		var size [8]byte
+		binary.LittleEndian.PutUint64(size[:], uint64(len(data)))
+		out := make([]byte, 0, brotliHeaderLength+len(compressed))
+		out = append(out, brotliMagic...)
+		out = append(out, size[:6]...)
+		return append(out, compressed...), nil
+	}
+	return nil, fmt.Errorf("pak: unknown compression %d", int(c))
+}
+
+// Returns decompressed data of resource, loading lazy resources
+func (p *PakFile) decompressed(id uint16) ([]byte, error) {
+	data, err := p.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if IsEncrypted(data) {
+		return nil, ErrEncrypted
+	}
+	return Decompress(data)
+}
+
+// Decompresses data of resource, returning LimitError once output exceeds
+// MaxResourceSize of limits or the total bytes left, negative left meaning
+// no total limit. Decompressed sizes recorded in compression headers are not
+// trusted.
+func decompressResource(id uint16, data []byte, limits Limits, left int64) ([]byte, error) {
+	limit, n := "resource size", int64(-1)
+	if limits.MaxResourceSize != 0 {
+		n = int64(limits.MaxResourceSize)
+	}
+	if left >= 0 && (n < 0 || left < n) {
+		limit, n = "total size", left
+	}
+
+	out, err := decompressLimited(data, n)
+	if err == errDecompressLimit {
+		size := uint64(max(DecompressedSize(data), n+1)) // the header may lie
+		if limit == "total size" {
+			used := limits.MaxTotalSize - uint64(n)
+			return nil, &LimitError{Limit: limit, Value: used + size, Max: limits.MaxTotalSize}
+		}
+		return nil, &LimitError{Limit: limit, Id: id, Value: size, Max: uint64(n)}
+	}
+	if err == nil && DetectCompression(data) != CompressionNone {
+		countMetric(MetricBytesDecompressed, int64(len(out)))
+	}
+	return out, err
+}
+
+// Replaces compressed resource data with decompressed data, decompressing
+// on up to workers goroutines, zero meaning one per CPU. Aliases get the
+// data of their targets. Cancellation is checked between resources. Lazy
+// resources are left unread, they are decompressed as they are loaded.
+// Decompressed data is checked against MaxResourceSize and MaxTotalSize of
+// limits as it is produced.
+func decompressAll(ctx context.Context, p *PakFile, workers int, limits Limits) error {
+	for _, lr := range p.Lazy {
+		lr.decompress = true
+		lr.limits = limits
+	}
+
+	var ids []uint16
+	var total atomic.Uint64 // size of stored resources once decompressed
+	for _, resId := range sortedIds(p) {
+		if _, ok := p.Aliases[resId]; ok {
+			continue
+		}
+		if DetectCompression(p.Resourses[resId]) != CompressionNone {
+			ids = append(ids, resId)
+		} else {
+			total.Add(uint64(len(p.Resourses[resId])))
+		}
+	}
+	err := limits.checkTotalSize(total.Load())
+	if err != nil {
+		return err
+	}
+
+	// Returns bytes left before MaxTotalSize, -1 if it is not set
+	left := func() int64 {
+		if limits.MaxTotalSize == 0 {
+			return -1
+		}
+		t := total.Load()
+		if t >= limits.MaxTotalSize {
+			return 0
+		}
+		return int64(min(limits.MaxTotalSize-t, math.MaxInt64))
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(ids))
+
+	results := make([][]byte, len(ids))
+	errs := make([]error, len(ids))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = decompressResource(ids[i], p.Resourses[ids[i]], limits, left())
+				if errs[i] == nil {
+					errs[i] = limits.checkTotalSize(total.Add(uint64(len(results[i]))))
+				}
+			}
+		}()
+	}
+
+	for i := range ids {
+		err = ctx.Err()
+		if err != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	for i, resId := range ids {
+		if _, ok := errs[i].(*LimitError); ok {
+			return errs[i]
+		}
+		if errs[i] != nil {
+			return fmt.Errorf("error decompressing resource id=%d: %v", resId, errs[i])
+		}
+	}
+
+	for i, resId := range ids {
+		p.Resourses[resId] = results[i]
+	}
+	for aliasId, target := range p.Aliases {
+		if _, ok := p.Resourses[aliasId]; ok {
+			p.Resourses[aliasId] = p.Resourses[target]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package pak_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/disintegration/pak"
+)
+
+// Returns gzip wrapped zeros with the size trailer claiming claimed bytes
+func gzipZeros(t *testing.T, n int, claimed uint32) []byte {
+	t.Helper()
+	data, err := pak.Compress(make([]byte, n), pak.CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint32(data[len(data)-4:], claimed)
+	return data
+}
+
+func TestReadDecompressLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		res    map[uint16][]byte
+		limits pak.Limits
+		limit  string // "" for no error
+	}{
+		{"within", map[uint16][]byte{1: gzipZeros(t, 1000, 1000)}, pak.Limits{MaxResourceSize: 1000, MaxTotalSize: 1000}, ""},
+		{"resource size", map[uint16][]byte{1: gzipZeros(t, 1001, 1001)}, pak.Limits{MaxResourceSize: 1000}, "resource size"},
+		{"resource size lying header", map[uint16][]byte{1: gzipZeros(t, 1<<20, 10)}, pak.Limits{MaxResourceSize: 1000}, "resource size"},
+		{"total size", map[uint16][]byte{1: gzipZeros(t, 600, 600), 2: gzipZeros(t, 600, 600)}, pak.Limits{MaxTotalSize: 1000}, "total size"},
+		{"total size with plain", map[uint16][]byte{1: make([]byte, 600), 2: gzipZeros(t, 600, 1)}, pak.Limits{MaxTotalSize: 1000}, "total size"},
+		{"total size lying header", map[uint16][]byte{1: gzipZeros(t, 1<<20, 10)}, pak.Limits{MaxResourceSize: 1 << 21, MaxTotalSize: 1000}, "total size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := pak.Write(&buf, &pak.PakFile{Version: 5, Resourses: tt.res})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := buf.Bytes()
+
+			for _, lazy := range []bool{false, true} {
+				opts := &pak.ReadOptions{Limits: tt.limits, Decompress: true, Workers: 1}
+				var err error
+				if lazy {
+					// Lazy resources are checked for size as they are loaded
+					if tt.limit != "resource size" {
+						continue
+					}
+					opts.LazyThreshold = 1
+					var p *pak.PakFile
+					p, err = pak.ReadAt(bytes.NewReader(data), int64(len(data)), opts)
+					if err == nil {
+						_, err = p.Load(1)
+					}
+				} else {
+					_, err = pak.ReadWithOptions(bytes.NewReader(data), opts)
+				}
+
+				var le *pak.LimitError
+				switch {
+				case tt.limit == "" && err != nil:
+					t.Errorf("lazy=%v: unexpected error %v", lazy, err)
+				case tt.limit != "" && (!errors.As(err, &le) || le.Limit != tt.limit):
+					t.Errorf("lazy=%v: error %v, want %s limit error", lazy, err, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestReadDecompressBrotliHeader(t *testing.T) {
+	decoded := false
+	pak.RegisterBrotli(func(b []byte) ([]byte, error) {
+		decoded = true
+		return nil, errors.New("not reached")
+	}, nil)
+	defer pak.RegisterBrotli(nil, nil)
+
+	res := []byte{0x1e, 0x9b, 0, 0, 0, 0, 1, 0, 0x0b}
+	var buf bytes.Buffer
+	err := pak.Write(&buf, &pak.PakFile{Version: 5, Resourses: map[uint16][]byte{1: res}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pak.ReadWithOptions(&buf, &pak.ReadOptions{Limits: pak.Limits{MaxResourceSize: 1 << 20}, Decompress: true})
+	var le *pak.LimitError
+	if !errors.As(err, &le) || le.Limit != "resource size" {
+		t.Errorf("error %v, want resource size limit error", err)
+	}
+	if decoded {
+		t.Error("brotli data over the limit was decoded")
+	}
+}
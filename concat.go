@@ -0,0 +1,38 @@
+package pak
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+)
+
+// Reads paks written back to back into one stream until EOF, e.g. by
+// concatenating pak files. Each pak ends with its last resource, so Layout
+// of the results has no trailer; bytes between paks fail as a bad header.
+func ReadAll(r io.Reader) ([]*PakFile, error) {
+	return ReadAllWithOptions(r, nil)
+}
+
+// Same as ReadAll using the given options (nil means defaults), applied to
+// each pak separately
+func ReadAllWithOptions(r io.Reader, opts *ReadOptions) ([]*PakFile, error) {
+	br := bufio.NewReader(r)
+
+	var paks []*PakFile
+	for {
+		_, err := br.Peek(1)
+		if err == io.EOF {
+			return paks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := readPakInto(context.Background(), br, opts, nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("error reading pak %d of stream: %v", len(paks), err)
+		}
+		paks = append(paks, p)
+	}
+}
@@ -0,0 +1,197 @@
+//go:build conformance
+
+// Conformance harness checking the writer and reader against Chromium's
+// tools/grit/grit/format/data_pack.py. Run with
+//
+//	PAK_GRIT_DIR=~/chromium/src/tools/grit go test -tags conformance -run Conformance
+//
+// Python 3 must be on PATH as python3.
+
+package pak_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+// Runs data_pack operations on JSON requests read from stdin
+const dataPackScript = `
+import base64, json, sys
+sys.path.insert(0, sys.argv[1])
+from grit.format import data_pack
+
+req = json.load(sys.stdin)
+if req["op"] == "write":
+    resources = {int(k): base64.b64decode(v) for k, v in req["resources"].items()}
+    data = data_pack.WriteDataPackToString(resources, req["encoding"])
+    json.dump({"data": base64.b64encode(data).decode()}, sys.stdout)
+else:
+    c = data_pack.ReadDataPackFromString(base64.b64decode(req["data"]))
+    json.dump({
+        "version": c.version,
+        "encoding": c.encoding,
+        "resources": {str(k): base64.b64encode(v).decode() for k, v in c.resources.items()},
+    }, sys.stdout)
+`
+
+type dataPackRequest struct {
+	Op        string            `json:"op"`
+	Encoding  int               `json:"encoding"`
+	Resources map[string][]byte `json:"resources"`
+	Data      []byte            `json:"data"`
+}
+
+type dataPackResponse struct {
+	Version   int               `json:"version"`
+	Encoding  int               `json:"encoding"`
+	Resources map[string][]byte `json:"resources"`
+	Data      []byte            `json:"data"`
+}
+
+// Runs request through data_pack.py, skipping the test without a grit checkout
+func dataPack(t *testing.T, req dataPackRequest) dataPackResponse {
+	t.Helper()
+	grit := os.Getenv("PAK_GRIT_DIR")
+	if grit == "" {
+		t.Skip("PAK_GRIT_DIR not set to tools/grit of a chromium checkout")
+	}
+
+	in, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("python3", "-c", dataPackScript, grit)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("error running data_pack.py: %v", err)
+	}
+
+	var resp dataPackResponse
+	err = json.Unmarshal(out, &resp)
+	if err != nil {
+		t.Fatalf("error decoding data_pack.py output: %v", err)
+	}
+	return resp
+}
+
+// Returns generated paks keyed by name: the paktest samples, plus random paks
+// with sparse ids, empty resources and duplicate data
+func conformanceCorpus() map[string]*pak.PakFile {
+	corpus := paktest.Samples()
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := range 8 {
+		p := &pak.PakFile{Version: 5, Encoding: pak.Encoding(i % 3), Resourses: make(map[uint16][]byte)}
+		var pool [][]byte
+		for range 1 + rnd.Intn(200) {
+			resId := uint16(1 + rnd.Intn(0xfffe))
+			var data []byte
+			switch {
+			case len(pool) > 0 && rnd.Intn(5) == 0:
+				data = pool[rnd.Intn(len(pool))]
+			case rnd.Intn(10) == 0:
+				data = []byte{}
+			default:
+				data = make([]byte, rnd.Intn(4096))
+				rnd.Read(data)
+				pool = append(pool, data)
+			}
+			p.Resourses[resId] = data
+		}
+		corpus["random-"+strconv.Itoa(i)] = p
+	}
+	return corpus
+}
+
+// Returns version 5 pak of resources stored the way data_pack.py writes
+// them: identical data is stored once, higher ids aliasing the lowest one
+func dedupe(encoding pak.Encoding, resources map[uint16][]byte) *pak.PakFile {
+	ids := make([]int, 0, len(resources))
+	for resId := range resources {
+		ids = append(ids, int(resId))
+	}
+	sort.Ints(ids)
+
+	p := &pak.PakFile{Version: 5, Encoding: encoding, Resourses: make(map[uint16][]byte), Aliases: make(map[uint16]uint16)}
+	first := make(map[string]uint16)
+	for _, id := range ids {
+		resId := uint16(id)
+		data := resources[resId]
+		p.Resourses[resId] = data
+		if target, ok := first[string(data)]; ok {
+			p.Aliases[resId] = target
+			continue
+		}
+		first[string(data)] = resId
+	}
+	return p
+}
+
+func TestConformanceWrite(t *testing.T) {
+	for name, p := range conformanceCorpus() {
+		t.Run(name, func(t *testing.T) {
+			req := dataPackRequest{Op: "write", Encoding: int(p.Encoding), Resources: make(map[string][]byte)}
+			for resId, resData := range p.Resourses {
+				req.Resources[strconv.Itoa(int(resId))] = append([]byte{}, resData...)
+			}
+			want := dataPack(t, req).Data
+
+			var buf bytes.Buffer
+			err := pak.Write(&buf, dedupe(p.Encoding, p.Resourses))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+			if !bytes.Equal(got, want) {
+				t.Fatalf("output differs from data_pack.py: %d bytes, want %d, first difference at %d", len(got), len(want), firstDifference(got, want))
+			}
+		})
+	}
+}
+
+func TestConformanceRead(t *testing.T) {
+	for name, p := range conformanceCorpus() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := pak.Write(&buf, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := dataPack(t, dataPackRequest{Op: "read", Data: buf.Bytes()})
+
+			q := &pak.PakFile{Version: pak.Version(resp.Version), Encoding: pak.Encoding(resp.Encoding), Resourses: make(map[uint16][]byte)}
+			for key, resData := range resp.Resources {
+				resId, err := strconv.ParseUint(key, 10, 16)
+				if err != nil {
+					t.Fatalf("bad id %q from data_pack.py", key)
+				}
+				q.Resourses[uint16(resId)] = resData
+			}
+			// data_pack.py resolves aliases to the data of their targets
+			want := p.Clone()
+			want.Aliases = nil
+			paktest.RequireEqual(t, want, q)
+		})
+	}
+}
+
+// Returns index of first differing byte of a and b
+func firstDifference(a, b []byte) int {
+	for i := range min(len(a), len(b)) {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return min(len(a), len(b))
+}
@@ -0,0 +1,107 @@
+package pak
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Chrome extension files start with magic followed by format version
+var crxMagic = []byte("Cr24")
+
+// Returns zip archive contained in Chrome extension (.crx) of the given size.
+// Both version 2 and version 3 headers are supported, signatures are not
+// checked.
+func OpenCRX(r io.ReaderAt, size int64) (*zip.Reader, error) {
+	var hdr [16]byte
+	n, err := r.ReadAt(hdr[:], 0)
+	if n < 12 {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("error reading crx header: %v", err)
+	}
+	if string(hdr[:4]) != string(crxMagic) {
+		return nil, fmt.Errorf("error reading crx header: not a crx file")
+	}
+
+	var start uint64
+	switch version := binary.LittleEndian.Uint32(hdr[4:]); version {
+	case 2:
+		// Public key length and signature length
+		if n < 16 {
+			return nil, fmt.Errorf("error reading crx header: %v", io.ErrUnexpectedEOF)
+		}
+		start = 16 + uint64(binary.LittleEndian.Uint32(hdr[8:])) + uint64(binary.LittleEndian.Uint32(hdr[12:]))
+	case 3:
+		// Length of protobuf encoded header
+		start = 12 + uint64(binary.LittleEndian.Uint32(hdr[8:]))
+	default:
+		return nil, fmt.Errorf("error reading crx header: unsupported version %d", version)
+	}
+	if start > uint64(size) {
+		return nil, fmt.Errorf("error reading crx header: header length %d exceeds file size %d", start, size)
+	}
+
+	return zip.NewReader(io.NewSectionReader(r, int64(start), size-int64(start)), size-int64(start))
+}
+
+// Returns names of .pak files in zip archive, in archive order
+func ZipPaks(zr *zip.Reader) []string {
+	var names []string
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() && strings.EqualFold(path.Ext(f.Name), ".pak") {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Reads pak struct from file in zip archive
+func ReadZipPak(zr *zip.Reader, name string) (*PakFile, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", name, err)
+	}
+	return p, nil
+}
+
+// Reads every .pak file contained in Chrome extension file, keyed by path
+// inside the extension
+func ReadCRXFile(name string) (map[string]*PakFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := OpenCRX(f, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	paks := make(map[string]*PakFile)
+	for _, pakName := range ZipPaks(zr) {
+		p, err := ReadZipPak(zr, pakName)
+		if err != nil {
+			return nil, err
+		}
+		paks[pakName] = p
+	}
+	return paks, nil
+}
@@ -0,0 +1,285 @@
+package pak
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Delta format, all integers little endian:
+// 4 byte magic "PAKD"
+// 1 byte delta format version
+// Deflate compressed body:
+// 4 byte version and 1 byte encoding of the new pak
+// 4 byte number of resources, then for each resource in ascending id order:
+//   2 byte resource id
+//   1 byte operation, followed by
+//     deltaCopy:    32 byte SHA-256 of an old resource with the same data
+//     deltaLiteral: 4 byte length and data
+//     deltaPatch:   32 byte SHA-256 of the old base resource,
+//                   32 byte SHA-256 of the result,
+//                   4 byte length of common prefix, 4 byte length of common
+//                   suffix, 4 byte length and data of the middle part
+// 4 byte number of aliases, then for each alias:
+//   2 byte alias id, 2 byte target id
+
+const deltaMagic = "PAKD"
+const deltaVersion = 1
+
+const (
+	deltaCopy = iota
+	deltaLiteral
+	deltaPatch
+)
+
+var errBadDelta = errors.New("pak: malformed delta")
+
+// Returns compact delta transforming old pak into new. Resources present in
+// old (under any id) are referenced by hash, changed resources are encoded
+// against the old resource with the same id when that is smaller than data.
+// Lazy resources of both paks are loaded.
+func MakeDelta(old, new *PakFile) ([]byte, error) {
+	old, err := old.loaded()
+	if err != nil {
+		return nil, err
+	}
+	new, err = new.loaded()
+	if err != nil {
+		return nil, err
+	}
+
+	oldHashes := make(map[[sha256.Size]byte]bool, len(old.Resourses))
+	for _, resData := range old.Resourses {
+		oldHashes[sha256.Sum256(resData)] = true
+	}
+
+	var body bytes.Buffer
+	le := binary.LittleEndian
+
+	binary.Write(&body, le, new.Version)
+	binary.Write(&body, le, new.Encoding)
+
+	ids := sortedIds(new)
+
+	binary.Write(&body, le, uint32(len(ids)))
+	for _, resId := range ids {
+		resData := new.Resourses[resId]
+		sum := sha256.Sum256(resData)
+
+		binary.Write(&body, le, resId)
+
+		if oldHashes[sum] {
+			body.WriteByte(deltaCopy)
+			body.Write(sum[:])
+			continue
+		}
+
+		if base, ok := old.Resourses[resId]; ok {
+			prefix, suffix := commonAffixes(base, resData)
+			middle := resData[prefix : len(resData)-suffix]
+			if 2*sha256.Size+12+len(middle) < 4+len(resData) {
+				baseSum := sha256.Sum256(base)
+				body.WriteByte(deltaPatch)
+				body.Write(baseSum[:])
+				body.Write(sum[:])
+				binary.Write(&body, le, uint32(prefix))
+				binary.Write(&body, le, uint32(suffix))
+				binary.Write(&body, le, uint32(len(middle)))
+				body.Write(middle)
+				continue
+			}
+		}
+
+		body.WriteByte(deltaLiteral)
+		binary.Write(&body, le, uint32(len(resData)))
+		body.Write(resData)
+	}
+
+	aliasIds := make([]uint16, 0, len(new.Aliases))
+	for aliasId := range new.Aliases {
+		aliasIds = append(aliasIds, aliasId)
+	}
+	sortIds(aliasIds)
+
+	binary.Write(&body, le, uint32(len(aliasIds)))
+	for _, aliasId := range aliasIds {
+		binary.Write(&body, le, aliasId)
+		binary.Write(&body, le, new.Aliases[aliasId])
+	}
+
+	var out bytes.Buffer
+	out.WriteString(deltaMagic)
+	out.WriteByte(deltaVersion)
+	zw, _ := flate.NewWriter(&out, flate.BestCompression)
+	zw.Write(body.Bytes())
+	zw.Close()
+
+	return out.Bytes(), nil
+}
+
+// Returns lengths of common prefix and suffix of a and b, not overlapping in either
+func commonAffixes(a, b []byte) (prefix, suffix int) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for prefix < n && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < n-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return
+}
+
+// Applies delta made by MakeDelta to old pak and returns the new pak.
+// Old pak is not modified. Every resource is verified against the hashes
+// recorded in the delta. Lazy resources of old pak are loaded.
+func ApplyDelta(old *PakFile, delta []byte) (*PakFile, error) {
+	if len(delta) < len(deltaMagic)+1 || string(delta[:len(deltaMagic)]) != deltaMagic {
+		return nil, errBadDelta
+	}
+	if delta[len(deltaMagic)] != deltaVersion {
+		return nil, fmt.Errorf("pak: unsupported delta version %d", delta[len(deltaMagic)])
+	}
+	old, err := old.loaded()
+	if err != nil {
+		return nil, err
+	}
+
+	oldByHash := make(map[[sha256.Size]byte][]byte, len(old.Resourses))
+	for _, resData := range old.Resourses {
+		oldByHash[sha256.Sum256(resData)] = resData
+	}
+
+	r := bufio.NewReader(flate.NewReader(bytes.NewReader(delta[len(deltaMagic)+1:])))
+	le := binary.LittleEndian
+
+	p := &PakFile{Resourses: make(map[uint16][]byte)}
+
+	err = binary.Read(r, le, &p.Version)
+	if err != nil {
+		return nil, errBadDelta
+	}
+	err = binary.Read(r, le, &p.Encoding)
+	if err != nil {
+		return nil, errBadDelta
+	}
+
+	var n uint32
+	err = binary.Read(r, le, &n)
+	if err != nil {
+		return nil, errBadDelta
+	}
+
+	readHash := func() (sum [sha256.Size]byte, err error) {
+		_, err = io.ReadFull(r, sum[:])
+		return
+	}
+	readData := func() ([]byte, error) {
+		var length uint32
+		err := binary.Read(r, le, &length)
+		if err != nil {
+			return nil, err
+		}
+		return readBytes(r, uint64(length))
+	}
+
+	for i := uint32(0); i < n; i++ {
+		var resId uint16
+		err = binary.Read(r, le, &resId)
+		if err != nil {
+			return nil, errBadDelta
+		}
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, errBadDelta
+		}
+
+		switch op {
+		case deltaCopy:
+			sum, err := readHash()
+			if err != nil {
+				return nil, errBadDelta
+			}
+			resData, ok := oldByHash[sum]
+			if !ok {
+				return nil, fmt.Errorf("pak: delta does not apply: data of resource id=%d not found in old pak", resId)
+			}
+			p.Resourses[resId] = cloneBytes(resData)
+
+		case deltaLiteral:
+			resData, err := readData()
+			if err != nil {
+				return nil, errBadDelta
+			}
+			if resData == nil {
+				resData = []byte{}
+			}
+			p.Resourses[resId] = resData
+
+		case deltaPatch:
+			baseSum, err := readHash()
+			if err != nil {
+				return nil, errBadDelta
+			}
+			sum, err := readHash()
+			if err != nil {
+				return nil, errBadDelta
+			}
+			var prefix, suffix uint32
+			binary.Read(r, le, &prefix)
+			err = binary.Read(r, le, &suffix)
+			if err != nil {
+				return nil, errBadDelta
+			}
+			middle, err := readData()
+			if err != nil {
+				return nil, errBadDelta
+			}
+
+			base, ok := oldByHash[baseSum]
+			if !ok || uint64(prefix)+uint64(suffix) > uint64(len(base)) {
+				return nil, fmt.Errorf("pak: delta does not apply: base of resource id=%d not found in old pak", resId)
+			}
+			resData := make([]byte, 0, int(prefix)+len(middle)+int(suffix))
+			resData = append(resData, base[:prefix]...)
+			resData = append(resData, middle...)
+			resData = append(resData, base[len(base)-int(suffix):]...)
+			if sha256.Sum256(resData) != sum {
+				return nil, fmt.Errorf("pak: delta does not apply: resource id=%d hash mismatch", resId)
+			}
+			p.Resourses[resId] = resData
+
+		default:
+			return nil, errBadDelta
+		}
+	}
+
+	err = binary.Read(r, le, &n)
+	if err != nil {
+		return nil, errBadDelta
+	}
+	for i := uint32(0); i < n; i++ {
+		var aliasId, target uint16
+		binary.Read(r, le, &aliasId)
+		err = binary.Read(r, le, &target)
+		if err != nil {
+			return nil, errBadDelta
+		}
+		if p.Aliases == nil {
+			p.Aliases = make(map[uint16]uint16)
+		}
+		p.Aliases[aliasId] = target
+		if targetData, ok := p.Resourses[target]; ok && bytes.Equal(targetData, p.Resourses[aliasId]) {
+			p.Resourses[aliasId] = targetData
+		}
+	}
+
+	return p, nil
+}
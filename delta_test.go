@@ -0,0 +1,93 @@
+package pak_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+// Id of a resource large enough to be encoded as a patch
+const idLarge uint16 = 400
+
+// Returns sample pak with a large text resource
+func deltaBase() *pak.PakFile {
+	p := paktest.Sample(5, pak.EncodingUTF8)
+	p.Set(idLarge, bytes.Repeat([]byte("line of text\n"), 300))
+	return p
+}
+
+// Returns base pak changed in every way a delta encodes: a resource
+// patched, one copied from another id, one added and one removed
+func changedBase() *pak.PakFile {
+	p := deltaBase()
+	large := bytes.Clone(p.Resourses[idLarge])
+	copy(large[2000:], "changed")
+	p.Set(idLarge, large)
+	p.Set(150, p.Resourses[paktest.IDImage])
+	p.Set(300, []byte("added"))
+	p.Delete(paktest.IDCSS)
+	return p
+}
+
+func TestApplyDelta(t *testing.T) {
+	old := deltaBase()
+	delta, err := pak.MakeDelta(old, changedBase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delta) > 1000 {
+		t.Errorf("delta takes %d bytes, large resource was not patched", len(delta))
+	}
+
+	p, err := pak.ApplyDelta(old, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paktest.RequireEqual(t, changedBase(), p)
+	paktest.RequireEqual(t, deltaBase(), old)
+}
+
+func TestApplyDeltaMismatch(t *testing.T) {
+	old := deltaBase()
+	delta, err := pak.MakeDelta(old, changedBase())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patchedBase := deltaBase()
+	patchedBase.Set(idLarge, bytes.Repeat([]byte("other text\n"), 300))
+
+	copiedBase := deltaBase()
+	copiedBase.Set(paktest.IDImage, []byte("not an image"))
+
+	header := append([]byte("PAKD"), 1)
+
+	tests := []struct {
+		name  string
+		old   *pak.PakFile
+		delta []byte
+		err   string
+	}{
+		{"other pak", &pak.PakFile{Version: 5, Resourses: map[uint16][]byte{1: []byte("other")}}, delta, "delta does not apply"},
+		{"sample without large resource", paktest.Sample(5, pak.EncodingUTF8), delta, "delta does not apply"},
+		{"empty pak", &pak.PakFile{Version: 5}, delta, "delta does not apply"},
+		{"patch base changed", patchedBase, delta, "delta does not apply"},
+		{"copy source changed", copiedBase, delta, "delta does not apply"},
+		{"bad magic", old, append([]byte("PAKX"), delta[4:]...), "malformed delta"},
+		{"unsupported version", old, append([]byte("PAKD\x02"), delta[5:]...), "unsupported delta version"},
+		{"truncated", old, delta[:len(delta)/2], "malformed delta"},
+		{"header only", old, header, "malformed delta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pak.ApplyDelta(tt.old, tt.delta)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ApplyDelta error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
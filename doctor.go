@@ -0,0 +1,151 @@
+package pak
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Health of the paks of a browser or Electron installation
+type DoctorReport struct {
+	Paks     []string             // paks found, slash-separated paths relative to the directory
+	Findings map[string][]Finding // by pak path, "" for the installation as a whole
+}
+
+// Reports whether no finding has error severity
+func (r *DoctorReport) OK() bool {
+	for _, fs := range r.Findings {
+		if HasErrors(fs) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *DoctorReport) add(path string, f ...Finding) {
+	if len(f) > 0 {
+		r.Findings[path] = append(r.Findings[path], f...)
+	}
+}
+
+// Examines every pak under installation directory of Chrome, Chromium, Edge
+// or an Electron app: validates each, checks that locale paks hold the
+// strings of en-US, which shows up as blank UI text otherwise, and that
+// 200% scale paks hold no resources missing from 100% ones.
+func Doctor(dir string) (*DoctorReport, error) {
+	r := &DoctorReport{Findings: make(map[string][]Finding)}
+
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && strings.EqualFold(filepath.Ext(name), ".pak") {
+			rel, err := filepath.Rel(dir, name)
+			if err != nil {
+				return err
+			}
+			r.Paks = append(r.Paks, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(r.Paks)
+
+	var global findings
+	if len(r.Paks) == 0 {
+		global.add(SeverityError, "no paks found")
+	}
+
+	paks := make(map[string]*PakFile)
+	localeDirs := make(map[string][]string)
+	for _, path := range r.Paks {
+		name := filepath.Join(dir, filepath.FromSlash(path))
+		fs, err := ValidateFile(name)
+		if err != nil {
+			return nil, err
+		}
+		r.add(path, fs...)
+		if HasErrors(fs) {
+			continue
+		}
+		p, err := ReadFile(name)
+		if err != nil {
+			r.add(path, Finding{Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		paks[path] = p
+
+		if d := filepath.Dir(filepath.FromSlash(path)); filepath.Base(d) == "locales" {
+			localeDirs[filepath.ToSlash(d)] = append(localeDirs[filepath.ToSlash(d)], path)
+		}
+	}
+
+	for d, locales := range localeDirs {
+		r.checkLocales(d, locales, paks)
+	}
+	for path, p := range paks {
+		if base, ok := strings.CutSuffix(path, "_200_percent.pak"); ok {
+			r.checkScale(path, p, paks[base+"_100_percent.pak"])
+		}
+	}
+
+	r.add("", global...)
+	return r, nil
+}
+
+// Checks locale paks of locales directory against its en-US.pak
+func (r *DoctorReport) checkLocales(dir string, locales []string, paks map[string]*PakFile) {
+	ref, ok := paks[dir+"/en-US.pak"]
+	if !ok {
+		var fs findings
+		fs.add(SeverityWarning, "%s has no en-US.pak to compare locales with", dir)
+		r.add("", fs...)
+		return
+	}
+
+	for _, path := range locales {
+		p := paks[path]
+		if p == ref {
+			continue
+		}
+		var fs findings
+		missing := 0
+		for resId := range ref.IDs() {
+			if !p.Has(resId) {
+				missing++
+			}
+		}
+		if missing > 0 {
+			fs.add(SeverityError, "%d of %d strings of en-US missing, shown blank in the UI", missing, ref.Len())
+		}
+		for resId, data := range p.All() {
+			if len(data) == 0 && len(ref.Resourses[resId]) > 0 {
+				fs.addId(SeverityWarning, resId, "empty string, not empty in en-US")
+			}
+		}
+		r.add(path, fs...)
+	}
+}
+
+// Checks 200% scale pak against its 100% counterpart
+func (r *DoctorReport) checkScale(path string, p, base *PakFile) {
+	var fs findings
+	if base == nil {
+		fs.add(SeverityWarning, "no matching 100%% scale pak")
+		r.add(path, fs...)
+		return
+	}
+	extra := 0
+	for resId := range p.IDs() {
+		if !base.Has(resId) {
+			extra++
+		}
+	}
+	if extra > 0 {
+		fs.add(SeverityWarning, "%d resources missing from 100%% scale pak, no fallback at 1x", extra)
+	}
+	r.add(path, fs...)
+}
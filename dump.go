@@ -0,0 +1,96 @@
+package pak
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Options controlling what Dump prints
+type DumpOptions struct {
+	Hex int // number of leading bytes of each resource to show in hex, zero for none
+}
+
+// Returns one line summary of pak. Lazy resources that cannot be read are
+// left out.
+func (p *PakFile) String() string {
+	p = p.available()
+	wp := p.plan(nil)
+	var size int64
+	for _, resId := range wp.order {
+		size += int64(len(p.Resourses[resId]))
+	}
+	return fmt.Sprintf("pak v%d %s: %d resources, %d aliases, %d bytes of data", p.Version, p.Encoding, len(wp.order), len(wp.aliases), size)
+}
+
+// Prints layout of the file Write would produce: header, index entries with
+// offsets and lengths, alias table, padding and trailing data. Lazy resources
+// are loaded.
+func (p *PakFile) Dump(w io.Writer, opts *DumpOptions) error {
+	if opts == nil {
+		opts = &DumpOptions{}
+	}
+	p, err := p.loaded()
+	if err != nil {
+		return err
+	}
+
+	wp := p.plan(nil)
+	h := wp.header
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "header: %d bytes\n", h.length())
+	fmt.Fprintf(tw, "  version\t%d\n", h.version)
+	fmt.Fprintf(tw, "  encoding\t%d (%s)\n", h.encoding, Encoding(h.encoding))
+	fmt.Fprintf(tw, "  resources\t%d\n", h.resources)
+	if h.version == 5 {
+		fmt.Fprintf(tw, "  aliases\t%d\n", h.aliases)
+	}
+
+	fmt.Fprintf(tw, "index: %d bytes at %d\n", (2+4)*(uint64(h.resources)+1), h.length())
+	fmt.Fprintf(tw, "  #\tid\toffset\tlength\tcompression\ttype\n")
+	offset := h.indexEnd() + uint64(len(wp.padding))
+	for i, resId := range wp.order {
+		resData := p.Resourses[resId]
+		t := sniffContentType(resData, p.Encoding)
+		t, _, _ = strings.Cut(t, ";")
+		fmt.Fprintf(tw, "  %d\t%d\t%d\t%d\t%s\t%s\n", i, resId, offset, len(resData), DetectCompression(resData), t)
+		offset += uint64(len(resData)) + uint64(wp.gap(i))
+	}
+	fmt.Fprintf(tw, "  %d\t0\t%d\t\t\tterminator\n", len(wp.order), offset)
+
+	if len(wp.aliases) > 0 {
+		fmt.Fprintf(tw, "aliases: %d bytes at %d\n", (2+2)*len(wp.aliases), h.indexEnd()-uint64((2+2)*len(wp.aliases)))
+		fmt.Fprintf(tw, "  id\tentry\ttarget\n")
+		for _, ai := range wp.aliases {
+			fmt.Fprintf(tw, "  %d\t%d\t%d\n", ai.id, ai.index, wp.order[ai.index])
+		}
+	}
+	if len(wp.padding) > 0 {
+		fmt.Fprintf(tw, "padding: %d bytes at %d\n", len(wp.padding), h.indexEnd())
+	}
+	if len(wp.trailer) > 0 {
+		fmt.Fprintf(tw, "trailer: %d bytes at %d\n", len(wp.trailer), offset)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if opts.Hex <= 0 {
+		return nil
+	}
+	for _, resId := range wp.order {
+		resData := p.Resourses[resId]
+		fmt.Fprintf(w, "\nresource id=%d:\n", resId)
+		_, err = io.WriteString(w, hex.Dump(resData[:min(len(resData), opts.Hex)]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
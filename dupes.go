@@ -0,0 +1,101 @@
+package pak
+
+import (
+	"crypto/sha256"
+	"sort"
+)
+
+// Resource of one of several paks
+type ResourceRef struct {
+	Pak int // index of the pak in the list passed in
+	Id  uint16
+}
+
+// Resources with identical data
+type DuplicateGroup struct {
+	Hash [sha256.Size]byte
+	Size int
+	Refs []ResourceRef // ordered by pak, then id
+}
+
+// Identical data found across one or more paks
+type DuplicateReport struct {
+	Groups []DuplicateGroup // groups of two or more, largest potential savings first
+
+	// Bytes saved by storing duplicates within each pak once, as version 5
+	// aliases do
+	AliasSavings int64
+
+	// Bytes saved on top of AliasSavings by storing data shared by several
+	// paks once, e.g. in a common pak
+	CrossPakSavings int64
+}
+
+// Hashes data of all resources and reports groups of identical non-empty data.
+// Resources already stored as aliases are not counted, as they take no space.
+// Lazy resources are loaded.
+func FindDuplicates(paks ...*PakFile) (*DuplicateReport, error) {
+	groups := make(map[[sha256.Size]byte]*DuplicateGroup)
+
+	for i, p := range paks {
+		p, err := p.loaded()
+		if err != nil {
+			return nil, err
+		}
+		for _, resId := range p.plan(nil).order {
+			resData := p.Resourses[resId]
+			if len(resData) == 0 {
+				continue
+			}
+			sum := sha256.Sum256(resData)
+			g, ok := groups[sum]
+			if !ok {
+				g = &DuplicateGroup{Hash: sum, Size: len(resData)}
+				groups[sum] = g
+			}
+			g.Refs = append(g.Refs, ResourceRef{Pak: i, Id: resId})
+		}
+	}
+
+	report := &DuplicateReport{}
+	savings := make(map[[sha256.Size]byte]int64)
+
+	for sum, g := range groups {
+		if len(g.Refs) < 2 {
+			continue
+		}
+
+		sort.Slice(g.Refs, func(i, j int) bool {
+			a, b := g.Refs[i], g.Refs[j]
+			if a.Pak != b.Pak {
+				return a.Pak < b.Pak
+			}
+			return a.Id < b.Id
+		})
+
+		paksWith := 0
+		for i, ref := range g.Refs {
+			if i == 0 || ref.Pak != g.Refs[i-1].Pak {
+				paksWith++
+			}
+		}
+
+		alias := int64(len(g.Refs)-paksWith) * int64(g.Size)
+		cross := int64(paksWith-1) * int64(g.Size)
+		report.AliasSavings += alias
+		report.CrossPakSavings += cross
+		savings[sum] = alias + cross
+
+		report.Groups = append(report.Groups, *g)
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if savings[a.Hash] != savings[b.Hash] {
+			return savings[a.Hash] > savings[b.Hash]
+		}
+		return a.Refs[0].Pak < b.Refs[0].Pak || a.Refs[0].Pak == b.Refs[0].Pak && a.Refs[0].Id < b.Refs[0].Id
+	})
+
+	return report, nil
+}
@@ -0,0 +1,89 @@
+package pak
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Encrypted resources start with magic followed by 12 byte nonce and AES-GCM
+// sealed data. Resource id is authenticated along with data, so encrypted
+// payloads cannot be swapped between resources.
+var encryptedMagic = []byte("PAKE\x01")
+
+const encryptedNonceLength = 12
+
+// Returned when encrypted resource is read without a key
+var ErrEncrypted = errors.New("pak: resource is encrypted")
+
+// Reports whether resource data is an encrypted envelope
+func IsEncrypted(data []byte) bool {
+	return len(data) >= len(encryptedMagic)+encryptedNonceLength && bytes.HasPrefix(data, encryptedMagic)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func resourceAAD(id uint16) []byte {
+	var aad [2]byte
+	binary.LittleEndian.PutUint16(aad[:], id)
+	return aad[:]
+}
+
+// Encrypts data of resource with AES-GCM, key must be 16, 24 or 32 bytes
+func EncryptResource(id uint16, data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting resource id=%d: %v", id, err)
+	}
+
+	out := make([]byte, len(encryptedMagic)+encryptedNonceLength, len(encryptedMagic)+encryptedNonceLength+len(data)+gcm.Overhead())
+	copy(out, encryptedMagic)
+	nonce := out[len(encryptedMagic):]
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(out, nonce, data, resourceAAD(id)), nil
+}
+
+// Decrypts resource data encrypted by EncryptResource. Data that is not an
+// encrypted envelope is returned as is.
+func DecryptResource(id uint16, data, key []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting resource id=%d: %v", id, err)
+	}
+
+	nonce := data[len(encryptedMagic) : len(encryptedMagic)+encryptedNonceLength]
+	out, err := gcm.Open(nil, nonce, data[len(encryptedMagic)+encryptedNonceLength:], resourceAAD(id))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting resource id=%d: %v", id, err)
+	}
+	return out, nil
+}
+
+// Returns transformer encrypting resources for which selected returns true,
+// or all resources if selected is nil. Paks written with it are decrypted by
+// reading with ReadOptions.Key set.
+func Encrypter(key []byte, selected func(id uint16) bool) Transformer {
+	return TransformFunc(func(id uint16, data []byte) ([]byte, error) {
+		if selected != nil && !selected(id) {
+			return data, nil
+		}
+		return EncryptResource(id, data, key)
+	})
+}
@@ -0,0 +1,107 @@
+package pak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// Pak appended to a file is followed by a footer: 8 byte little endian pak
+// length and magic
+var embedMagic = []byte("PAKEMBED")
+
+const embedFooterLength = 8 + 8
+
+// Returned when file has no pak appended
+var ErrNoEmbeddedPak = errors.New("pak: no embedded pak found")
+
+// Returns offset and length of pak appended to file of the given size
+func embeddedRange(r io.ReaderAt, size int64) (offset, length int64, err error) {
+	if size < embedFooterLength {
+		return 0, 0, ErrNoEmbeddedPak
+	}
+
+	var footer [embedFooterLength]byte
+	_, err = r.ReadAt(footer[:], size-embedFooterLength)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !bytes.Equal(footer[8:], embedMagic) {
+		return 0, 0, ErrNoEmbeddedPak
+	}
+
+	n := binary.LittleEndian.Uint64(footer[:8])
+	if n > uint64(size-embedFooterLength) {
+		return 0, 0, ErrNoEmbeddedPak
+	}
+	return size - embedFooterLength - int64(n), int64(n), nil
+}
+
+// Appends pak to file, typically an executable, followed by a footer locating
+// it. A pak appended before is replaced. The file is rewritten atomically and
+// keeps its permissions.
+func AppendToExecutable(name string, p *PakFile) error {
+	var buf bytes.Buffer
+	err := Write(&buf, p)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	end, _, err := embeddedRange(f, fi.Size())
+	if err == ErrNoEmbeddedPak {
+		end, err = fi.Size(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var footer [embedFooterLength]byte
+	binary.LittleEndian.PutUint64(footer[:8], uint64(buf.Len()))
+	copy(footer[8:], embedMagic)
+
+	return writeFileAtomic(name, func(w io.Writer) error {
+		_, err := io.Copy(w, io.NewSectionReader(f, 0, end))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(footer[:])
+		return err
+	})
+}
+
+// Reads pak appended to file by AppendToExecutable
+func ReadEmbedded(name string) (*PakFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset, length, err := embeddedRange(f, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+	return Read(io.NewSectionReader(f, offset, length))
+}
@@ -0,0 +1,16 @@
+//go:build !js
+
+package pak
+
+import (
+	"os"
+)
+
+// Reads pak appended to the running executable, see AppendToExecutable
+func ReadExecutable() (*PakFile, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return ReadEmbedded(exe)
+}
@@ -0,0 +1,137 @@
+package pak
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kind of region of a pak file
+type RegionKind int
+
+const (
+	RegionHeader   RegionKind = iota
+	RegionIndex               // index entries, or header and index if not known apart
+	RegionAliases             // alias table (version 5)
+	RegionPadding             // unreferenced bytes between index and data or between resources
+	RegionResource            // resource data
+	RegionTrailer             // bytes after the last resource
+)
+
+func (k RegionKind) String() string {
+	switch k {
+	case RegionHeader:
+		return "header"
+	case RegionIndex:
+		return "index"
+	case RegionAliases:
+		return "alias table"
+	case RegionPadding:
+		return "padding"
+	case RegionResource:
+		return "resource"
+	case RegionTrailer:
+		return "trailer"
+	}
+	return fmt.Sprintf("region(%d)", int(k))
+}
+
+// Part of a pak file holding an offset
+type Region struct {
+	Kind  RegionKind
+	Id    uint16 // resource id for RegionResource
+	Start int64
+	End   int64 // exclusive, -1 if unknown
+}
+
+func (r Region) String() string {
+	if r.Kind == RegionResource {
+		return fmt.Sprintf("resource id=%d [%d, %d)", r.Id, r.Start, r.End)
+	}
+	if r.End < 0 {
+		return fmt.Sprintf("%s [%d, end)", r.Kind, r.Start)
+	}
+	return fmt.Sprintf("%s [%d, %d)", r.Kind, r.Start, r.End)
+}
+
+// Tells which part of a pak file an offset falls into, given resource
+// locations from Reader.Index. Everything before the first resource is
+// reported as RegionIndex, as header and index cannot be told apart from
+// resource locations alone, see Reader.Explain. Negative offsets are reported
+// as RegionHeader.
+func Explain(index []ResourceInfo, off int64) Region {
+	var stored []ResourceInfo
+	for _, info := range index {
+		if !info.Alias {
+			stored = append(stored, info)
+		}
+	}
+	sort.SliceStable(stored, func(i, j int) bool { return stored[i].Offset < stored[j].Offset })
+
+	if len(stored) == 0 {
+		return Region{Kind: RegionIndex, Start: 0, End: -1}
+	}
+	if off < 0 {
+		return Region{Kind: RegionHeader, Start: off, End: 0}
+	}
+	if off < stored[0].Offset {
+		return Region{Kind: RegionIndex, Start: 0, End: stored[0].Offset}
+	}
+
+	// Last resource starting at or before offset, skipping empty ones
+	i := sort.Search(len(stored), func(i int) bool { return stored[i].Offset > off }) - 1
+	for i > 0 && stored[i].Length == 0 && stored[i-1].Offset == stored[i].Offset {
+		i--
+	}
+	info := stored[i]
+	end := info.Offset + info.Length
+	if off < end {
+		return Region{Kind: RegionResource, Id: info.Id, Start: info.Offset, End: end}
+	}
+
+	if i+1 < len(stored) {
+		return Region{Kind: RegionPadding, Start: end, End: stored[i+1].Offset}
+	}
+	return Region{Kind: RegionTrailer, Start: end, End: -1}
+}
+
+// Returns locations of all resources in file order, aliases last
+func (pr *Reader) Index() []ResourceInfo {
+	index := make([]ResourceInfo, 0, len(pr.infos))
+	for _, resId := range pr.ids {
+		index = append(index, pr.infos[resId])
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		a, b := index[i], index[j]
+		if a.Alias != b.Alias {
+			return !a.Alias
+		}
+		return a.Offset < b.Offset
+	})
+	return index
+}
+
+// Tells which part of the pak file an offset falls into: header, index, alias
+// table, padding, a resource or trailing data
+func (pr *Reader) Explain(off int64) Region {
+	h := pr.header
+	headerEnd := int64(h.length())
+	aliasesStart := int64(h.indexEnd()) - int64((2+2)*h.aliases)
+
+	switch {
+	case off < headerEnd:
+		return Region{Kind: RegionHeader, Start: 0, End: headerEnd}
+	case off < aliasesStart:
+		return Region{Kind: RegionIndex, Start: headerEnd, End: aliasesStart}
+	case off < int64(h.indexEnd()):
+		return Region{Kind: RegionAliases, Start: aliasesStart, End: int64(h.indexEnd())}
+	case off >= pr.dataEnd:
+		return Region{Kind: RegionTrailer, Start: pr.dataEnd, End: -1}
+	}
+
+	r := Explain(pr.Index(), off)
+	if r.Kind == RegionIndex {
+		// Between index and the first resource
+		r = Region{Kind: RegionPadding, Start: int64(h.indexEnd()), End: r.End}
+	}
+	return r
+}
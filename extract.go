@@ -0,0 +1,267 @@
+package pak
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Returns file name, a slash separated path relative to the output
+// directory, for resource data
+type NamingFunc func(id uint16, data []byte) string
+
+// Names files by bare resource id
+func NameByID(id uint16, data []byte) string {
+	return strconv.Itoa(int(id))
+}
+
+// Names files by resource id and extension guessed from content
+func NameByContent(id uint16, data []byte) string {
+	return strconv.Itoa(int(id)) + GuessExtension(data, EncodingUTF8)
+}
+
+// Names files from id -> name mapping, e.g. built from a symbol table,
+// falling back to another naming function for ids missing from names
+func NameFromMap(names map[uint16]string, fallback NamingFunc) NamingFunc {
+	return func(id uint16, data []byte) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+		return fallback(id, data)
+	}
+}
+
+// Options controlling how resources are extracted to files
+type ExtractOptions struct {
+	// NoExtensions names files by bare resource id, for strict round-tripping
+	// with tools expecting that. By default an extension guessed from content
+	// is appended, e.g. 1234.png or 5678.html.
+	NoExtensions bool
+
+	// Naming, if set, names files instead
+	Naming NamingFunc
+
+	// Progress is called after each file is written
+	Progress ProgressFunc
+
+	// Metadata, if set, is written to the directory as MetadataFileName,
+	// where PackDir leaves it alone and ReadDirMetadata finds it
+	Metadata Metadata
+}
+
+// File extensions for detected content types
+var extensions = map[string]string{
+	"image/png":        ".png",
+	"image/jpeg":       ".jpg",
+	"image/gif":        ".gif",
+	"image/webp":       ".webp",
+	"image/bmp":        ".bmp",
+	"image/x-icon":     ".ico",
+	"image/svg+xml":    ".svg",
+	"text/html":        ".html",
+	"text/javascript":  ".js",
+	"text/css":         ".css",
+	"text/xml":         ".xml",
+	"text/plain":       ".txt",
+	"application/json": ".json",
+	"application/pdf":  ".pdf",
+	"application/wasm": ".wasm",
+	"font/woff":        ".woff",
+	"font/woff2":       ".woff2",
+	"font/ttf":         ".ttf",
+	"audio/mpeg":       ".mp3",
+	"audio/wave":       ".wav",
+	"video/mp4":        ".mp4",
+	"video/webm":       ".webm",
+}
+
+// Guesses file extension of resource data, including a ".gz" or ".br" suffix
+// for compressed data. Returns empty string for unrecognized binary data.
+func GuessExtension(data []byte, encoding Encoding) string {
+	mime := sniffContentType(data, encoding)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+
+	ext := extensions[mime]
+	switch DetectCompression(data) {
+	case CompressionGzip:
+		ext += ".gz"
+	case CompressionBrotli:
+		ext += ".br"
+	}
+	return ext
+}
+
+// Writes every resource, aliases included, to its own file in dir, creating
+// dir if needed. Files are named by resource id, see ExtractOptions.
+// Resource data is written as stored, compressed resources stay compressed.
+// Lazy resources are loaded one at a time.
+func ExtractDir(p *PakFile, dir string, opts *ExtractOptions) error {
+	return ExtractDirContext(context.Background(), p, dir, opts)
+}
+
+// Same as ExtractDir, giving up with ctx.Err() once ctx is done. Cancellation
+// is checked between files, files written before it are left in place.
+func ExtractDirContext(ctx context.Context, p *PakFile, dir string, opts *ExtractOptions) error {
+	if opts == nil {
+		opts = &ExtractOptions{}
+	}
+
+	naming := opts.Naming
+	if naming == nil && opts.NoExtensions {
+		naming = NameByID
+	}
+	if naming == nil {
+		naming = func(id uint16, data []byte) string {
+			return strconv.Itoa(int(id)) + GuessExtension(data, p.Encoding)
+		}
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	ids, _ := p.ListIDs()
+	var done, total int64
+	for _, resId := range ids {
+		size, _ := p.SizeStored(resId)
+		total += size
+	}
+
+	names := make(map[string]uint16, len(ids))
+	for _, resId := range ids {
+		err = ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		var resData []byte
+		resData, err = p.Load(resId)
+		if err != nil {
+			return err
+		}
+
+		name := naming(resId, resData)
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("error extracting resource id=%d: bad file name %q", resId, name)
+		}
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("error extracting resource id=%d: file name %q already used by id=%d", resId, name, other)
+		}
+		names[name] = resId
+
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(path, resData, 0644)
+		if err != nil {
+			return err
+		}
+
+		done += int64(len(resData))
+		if opts.Progress != nil {
+			opts.Progress(resId, done, total)
+		}
+	}
+
+	if opts.Metadata != nil {
+		return WriteMetadataFile(filepath.Join(dir, MetadataFileName), opts.Metadata)
+	}
+	return nil
+}
+
+// Scans pak from r once, passing each resource to sink as it is reached in
+// file order. rd yields resource data as stored and is valid only until sink
+// returns, unread data is skipped. Aliases follow the resource they point to,
+// only data of a resource having aliases is held in memory.
+func ExtractStream(r io.Reader, sink func(id uint16, rd io.Reader) error) error {
+	h, err := readHeader(r)
+	if err != nil {
+		return err
+	}
+	if h.version != 4 && h.version != 5 {
+		return fmt.Errorf("error reading pak: unsupported version %d", h.version)
+	}
+	err = Encoding(h.encoding).Validate()
+	if err != nil {
+		return fmt.Errorf("error reading pak: %v", err)
+	}
+
+	resInfos, aliasInfos, err := readIndex(r, h)
+	if err != nil {
+		return err
+	}
+
+	numberOfResources := h.resources
+	if resInfos[numberOfResources].id != 0 {
+		return fmt.Errorf("error reading resources: last id != 0")
+	}
+	for i := uint32(0); i < numberOfResources; i++ {
+		if resInfos[i+1].offset < resInfos[i].offset {
+			return fmt.Errorf("error reading resource id=%d: offsets are not ascending", resInfos[i].id)
+		}
+	}
+
+	dataStart := uint64(resInfos[0].offset)
+	if dataStart < h.indexEnd() {
+		return fmt.Errorf("error reading resources: data offset %d overlaps index", dataStart)
+	}
+
+	// Aliases by index of the entry they point to
+	aliases := make(map[uint16][]uint16)
+	for _, ai := range aliasInfos {
+		if uint32(ai.index) >= numberOfResources {
+			return fmt.Errorf("error reading alias id=%d: entry index %d out of range", ai.id, ai.index)
+		}
+		aliases[ai.index] = append(aliases[ai.index], ai.id)
+	}
+
+	_, err = io.CopyN(io.Discard, r, int64(dataStart-h.indexEnd()))
+	if err != nil {
+		return err
+	}
+
+	for i := uint32(0); i < numberOfResources; i++ {
+		resId := resInfos[i].id
+		resLength := int64(resInfos[i+1].offset - resInfos[i].offset)
+
+		ids := aliases[uint16(i)]
+		if len(ids) == 0 {
+			lr := &io.LimitedReader{R: r, N: resLength}
+			err = sink(resId, lr)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(io.Discard, lr)
+			if err != nil {
+				return err
+			}
+			if lr.N > 0 {
+				return fmt.Errorf("error reading resource id=%d: %v", resId, io.ErrUnexpectedEOF)
+			}
+			continue
+		}
+
+		resData, err := readBytes(r, uint64(resLength))
+		if err != nil {
+			return fmt.Errorf("error reading resource id=%d: %v", resId, err)
+		}
+		for _, id := range append([]uint16{resId}, ids...) {
+			err = sink(id, bytes.NewReader(resData))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package pak
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Name of the index of a cached extraction
+const cacheIndexName = "index.json"
+
+// On-disk cache of extracted paks keyed by SHA-256 of the pak file, so
+// repeated inspections of the same pak skip reading and decompressing it.
+// Each pak is extracted once to Dir/<digest>/, resources decompressed where
+// possible and named by id and extension guessed from content. Entries are
+// created atomically, so concurrent users of one Dir are safe.
+type ExtractCache struct {
+	Dir string
+}
+
+// Returns extraction cache in the user cache directory, e.g.
+// ~/.cache/pak/extract on Linux
+func DefaultExtractCache() (*ExtractCache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &ExtractCache{Dir: filepath.Join(dir, "pak", "extract")}, nil
+}
+
+// Extracted pak in the cache
+type CachedPak struct {
+	Dir      string            `json:"-"`
+	Version  Version           `json:"version"`
+	Encoding Encoding          `json:"encoding"`
+	Files    map[uint16]string `json:"files"` // file names in Dir by resource id, aliases share files
+}
+
+// Returns decompressed resource data from the cache
+func (cp *CachedPak) ReadFile(id uint16) ([]byte, error) {
+	name, ok := cp.Files[id]
+	if !ok {
+		return nil, fmt.Errorf("error reading cached resource id=%d: not found", id)
+	}
+	return os.ReadFile(filepath.Join(cp.Dir, name))
+}
+
+// Returns extraction of pak file, extracting it on first use
+func (c *ExtractCache) Open(name string) (*CachedPak, error) {
+	digest, err := fileDigest(name)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(c.Dir, digest)
+
+	cp, err := readCacheIndex(dir)
+	if err == nil {
+		now := time.Now()
+		os.Chtimes(dir, now, now) // marks entry used for Prune
+		return cp, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	p, err := ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.extract(p, dir)
+}
+
+// Extracts pak to temporary directory renamed to dir when complete
+func (c *ExtractCache) extract(p *PakFile, dir string) (*CachedPak, error) {
+	err := os.MkdirAll(c.Dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(c.Dir, ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	cp := &CachedPak{Dir: dir, Version: p.Version, Encoding: p.Encoding, Files: make(map[uint16]string, len(p.Resourses))}
+	for resId, resData := range p.All() {
+		if target, ok := p.Aliases[resId]; ok && p.Has(target) {
+			continue
+		}
+		if raw, err := Decompress(resData); err == nil {
+			resData = raw
+		}
+		file := strconv.Itoa(int(resId)) + GuessExtension(resData, p.Encoding)
+		err = os.WriteFile(filepath.Join(tmp, file), resData, 0644)
+		if err != nil {
+			return nil, err
+		}
+		cp.Files[resId] = file
+	}
+	for aliasId, target := range p.Aliases {
+		if file, ok := cp.Files[target]; ok {
+			cp.Files[aliasId] = file
+		}
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(filepath.Join(tmp, cacheIndexName), data, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Rename(tmp, dir)
+	if err != nil {
+		// Extracted concurrently by someone else
+		if other, rerr := readCacheIndex(dir); rerr == nil {
+			return other, nil
+		}
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Removes entries not used for maxAge
+func (c *ExtractCache) Prune(maxAge time.Duration) error {
+	entries, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil || !e.IsDir() || fi.ModTime().After(cutoff) {
+			continue
+		}
+		err = os.RemoveAll(filepath.Join(c.Dir, e.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readCacheIndex(dir string) (*CachedPak, error) {
+	data, err := os.ReadFile(filepath.Join(dir, cacheIndexName))
+	if err != nil {
+		return nil, err
+	}
+	cp := &CachedPak{Dir: dir}
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cached pak %s: %v", dir, err)
+	}
+	return cp, nil
+}
+
+// Returns SHA-256 of file contents in hex
+func fileDigest(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
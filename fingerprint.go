@@ -0,0 +1,174 @@
+package pak
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Lowest similarity for GuessChromiumVersion to report a milestone
+const fingerprintMinScore = 0.5
+
+// Id set of a pak shipped with a Chromium milestone. Ids assigned by grit are
+// mostly contiguous, so the set is kept as ascending ranges.
+type Signature struct {
+	Milestone int
+	Count     int         // number of resources, aliases included
+	Ranges    [][2]uint16 // first and last id of each range
+}
+
+// Returns signature of reference pak shipped with milestone
+func NewSignature(milestone int, p *PakFile) Signature {
+	ids, _ := p.ListIDs()
+	s := Signature{Milestone: milestone, Count: len(ids)}
+	for _, resId := range ids {
+		n := len(s.Ranges)
+		if n > 0 && uint32(s.Ranges[n-1][1])+1 == uint32(resId) {
+			s.Ranges[n-1][1] = resId
+			continue
+		}
+		s.Ranges = append(s.Ranges, [2]uint16{resId, resId})
+	}
+	return s
+}
+
+// Formats signature as a single line, e.g. "m120 100-250,300,410-415"
+func (s Signature) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "m%d ", s.Milestone)
+	for i, r := range s.Ranges {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if r[0] == r[1] {
+			fmt.Fprintf(&b, "%d", r[0])
+		} else {
+			fmt.Fprintf(&b, "%d-%d", r[0], r[1])
+		}
+	}
+	return b.String()
+}
+
+// Parses signature formatted by Signature.String
+func ParseSignature(line string) (Signature, error) {
+	var s Signature
+
+	head, list, ok := strings.Cut(strings.TrimSpace(line), " ")
+	if !ok || !strings.HasPrefix(head, "m") {
+		return s, fmt.Errorf("error parsing signature: expected \"m<milestone> <ids>\"")
+	}
+	milestone, err := strconv.Atoi(head[1:])
+	if err != nil {
+		return s, fmt.Errorf("error parsing signature: bad milestone %q", head)
+	}
+	s.Milestone = milestone
+
+	for _, part := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		a, err := strconv.ParseUint(first, 10, 16)
+		if err != nil {
+			return s, fmt.Errorf("error parsing signature: bad id %q", first)
+		}
+		b := a
+		if isRange {
+			b, err = strconv.ParseUint(last, 10, 16)
+			if err != nil || b < a {
+				return s, fmt.Errorf("error parsing signature: bad range %q", part)
+			}
+		}
+		if n := len(s.Ranges); n > 0 && a <= uint64(s.Ranges[n-1][1]) {
+			return s, fmt.Errorf("error parsing signature: ranges not ascending at %q", part)
+		}
+		s.Ranges = append(s.Ranges, [2]uint16{uint16(a), uint16(b)})
+		s.Count += int(b-a) + 1
+	}
+	return s, nil
+}
+
+// Reports whether id belongs to signature
+func (s Signature) contains(id uint16) bool {
+	i := sort.Search(len(s.Ranges), func(i int) bool { return s.Ranges[i][1] >= id })
+	return i < len(s.Ranges) && s.Ranges[i][0] <= id
+}
+
+// Returns Jaccard similarity of pak ids and signature ids
+func (s Signature) similarity(p *PakFile) float64 {
+	ids, _ := p.ListIDs()
+	common := 0
+	for _, resId := range ids {
+		if s.contains(resId) {
+			common++
+		}
+	}
+	union := len(ids) + s.Count - common
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// Signatures of known milestones, one Signature.String line each, generated
+// from reference paks of official builds with "pak fingerprint -m", see
+// signatures.txt. Blank lines and lines starting with # are skipped.
+//
+//go:embed signatures.txt
+var knownSignatures string
+
+var signatures struct {
+	sync.Mutex
+	list   []Signature
+	loaded bool
+}
+
+// Adds signature used by GuessChromiumVersion
+func RegisterSignature(s Signature) {
+	signatures.Lock()
+	signatures.list = append(signatures.list, s)
+	signatures.Unlock()
+}
+
+// Returns bundled and registered signatures
+func allSignatures() []Signature {
+	signatures.Lock()
+	defer signatures.Unlock()
+	if !signatures.loaded {
+		for _, line := range strings.Split(knownSignatures, "\n") {
+			if line = strings.TrimSpace(line); line == "" || line[0] == '#' {
+				continue
+			}
+			s, err := ParseSignature(line)
+			if err != nil {
+				panic(err)
+			}
+			signatures.list = append(signatures.list, s)
+		}
+		signatures.loaded = true
+	}
+	return append([]Signature(nil), signatures.list...)
+}
+
+// Milestone match of a pak
+type Guess struct {
+	Milestone int
+	Score     float64 // Jaccard similarity of id sets, 1 for identical sets
+}
+
+// Guesses Chromium milestone a pak belongs to by comparing its id set with
+// signatures of known milestones. Returns false if no signature is similar
+// enough. Ids shift between milestones as resources are added and removed, so
+// close milestones may score alike; the guess is a hint for warnings, not a
+// proof. No signatures are bundled yet, see signatures.txt, so without
+// RegisterSignature it always returns false.
+func GuessChromiumVersion(p *PakFile) (Guess, bool) {
+	var best Guess
+	for _, s := range allSignatures() {
+		score := s.similarity(p)
+		if score > best.Score {
+			best = Guess{Milestone: s.Milestone, Score: score}
+		}
+	}
+	return best, best.Score >= fingerprintMinScore
+}
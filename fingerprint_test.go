@@ -0,0 +1,83 @@
+package pak_test
+
+import (
+	"testing"
+
+	"github.com/disintegration/pak"
+)
+
+// Returns pak holding empty resources with ids of the given ranges
+func pakWithIds(ranges ...[2]uint16) *pak.PakFile {
+	p := &pak.PakFile{Version: 5, Resourses: make(map[uint16][]byte)}
+	for _, r := range ranges {
+		for id := uint32(r[0]); id <= uint32(r[1]); id++ {
+			p.Resourses[uint16(id)] = []byte{}
+		}
+	}
+	return p
+}
+
+func TestGuessChromiumVersion(t *testing.T) {
+	// Reference id sets of consecutive milestones drifting the way grit
+	// numbering does: resources are added at the end of each range and some
+	// are removed
+	references := map[int]*pak.PakFile{
+		118: pakWithIds([2]uint16{100, 1999}, [2]uint16{2500, 2600}),
+		119: pakWithIds([2]uint16{100, 1299}, [2]uint16{1350, 2149}, [2]uint16{2500, 2650}),
+		120: pakWithIds([2]uint16{100, 1299}, [2]uint16{1400, 2399}, [2]uint16{2500, 2700}, [2]uint16{3000, 3100}),
+	}
+	for milestone, p := range references {
+		s := pak.NewSignature(milestone, p)
+		parsed, err := pak.ParseSignature(s.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pak.RegisterSignature(parsed)
+	}
+
+	for milestone, p := range references {
+		g, ok := pak.GuessChromiumVersion(p)
+		if !ok || g.Milestone != milestone || g.Score != 1 {
+			t.Errorf("reference pak of m%d guessed as %+v, %v", milestone, g, ok)
+		}
+	}
+
+	// Build of m119 with a few resources added and stripped
+	p := references[119]
+	for id := uint16(500); id < 520; id++ {
+		p.Delete(id)
+	}
+	for id := uint16(2651); id < 2660; id++ {
+		p.Set(id, []byte{})
+	}
+	g, ok := pak.GuessChromiumVersion(p)
+	if !ok || g.Milestone != 119 || g.Score >= 1 {
+		t.Errorf("modified pak of m119 guessed as %+v, %v", g, ok)
+	}
+
+	_, ok = pak.GuessChromiumVersion(pakWithIds([2]uint16{30000, 30100}))
+	if ok {
+		t.Error("unrelated pak matched a milestone")
+	}
+}
+
+func TestSignatureString(t *testing.T) {
+	s := pak.NewSignature(120, pakWithIds([2]uint16{1, 1}, [2]uint16{3, 5}, [2]uint16{65535, 65535}))
+	if got, want := s.String(), "m120 1,3-5,65535"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	parsed, err := pak.ParseSignature(s.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != s.String() || parsed.Count != s.Count {
+		t.Errorf("parsed %+v, want %+v", parsed, s)
+	}
+
+	for _, line := range []string{"120 1-5", "m120 5-1", "m120 5,3", "mx 1", "m120 1,,2"} {
+		_, err := pak.ParseSignature(line)
+		if err == nil {
+			t.Errorf("ParseSignature(%q) succeeded", line)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package pak_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+// Keeps fuzzed inputs from allocating more than a service would allow
+var fuzzLimits = pak.Limits{MaxResources: 1 << 16, MaxResourceSize: 1 << 20, MaxTotalSize: 1 << 22}
+
+func addSeeds(f *testing.F) {
+	for _, seed := range paktest.FuzzSeeds() {
+		f.Add(seed)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p, err := pak.ReadWithOptions(bytes.NewReader(data), &pak.ReadOptions{Limits: fuzzLimits})
+		if err != nil {
+			return
+		}
+
+		// Whatever was read must write and read back to the same contents
+		var buf bytes.Buffer
+		err = pak.Write(&buf, p)
+		if err != nil {
+			t.Fatalf("error writing pak that was read: %v", err)
+		}
+		q, err := pak.Read(&buf)
+		if err != nil {
+			t.Fatalf("error reading pak that was written: %v", err)
+		}
+		paktest.RequireEqual(t, p, q)
+
+		p.Validate()
+		pak.Report(p)
+		for resId := range p.Resourses {
+			p.ContentType(resId)
+		}
+	})
+}
+
+func FuzzRecover(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pak.Recover(bytes.NewReader(data))
+	})
+}
+
+func FuzzValidateReader(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fs, err := pak.ValidateReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("I/O error from in-memory reader: %v", err)
+		}
+
+		// A pak without validation errors must be readable
+		if !pak.HasErrors(fs) {
+			_, err = pak.Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("pak passed validation but failed to read: %v", err)
+			}
+		}
+	})
+}
+
+func FuzzStat(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pak.Stat(bytes.NewReader(data))
+	})
+}
+
+func FuzzCarve(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		candidates, err := pak.Carve(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("I/O error from in-memory reader: %v", err)
+		}
+		for _, c := range candidates {
+			if c.Offset < 0 || c.Offset+c.Size > int64(len(data)) {
+				t.Fatalf("candidate %+v out of bounds of %d bytes", c, len(data))
+			}
+		}
+	})
+}
+
+func FuzzDecompress(f *testing.F) {
+	for _, seed := range paktest.FuzzSeeds() {
+		f.Add(seed)
+	}
+	gz, _ := pak.Compress([]byte("resource data"), pak.CompressionGzip)
+	f.Add(gz)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pak.DecompressedSize(data)
+		pak.Decompress(data)
+	})
+}
@@ -0,0 +1,222 @@
+package pak
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Resource defined in a .grd file
+type GrdEntry struct {
+	Name        string // symbol, e.g. IDR_NEW_TAB_PAGE_HTML
+	Kind        string // element name: "include", "structure" or "message"
+	File        string // source file, empty for messages
+	Path        string // resource_path attribute: path WebUI serves the file at, if not File
+	Type        string // e.g. "BINDATA" or "chrome_scaled_image"
+	Compress    string // compress attribute: "gzip", "brotli", "false", "default" or empty
+	Conditional bool   // inside an <if> element, so possibly not built
+}
+
+// Resources defined in a GRIT .grd file, in document order
+type Grd struct {
+	Entries []GrdEntry
+}
+
+// Reads resource definitions from GRIT .grd file. Nested .grdp parts are not
+// followed.
+func ReadGrd(r io.Reader) (*Grd, error) {
+	g := &Grd{}
+	dec := xml.NewDecoder(r)
+	ifDepth := 0
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading grd: %v", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "if":
+				ifDepth++
+			case "include", "structure", "message":
+				e := GrdEntry{Kind: t.Name.Local, Conditional: ifDepth > 0}
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "name":
+						e.Name = a.Value
+					case "file":
+						e.File = a.Value
+					case "type":
+						e.Type = a.Value
+					case "compress":
+						e.Compress = a.Value
+					case "resource_path":
+						e.Path = a.Value
+					}
+				}
+				if e.Name != "" {
+					g.Entries = append(g.Entries, e)
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "if" {
+				ifDepth--
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// Reads .grd file, see ReadGrd
+func ReadGrdFile(name string) (*Grd, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadGrd(f)
+}
+
+// Writes .grd with include and structure entries of g in order, readable by
+// ReadGrd and grit. Messages and <if> conditions are not written.
+func WriteGrd(w io.Writer, g *Grd) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString("<grit latest_public_release=\"0\" current_release=\"1\">\n")
+	bw.WriteString("  <outputs>\n    <output filename=\"resources.h\" type=\"rc_header\" />\n  </outputs>\n")
+	bw.WriteString("  <release seq=\"1\">\n")
+	for _, kind := range []string{"include", "structure"} {
+		var entries []GrdEntry
+		for _, e := range g.Entries {
+			if e.Kind == kind {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "    <%ss>\n", kind)
+		for _, e := range entries {
+			fmt.Fprintf(bw, "      <%s name=%s file=%s", kind, xmlAttr(e.Name), xmlAttr(e.File))
+			if e.Type != "" {
+				fmt.Fprintf(bw, " type=%s", xmlAttr(e.Type))
+			}
+			if e.Compress != "" {
+				fmt.Fprintf(bw, " compress=%s", xmlAttr(e.Compress))
+			}
+			if e.Path != "" {
+				fmt.Fprintf(bw, " resource_path=%s", xmlAttr(e.Path))
+			}
+			bw.WriteString(" />\n")
+		}
+		fmt.Fprintf(bw, "    </%ss>\n", kind)
+	}
+	bw.WriteString("  </release>\n</grit>\n")
+	return bw.Flush()
+}
+
+// Returns s quoted as XML attribute value
+func xmlAttr(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return `"` + buf.String() + `"`
+}
+
+// Resource compressed differently than its .grd definition asks
+type GrdMismatch struct {
+	Name string
+	Id   uint16
+	Want Compression
+	Got  Compression
+}
+
+// Differences between a pak and the .grd it was built from
+type GrdReport struct {
+	Missing            []string      // defined unconditionally but absent from pak
+	MissingConditional []string      // defined inside <if> and absent, possibly by design
+	Unresolved         []string      // defined but missing from symbol table
+	Unexpected         []uint16      // ids in pak not defined by the .grd, ascending
+	Compression        []GrdMismatch // explicit compress attribute not honored
+}
+
+// Reports whether pak matches .grd, conditional resources may be absent
+func (r *GrdReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Unresolved) == 0 && len(r.Unexpected) == 0 && len(r.Compression) == 0
+}
+
+// Compares pak with .grd it was supposedly built from, resolving names to
+// ids with symbol table of the build, e.g. read from the generated header.
+// Compression is checked for entries with compress set to "gzip", "brotli"
+// or "false"; "default" depends on grit version and is not checked. Lazy
+// resources that cannot be read are reported as missing.
+func CompareGrd(p *PakFile, g *Grd, symbols SymbolTable) *GrdReport {
+	p = p.available()
+	r := &GrdReport{}
+	defined := make(map[uint16]bool)
+
+	for _, e := range g.Entries {
+		resId, ok := symbols[e.Name]
+		if !ok {
+			r.Unresolved = append(r.Unresolved, e.Name)
+			continue
+		}
+		defined[resId] = true
+
+		data, ok := p.Resourses[resId]
+		if !ok {
+			if e.Conditional {
+				r.MissingConditional = append(r.MissingConditional, e.Name)
+			} else {
+				r.Missing = append(r.Missing, e.Name)
+			}
+			continue
+		}
+
+		var want Compression
+		switch e.Compress {
+		case "gzip":
+			want = CompressionGzip
+		case "brotli":
+			want = CompressionBrotli
+		case "false":
+			want = CompressionNone
+		default:
+			continue
+		}
+		if got := DetectCompression(data); got != want {
+			r.Compression = append(r.Compression, GrdMismatch{Name: e.Name, Id: resId, Want: want, Got: got})
+		}
+	}
+
+	for _, resId := range sortedIds(p) {
+		if !defined[resId] {
+			r.Unexpected = append(r.Unexpected, resId)
+		}
+	}
+
+	sort.Strings(r.Missing)
+	sort.Strings(r.MissingConditional)
+	sort.Strings(r.Unresolved)
+	return r
+}
+
+// Returns path WebUI serves the entry at, without leading slash
+func (e *GrdEntry) servedPath() string {
+	p := e.Path
+	if p == "" {
+		p = e.File
+	}
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
@@ -0,0 +1,105 @@
+package pak
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Returns ids of resources with the given data, in ascending order
+func (p *PakFile) FindByContent(data []byte) []uint16 {
+	var ids []uint16
+	for resId, resData := range p.Resourses {
+		if bytes.Equal(resData, data) {
+			ids = append(ids, resId)
+		}
+	}
+	sortIds(ids)
+	return ids
+}
+
+// Returns ids of resources whose data has the given SHA-256 hash, in ascending order
+func (p *PakFile) FindByHash(h [sha256.Size]byte) []uint16 {
+	var ids []uint16
+	for resId, resData := range p.Resourses {
+		if sha256.Sum256(resData) == h {
+			ids = append(ids, resId)
+		}
+	}
+	sortIds(ids)
+	return ids
+}
+
+// Resource of a named pak
+type Location struct {
+	Name string // pak name, the file path for paks added by AddDir
+	Id   uint16
+}
+
+// Index of resources of many paks keyed by SHA-256 hash of their data
+type HashIndex struct {
+	byHash map[[sha256.Size]byte][]Location
+}
+
+// Returns empty index
+func NewHashIndex() *HashIndex {
+	return &HashIndex{byHash: make(map[[sha256.Size]byte][]Location)}
+}
+
+// Adds all resources of pak under the given name, loading lazy ones. On error
+// the index is not changed.
+func (x *HashIndex) Add(name string, p *PakFile) error {
+	p, err := p.loaded()
+	if err != nil {
+		return err
+	}
+	for _, resId := range sortedIds(p) {
+		sum := sha256.Sum256(p.Resourses[resId])
+		x.byHash[sum] = append(x.byHash[sum], Location{Name: name, Id: resId})
+	}
+	return nil
+}
+
+// Adds every .pak file found in directory tree, named by its path.
+// Files that fail to read are skipped and returned in skipped.
+func (x *HashIndex) AddDir(dir string) (skipped []string, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".pak") {
+			return nil
+		}
+		p, err := ReadFile(path)
+		if err == nil {
+			err = x.Add(path, p)
+		}
+		if err != nil {
+			skipped = append(skipped, path)
+		}
+		return nil
+	})
+	return skipped, err
+}
+
+// Returns locations of resources with the given data
+func (x *HashIndex) FindByContent(data []byte) []Location {
+	return x.FindByHash(sha256.Sum256(data))
+}
+
+// Returns locations of resources whose data has the given SHA-256 hash
+func (x *HashIndex) FindByHash(h [sha256.Size]byte) []Location {
+	return append([]Location(nil), x.byHash[h]...)
+}
+
+// Returns resource ids of pak in ascending order
+func sortedIds(p *PakFile) []uint16 {
+	ids := make([]uint16, 0, len(p.Resourses))
+	for resId := range p.Resourses {
+		ids = append(ids, resId)
+	}
+	sortIds(ids)
+	return ids
+}
@@ -0,0 +1,111 @@
+package pak
+
+import (
+	"encoding/base64"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Largest image embedded into HTML report, bigger ones are listed only
+const htmlPreviewMaxImage = 256 << 10
+
+// Length of text snippets in HTML report
+const htmlPreviewMaxText = 400
+
+type htmlEntry struct {
+	ReportEntry
+	Image template.URL // data URI of image preview
+	Text  string       // text snippet
+}
+
+type htmlReport struct {
+	Title   string
+	Version Version
+	Summary *SizeReport
+	Entries []htmlEntry
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+td.num { text-align: right; }
+img { max-width: 256px; max-height: 256px; background: #eee; }
+pre { margin: 0; max-width: 60em; white-space: pre-wrap; font-size: 85%; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Version {{.Version}}, {{.Summary.Count}} resources, {{.Summary.Aliases}} aliases,
+{{.Summary.Stored}} bytes stored, {{.Summary.Raw}} bytes decompressed.</p>
+<h2>By type</h2>
+<table>
+<tr><th>Type</th><th>Count</th><th>Stored</th><th>Decompressed</th></tr>
+{{range .Summary.Types}}<tr><td>{{.Type}}</td><td class="num">{{.Count}}</td><td class="num">{{.Stored}}</td><td class="num">{{.Raw}}</td></tr>
+{{end}}</table>
+<h2>Resources</h2>
+<table>
+<tr><th>Id</th><th>Stored</th><th>Decompressed</th><th>Compression</th><th>Type</th><th>Preview</th></tr>
+{{range .Entries}}<tr id="r{{.Id}}"><td class="num">{{.Id}}</td>
+{{if .Alias}}<td colspan="5">alias of <a href="#r{{.AliasOf}}">{{.AliasOf}}</a></td>
+{{else}}<td class="num">{{.Stored}}</td><td class="num">{{.Raw}}</td><td>{{.Compression}}</td><td>{{.Type}}</td>
+<td>{{if .Image}}<img src="{{.Image}}" alt="{{.Id}}">{{else if .Text}}<pre>{{.Text}}</pre>{{end}}</td>
+{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Writes standalone HTML page listing every resource with its sizes, type and
+// an inline preview: images are embedded, text resources are shown as
+// snippets.
+func WriteHTMLReport(w io.Writer, p *PakFile, title string) error {
+	d, err := NewReportData(p)
+	if err != nil {
+		return err
+	}
+	report := htmlReport{Title: title, Version: d.Version, Summary: d.Summary}
+
+	for _, re := range d.Entries {
+		e := htmlEntry{ReportEntry: re}
+		if e.Alias {
+			report.Entries = append(report.Entries, e)
+			continue
+		}
+
+		raw, err := Decompress(e.Data)
+		if err == nil {
+			mime := e.Type
+			if i := strings.IndexByte(mime, ';'); i >= 0 {
+				mime = mime[:i]
+			}
+			switch {
+			case strings.HasPrefix(mime, "image/") && len(raw) <= htmlPreviewMaxImage:
+				e.Image = template.URL("data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(raw))
+			case strings.HasPrefix(mime, "text/") || mime == "application/json":
+				e.Text = textSnippet(raw, p.Encoding)
+			}
+		}
+
+		report.Entries = append(report.Entries, e)
+	}
+
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// Returns beginning of text resource as valid UTF-8
+func textSnippet(data []byte, encoding Encoding) string {
+	if isUTF16Text(data, encoding) {
+		data = utf16ToUTF8(data)
+	}
+	if len(data) <= htmlPreviewMaxText {
+		return strings.ToValidUTF8(string(data), "\uFFFD")
+	}
+	return strings.ToValidUTF8(string(data[:htmlPreviewMaxText]), "") + "\u2026"
+}
@@ -0,0 +1,270 @@
+package pak
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Matches strings holding ICU MessageFormat plural or select arguments
+var icuArgRe = regexp.MustCompile(`\{\s*\w+\s*,\s*(?:plural|selectordinal|select)\s*,`)
+
+// CLDR cardinal plural categories besides "other" by language
+var pluralCategories = map[string][]string{
+	"ja": {}, "ko": {}, "zh": {}, "vi": {}, "th": {}, "id": {}, "ms": {}, "lo": {}, "km": {}, "my": {},
+	"en": {"one"}, "de": {"one"}, "nl": {"one"}, "sv": {"one"}, "da": {"one"}, "nb": {"one"}, "no": {"one"},
+	"fi": {"one"}, "et": {"one"}, "el": {"one"}, "hu": {"one"}, "tr": {"one"}, "bg": {"one"}, "ka": {"one"},
+	"fil": {"one"}, "hi": {"one"}, "bn": {"one"}, "fa": {"one"}, "sw": {"one"}, "ta": {"one"}, "te": {"one"},
+	"it": {"one", "many"}, "es": {"one", "many"}, "pt": {"one", "many"}, "fr": {"one", "many"}, "ca": {"one", "many"},
+	"ro": {"one", "few"}, "hr": {"one", "few"}, "sr": {"one", "few"}, "bs": {"one", "few"},
+	"ru": {"one", "few", "many"}, "uk": {"one", "few", "many"}, "be": {"one", "few", "many"},
+	"pl": {"one", "few", "many"}, "lt": {"one", "few", "many"}, "cs": {"one", "few", "many"}, "sk": {"one", "few", "many"},
+	"lv": {"zero", "one"}, "he": {"one", "two"}, "sl": {"one", "two", "few"},
+	"ga": {"one", "two", "few", "many"}, "mt": {"one", "two", "few", "many"},
+	"ar": {"zero", "one", "two", "few", "many"}, "cy": {"zero", "one", "two", "few", "many"},
+}
+
+// All CLDR plural categories
+var pluralKeywords = []string{"zero", "one", "two", "few", "many", "other"}
+
+// Returns plural categories of locale, e.g. "pt-BR", or nil if unknown
+func localePlurals(locale string) []string {
+	lang := strings.ToLower(locale)
+	lang, _, _ = strings.Cut(lang, "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	cats, ok := pluralCategories[lang]
+	if !ok {
+		return nil
+	}
+	return append(cats[:len(cats):len(cats)], "other")
+}
+
+// Checks ICU MessageFormat syntax of string: balanced braces, plural,
+// selectordinal and select arguments with an other branch and no duplicate
+// selectors, plural categories valid for locale. Locale may be empty or
+// unknown, then any CLDR category is accepted.
+func ValidateICU(s, locale string) error {
+	p := &icuParser{s: s, plurals: localePlurals(locale)}
+	err := p.message(0)
+	if err == nil && p.pos < len(p.s) {
+		err = p.errorf("unbalanced }")
+	}
+	return err
+}
+
+// Checks ICU syntax of strings of catalog using plural or select
+// arguments, see ValidateICU. Findings are in ascending id order.
+func CheckICU(c Catalog, locale string) []Finding {
+	var fs findings
+	for _, resId := range sortedCatalogIds(c) {
+		s := c[resId]
+		if !icuArgRe.MatchString(s) {
+			continue
+		}
+		err := ValidateICU(s, locale)
+		if err != nil {
+			fs.addId(SeverityError, resId, "%v", err)
+		}
+	}
+	return fs
+}
+
+// Returns ids of catalog in ascending order
+func sortedCatalogIds(c Catalog) []uint16 {
+	ids := make([]uint16, 0, len(c))
+	for resId := range c {
+		ids = append(ids, resId)
+	}
+	sortIds(ids)
+	return ids
+}
+
+type icuParser struct {
+	s       string
+	pos     int
+	plurals []string // categories of locale, nil for any
+}
+
+func (p *icuParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("ICU syntax at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *icuParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// Returns run of letters, digits, underscores and, if extra allows, other
+// characters at position
+func (p *icuParser) word(extra string) string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 || strings.IndexByte(extra, c) >= 0 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+// Parses message text up to the } closing it at depth > 0, or to the end
+func (p *icuParser) message(depth int) error {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\'':
+			// '' is an apostrophe, '{ and '} start quoted text up to the
+			// next apostrophe
+			if p.pos+1 < len(p.s) && (p.s[p.pos+1] == '{' || p.s[p.pos+1] == '}') {
+				end := strings.IndexByte(p.s[p.pos+1:], '\'')
+				if end < 0 {
+					return p.errorf("unterminated quote")
+				}
+				p.pos += end + 2
+			} else {
+				p.pos++
+			}
+		case '{':
+			p.pos++
+			err := p.argument()
+			if err != nil {
+				return err
+			}
+		case '}':
+			if depth == 0 {
+				return p.errorf("unbalanced }")
+			}
+			return nil
+		default:
+			p.pos++
+		}
+	}
+	if depth > 0 {
+		return p.errorf("unbalanced {")
+	}
+	return nil
+}
+
+// Parses argument after its {, up to and including the closing }
+func (p *icuParser) argument() error {
+	p.skipSpace()
+	if p.word("") == "" {
+		return p.errorf("missing argument name")
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+	if p.pos >= len(p.s) || p.s[p.pos] != ',' {
+		return p.errorf("expected , or } after argument name")
+	}
+	p.pos++
+	p.skipSpace()
+
+	kind := p.word("")
+	switch kind {
+	case "plural", "selectordinal", "select":
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != ',' {
+			return p.errorf("expected , after %s", kind)
+		}
+		p.pos++
+		return p.cases(kind)
+	case "":
+		return p.errorf("missing argument type")
+	}
+
+	// Simple argument like {n, number, integer}, style may hold braces
+	depth := 1
+	for ; p.pos < len(p.s); p.pos++ {
+		switch p.s[p.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+	}
+	return p.errorf("unbalanced {")
+}
+
+// Parses selector {message} pairs of plural or select argument up to and
+// including its closing }
+func (p *icuParser) cases(kind string) error {
+	seen := make(map[string]bool)
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return p.errorf("unbalanced {")
+		}
+		if p.s[p.pos] == '}' {
+			break
+		}
+
+		start := p.pos
+		selector := p.word("=:-")
+		if selector == "" {
+			return p.errorf("expected %s selector", kind)
+		}
+		if kind == "plural" && strings.HasPrefix(selector, "offset:") {
+			continue
+		}
+		err := p.checkSelector(kind, selector)
+		if err != nil {
+			p.pos = start
+			return err
+		}
+		if seen[selector] {
+			p.pos = start
+			return p.errorf("duplicate selector %s", selector)
+		}
+		seen[selector] = true
+
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+			return p.errorf("expected { after selector %s", selector)
+		}
+		p.pos++
+		err = p.message(1)
+		if err != nil {
+			return err
+		}
+		p.pos++ // closing }
+	}
+
+	if !seen["other"] {
+		return p.errorf("%s without other branch", kind)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *icuParser) checkSelector(kind, selector string) error {
+	if kind == "select" {
+		return nil
+	}
+	if n, ok := strings.CutPrefix(selector, "="); ok {
+		if n == "" || strings.Trim(n, "0123456789") != "" {
+			return p.errorf("bad explicit value %s", selector)
+		}
+		return nil
+	}
+
+	cats := pluralKeywords
+	if kind == "plural" && p.plurals != nil {
+		cats = p.plurals
+	}
+	for _, c := range cats {
+		if c == selector {
+			return nil
+		}
+	}
+	return p.errorf("%s category %s is not one of %s", kind, selector, strings.Join(cats, ", "))
+}
@@ -0,0 +1,27 @@
+package pak
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Decodes image resource, looking through compression wrappers.
+// PNG, JPEG and GIF are supported out of the box, other formats work once
+// their decoder is registered with the image package, e.g. WebP by importing
+// golang.org/x/image/webp.
+func (p *PakFile) GetImage(id uint16) (image.Image, error) {
+	data, err := p.decompressed(id)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image id=%d: %v", id, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,58 @@
+package pak
+
+import (
+	"iter"
+)
+
+// Returns iterator over resources in ascending id order, aliases and lazy
+// resources included. The set of ids is taken when iteration starts. Lazy
+// resources are loaded as they are reached, ones that cannot be read are
+// skipped.
+func (p *PakFile) All() iter.Seq2[uint16, []byte] {
+	return func(yield func(uint16, []byte) bool) {
+		ids, _ := p.ListIDs()
+		for _, resId := range ids {
+			resData, err := p.Load(resId)
+			if err != nil {
+				continue // deleted during iteration or unreadable
+			}
+			if !yield(resId, resData) {
+				return
+			}
+		}
+	}
+}
+
+// Returns iterator over resource ids in ascending order, aliases and lazy
+// resources included
+func (p *PakFile) IDs() iter.Seq[uint16] {
+	return func(yield func(uint16) bool) {
+		ids, _ := p.ListIDs()
+		for _, resId := range ids {
+			if !yield(resId) {
+				return
+			}
+		}
+	}
+}
+
+// Returns iterator over resources in the order Write stores them: data
+// entries in file order recorded by Read, or ascending, followed by aliases.
+// Lazy resources are loaded when iteration starts, ones that cannot be read
+// are skipped.
+func (p *PakFile) InFileOrder() iter.Seq2[uint16, []byte] {
+	return func(yield func(uint16, []byte) bool) {
+		p := p.available()
+		wp := p.plan(nil)
+		for _, resId := range wp.order {
+			if !yield(resId, p.Resourses[resId]) {
+				return
+			}
+		}
+		for _, ai := range wp.aliases {
+			if !yield(ai.id, p.Resourses[ai.id]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package pak
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Decodes JSON resource into v, looking through compression wrappers and
+// converting UTF-16 text when the pak declares that encoding
+func (p *PakFile) GetJSON(id uint16, v any) error {
+	data, err := p.decompressed(id)
+	if err != nil {
+		return err
+	}
+
+	if isUTF16Text(data, p.Encoding) {
+		data = utf16ToUTF8(data)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return fmt.Errorf("error decoding json id=%d: %v", id, err)
+	}
+	return nil
+}
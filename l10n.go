@@ -0,0 +1,195 @@
+package pak
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Strings of a locale pak, e.g. locales/de.pak, by resource id. Stored as
+// JSON object keyed by id:
+//
+//	{
+//	  "12345": "Neuer Tab"
+//	}
+type Catalog map[uint16]string
+
+// Returns locale of locale pak file, e.g. "de" for locales/de.pak
+func LocaleName(pakName string) string {
+	return strings.TrimSuffix(filepath.Base(pakName), filepath.Ext(pakName))
+}
+
+// Returns strings of pak decoded as UTF-8, aliases included. Text is decoded
+// from UTF-16 for paks declaring that encoding. Resources that are not valid
+// text in the pak encoding are skipped.
+func ExportStrings(p *PakFile) Catalog {
+	c := make(Catalog, len(p.Resourses))
+	for resId, resData := range p.Resourses {
+		if p.Encoding == EncodingUTF16 {
+			if len(resData)%2 != 0 {
+				continue
+			}
+			resData = utf16ToUTF8(resData)
+		}
+		if !utf8.Valid(resData) {
+			continue
+		}
+		c[resId] = string(resData)
+	}
+	return c
+}
+
+// Sets pak resources to strings of catalog, encoded as the pak declares.
+// Resources missing from the catalog are left alone.
+func ImportStrings(p *PakFile, c Catalog) error {
+	for resId, s := range c {
+		data := []byte(s)
+		if p.Encoding == EncodingUTF16 {
+			data = utf8ToUTF16(s)
+		}
+		err := p.Set(resId, data)
+		if err != nil {
+			return fmt.Errorf("error importing string id=%d: %v", resId, err)
+		}
+	}
+	return nil
+}
+
+// Returns ids of strings of ref missing from c in ascending order, i.e.
+// untranslated strings when ref is the source locale
+func MissingStrings(ref, c Catalog) []uint16 {
+	var ids []uint16
+	for resId := range ref {
+		if _, ok := c[resId]; !ok {
+			ids = append(ids, resId)
+		}
+	}
+	sortIds(ids)
+	return ids
+}
+
+// Matches $1-$9 substitutions, $$ escapes, and <ph name="..."> elements of
+// GRIT messages
+var placeholderRe = regexp.MustCompile(`\$\$|\$[1-9]|<ph\s+name\s*=\s*"([^"]+)"`)
+
+// Returns distinct placeholders of string in sorted order: "$1" style
+// substitutions and "<ph NAME>" for GRIT placeholders
+func Placeholders(s string) []string {
+	seen := make(map[string]bool)
+	var phs []string
+	for _, m := range placeholderRe.FindAllStringSubmatch(s, -1) {
+		ph := m[0]
+		switch {
+		case ph == "$$":
+			continue
+		case m[1] != "":
+			ph = "<ph " + m[1] + ">"
+		}
+		if !seen[ph] {
+			seen[ph] = true
+			phs = append(phs, ph)
+		}
+	}
+	sort.Strings(phs)
+	return phs
+}
+
+// Translated string whose placeholders differ from the source string's. A
+// missing placeholder drops a value at run time, an extra one is left
+// unreplaced or trips checks of the substitution code.
+type PlaceholderMismatch struct {
+	Id      uint16
+	Missing []string // in source but not in translation
+	Extra   []string // in translation but not in source
+}
+
+// Compares placeholders of strings of c with those of ref, the source
+// locale. Only strings present in both are checked, mismatches are returned
+// in ascending id order.
+func CheckPlaceholders(ref, c Catalog) []PlaceholderMismatch {
+	var mismatches []PlaceholderMismatch
+	for resId, s := range c {
+		src, ok := ref[resId]
+		if !ok {
+			continue
+		}
+		want, got := Placeholders(src), Placeholders(s)
+		m := PlaceholderMismatch{Id: resId, Missing: subtract(want, got), Extra: subtract(got, want)}
+		if len(m.Missing) > 0 || len(m.Extra) > 0 {
+			mismatches = append(mismatches, m)
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Id < mismatches[j].Id })
+	return mismatches
+}
+
+// Returns elements of sorted a missing from sorted b
+func subtract(a, b []string) []string {
+	var diff []string
+	for _, s := range a {
+		i := sort.SearchStrings(b, s)
+		if i == len(b) || b[i] != s {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+// Converts UTF-8 text to UTF-16LE data without byte order mark
+func utf8ToUTF16(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	data := make([]byte, 2*len(u))
+	for i, v := range u {
+		data[2*i], data[2*i+1] = byte(v), byte(v>>8)
+	}
+	return data
+}
+
+// Reads catalog written by WriteCatalog
+func ReadCatalog(r io.Reader) (Catalog, error) {
+	c := make(Catalog)
+	err := json.NewDecoder(r).Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("error reading catalog: %v", err)
+	}
+	return c, nil
+}
+
+// Writes catalog as indented JSON
+func WriteCatalog(w io.Writer, c Catalog) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(c)
+}
+
+// Reads catalog file
+func ReadCatalogFile(name string) (Catalog, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCatalog(f)
+}
+
+// Writes catalog file atomically
+func WriteCatalogFile(name string, c Catalog) error {
+	var buf bytes.Buffer
+	err := WriteCatalog(&buf, c)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(name, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
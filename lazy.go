@@ -0,0 +1,248 @@
+package pak
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Resource left unread by ReadAt, its data stays in the source pak until
+// loaded. The source must remain readable for as long as the placeholder is
+// used.
+type LazyResource struct {
+	Id     uint16
+	Offset int64 // position of data in source
+	Length int64
+
+	r          io.ReaderAt
+	key        []byte // decryption key of ReadOptions
+	decompress bool   // Decompress of ReadOptions
+	limits     Limits // Limits of ReadOptions, checked when decompressing
+}
+
+// Reads resource data from source
+func (lr *LazyResource) Load() ([]byte, error) {
+	data := make([]byte, lr.Length)
+	n, err := lr.r.ReadAt(data, lr.Offset)
+	if n == len(data) {
+		err = nil // data may end at EOF
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading resource id=%d: %v", lr.Id, err)
+	}
+	if lr.key != nil {
+		data, err = DecryptResource(lr.Id, data, lr.key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if lr.decompress && DetectCompression(data) != CompressionNone {
+		data, err = decompressResource(lr.Id, data, lr.limits, -1)
+		if _, ok := err.(*LimitError); ok {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing resource id=%d: %v", lr.Id, err)
+		}
+	}
+	return data, nil
+}
+
+// Reads pak struct from io.ReaderAt of the given size. Resources longer than
+// opts.LazyThreshold are not read, they are kept in Lazy as placeholders to be
+// loaded on demand, together with aliases of them. Limits apply to resources
+// that are read, lazy ones do not count towards MaxTotalSize. With
+// opts.Decompress lazy resources are decompressed as they are loaded. Layout
+// is not recorded.
+func ReadAt(r io.ReaderAt, size int64, opts *ReadOptions) (*PakFile, error) {
+	if opts == nil {
+		opts = &ReadOptions{}
+	}
+
+	pr, err := Open(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	err = opts.Limits.checkCount(uint32(pr.Len()))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PakFile{Version: pr.version, Encoding: pr.encoding, Resourses: make(map[uint16][]byte, pr.Len())}
+
+	index := pr.Index()
+	var done, total, read int64
+	for _, info := range index {
+		if !info.Alias {
+			total += info.Length
+		}
+	}
+
+	for _, info := range index {
+		if info.Alias {
+			if p.Aliases == nil {
+				p.Aliases = make(map[uint16]uint16)
+			}
+			p.Aliases[info.Id] = info.AliasOf
+			if lr, ok := p.Lazy[info.AliasOf]; ok {
+				p.Lazy[info.Id] = lr
+			} else {
+				p.Resourses[info.Id] = p.Resourses[info.AliasOf]
+			}
+			continue
+		}
+
+		done += info.Length
+		if opts.LazyThreshold > 0 && info.Length > opts.LazyThreshold {
+			if p.Lazy == nil {
+				p.Lazy = make(map[uint16]*LazyResource)
+			}
+			p.Lazy[info.Id] = &LazyResource{Id: info.Id, Offset: info.Offset, Length: info.Length, r: r, key: opts.Key}
+		} else {
+			err = opts.Limits.checkResourceSize(info.Id, uint32(info.Length))
+			if err != nil {
+				return nil, err
+			}
+			read += info.Length
+			err = opts.Limits.checkTotalSize(uint64(read))
+			if err != nil {
+				return nil, err
+			}
+			resData, err := pr.Get(info.Id)
+			if err != nil {
+				return nil, err
+			}
+			if opts.Key != nil {
+				resData, err = DecryptResource(info.Id, resData, opts.Key)
+				if err != nil {
+					return nil, err
+				}
+			}
+			p.Resourses[info.Id] = resData
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(info.Id, done, total)
+		}
+	}
+
+	if opts.Decompress {
+		err = decompressAll(context.Background(), p, opts.Workers, opts.Limits)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Reads pak stored at offset off of r and size bytes long, e.g. inside a
+// firmware blob, game archive or custom container
+func ReadSection(r io.ReaderAt, off, size int64) (*PakFile, error) {
+	return ReadSectionWithOptions(r, off, size, nil)
+}
+
+// Same as ReadSection using the given options (nil means defaults), see
+// ReadAt. Offsets of lazy resources are relative to the section.
+func ReadSectionWithOptions(r io.ReaderAt, off, size int64, opts *ReadOptions) (*PakFile, error) {
+	if off < 0 || size < 0 {
+		return nil, fmt.Errorf("error reading pak section: bad offset %d or size %d", off, size)
+	}
+	return ReadAt(io.NewSectionReader(r, off, size), size, opts)
+}
+
+// Returns resource data, reading it from source for lazy resources
+func (p *PakFile) Load(id uint16) ([]byte, error) {
+	if data, ok := p.Resourses[id]; ok {
+		return data, nil
+	}
+	if lr, ok := p.Lazy[id]; ok {
+		return lr.Load()
+	}
+	return nil, fmt.Errorf("resource id=%d not found", id)
+}
+
+// Reads every lazy resource into Resourses, leaving Lazy empty
+func (p *PakFile) LoadAll() error {
+	if len(p.Lazy) == 0 {
+		return nil
+	}
+	if p.Resourses == nil {
+		p.Resourses = make(map[uint16][]byte)
+	}
+
+	// Aliases share placeholders with their targets, read each once
+	loaded := make(map[*LazyResource][]byte, len(p.Lazy))
+	for _, resId := range sortedLazyIds(p) {
+		lr := p.Lazy[resId]
+		data, ok := loaded[lr]
+		if !ok {
+			var err error
+			data, err = lr.Load()
+			if err != nil {
+				return err
+			}
+			loaded[lr] = data
+		}
+		p.Resourses[resId] = data
+	}
+	p.Lazy = nil
+	return nil
+}
+
+// Returns pak with lazy resources loaded, p itself when it has none
+func (p *PakFile) loaded() (*PakFile, error) {
+	if len(p.Lazy) == 0 {
+		return p, nil
+	}
+	c := *p
+	c.Resourses = make(map[uint16][]byte, len(p.Resourses)+len(p.Lazy))
+	for resId, resData := range p.Resourses {
+		c.Resourses[resId] = resData
+	}
+	err := c.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Returns pak with lazy resources loaded as loaded does, skipping ones that
+// cannot be read, for accessors that have no way to report errors
+func (p *PakFile) available() *PakFile {
+	if len(p.Lazy) == 0 {
+		return p
+	}
+	c := *p
+	c.Resourses = make(map[uint16][]byte, len(p.Resourses)+len(p.Lazy))
+	for resId, resData := range p.Resourses {
+		c.Resourses[resId] = resData
+	}
+	loaded := make(map[*LazyResource][]byte, len(p.Lazy))
+	for resId, lr := range p.Lazy {
+		data, ok := loaded[lr]
+		if !ok {
+			var err error
+			data, err = lr.Load()
+			if err != nil {
+				continue
+			}
+			loaded[lr] = data
+		}
+		c.Resourses[resId] = data
+	}
+	c.Lazy = nil
+	return &c
+}
+
+func sortedLazyIds(p *PakFile) []uint16 {
+	ids := make([]uint16, 0, len(p.Lazy))
+	for resId := range p.Lazy {
+		ids = append(ids, resId)
+	}
+	sortIds(ids)
+	return ids
+}
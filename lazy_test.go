@@ -0,0 +1,247 @@
+package pak_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+// Reads sample with resources longer than 16 bytes left lazy
+func readLazySample(t *testing.T, decompress bool) (*pak.PakFile, *pak.PakFile) {
+	t.Helper()
+	data := paktest.SampleBytes(5, pak.EncodingUTF8)
+	eager, err := pak.ReadWithOptions(bytes.NewReader(data), &pak.ReadOptions{Decompress: decompress})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := pak.ReadAt(bytes.NewReader(data), int64(len(data)), &pak.ReadOptions{LazyThreshold: 16, Decompress: decompress})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lazy.Lazy) == 0 {
+		t.Fatal("no resource was left lazy")
+	}
+	return eager, lazy
+}
+
+func TestLazyAccess(t *testing.T) {
+	eager, lazy := readLazySample(t, false)
+
+	for resId := range lazy.Lazy {
+		data, ok := lazy.Get(resId)
+		if !ok || !bytes.Equal(data, eager.Resourses[resId]) {
+			t.Errorf("Get(%d) = %q, %v, want %q", resId, data, ok, eager.Resourses[resId])
+		}
+		raw, ok := lazy.SizeRaw(resId)
+		if want, _ := eager.SizeRaw(resId); !ok || raw != want {
+			t.Errorf("SizeRaw(%d) = %d, %v, want %d", resId, raw, ok, want)
+		}
+		if got, want := lazy.ContentType(resId), eager.ContentType(resId); got != want {
+			t.Errorf("ContentType(%d) = %q, want %q", resId, got, want)
+		}
+	}
+
+	var ids []uint16
+	for resId, resData := range lazy.All() {
+		ids = append(ids, resId)
+		if !bytes.Equal(resData, eager.Resourses[resId]) {
+			t.Errorf("All yields %q for %d, want %q", resData, resId, eager.Resourses[resId])
+		}
+	}
+	if len(ids) != eager.Len() {
+		t.Errorf("All yields %d resources, want %d", len(ids), eager.Len())
+	}
+
+	m, err := pak.NewManifest(lazy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := pak.NewManifest(eager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := pak.VerifyManifest(eager, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() || len(m) != len(want) {
+		t.Errorf("manifest of lazy pak does not match eager one: %+v", r)
+	}
+
+	got, err := pak.Report(lazy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReport, err := pak.Report(eager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != wantReport.String() {
+		t.Errorf("Report of lazy pak:\n%s\nwant:\n%s", got, wantReport)
+	}
+
+	dir := t.TempDir()
+	err = pak.ExtractDir(lazy, dir, &pak.ExtractOptions{NoExtensions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for resId, resData := range eager.Resourses {
+		data, err := os.ReadFile(filepath.Join(dir, strconv.Itoa(int(resId))))
+		if err != nil || !bytes.Equal(data, resData) {
+			t.Errorf("extracted %d = %q, %v, want %q", resId, data, err, resData)
+		}
+	}
+}
+
+func TestLazyDecompress(t *testing.T) {
+	eager, lazy := readLazySample(t, true)
+
+	data, err := lazy.Load(paktest.IDScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, eager.Resourses[paktest.IDScript]) {
+		t.Errorf("lazy script = %q, want %q", data, eager.Resourses[paktest.IDScript])
+	}
+}
+
+func TestLazyApplyOps(t *testing.T) {
+	_, lazy := readLazySample(t, false)
+
+	err := pak.ApplyOps(lazy, []pak.Op{{Op: "replace", Id: paktest.IDHTML, Data: []byte("new")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := lazy.Get(paktest.IDHTML)
+	if string(data) != "new" {
+		t.Errorf("replaced resource = %q, want %q", data, "new")
+	}
+	err = pak.ApplyOps(lazy, []pak.Op{{Op: "add", Id: paktest.IDScript, Data: []byte("x")}})
+	if err == nil {
+		t.Error("add of existing lazy resource succeeded")
+	}
+}
+
+// Returns pak written in pak format as string, for comparing results
+func written(p *pak.PakFile, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = pak.Write(&buf, p)
+	return buf.String(), err
+}
+
+func TestLazyWholePak(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func(p *pak.PakFile) (string, error)
+	}{
+		{"MakeDelta", func(p *pak.PakFile) (string, error) {
+			delta, err := pak.MakeDelta(&pak.PakFile{Version: 5}, p)
+			if err != nil {
+				return "", err
+			}
+			return written(pak.ApplyDelta(&pak.PakFile{Version: 5}, delta))
+		}},
+		{"ApplyDelta copies", func(p *pak.PakFile) (string, error) {
+			delta, err := pak.MakeDelta(p, p)
+			if err != nil {
+				return "", err
+			}
+			return written(pak.ApplyDelta(p, delta))
+		}},
+		{"Snapshot", func(p *pak.PakFile) (string, error) {
+			s, err := p.Snapshot()
+			if err != nil {
+				return "", err
+			}
+			var b strings.Builder
+			fmt.Fprintln(&b, s.Len())
+			for _, resId := range s.IDs() {
+				data, ok := s.Get(resId)
+				fmt.Fprintf(&b, "%d %v %q\n", resId, ok, data)
+			}
+			return b.String(), nil
+		}},
+		{"InFileOrder", func(p *pak.PakFile) (string, error) {
+			var b strings.Builder
+			for resId, resData := range p.InFileOrder() {
+				fmt.Fprintf(&b, "%d %q\n", resId, resData)
+			}
+			return b.String(), nil
+		}},
+		{"ToZip", func(p *pak.PakFile) (string, error) {
+			var buf bytes.Buffer
+			err := pak.ToZip(&buf, p)
+			if err != nil {
+				return "", err
+			}
+			return written(pak.FromZip(bytes.NewReader(buf.Bytes()), int64(buf.Len())))
+		}},
+		{"ToTar", func(p *pak.PakFile) (string, error) {
+			var buf bytes.Buffer
+			err := pak.ToTar(&buf, p)
+			if err != nil {
+				return "", err
+			}
+			return written(pak.FromTar(&buf))
+		}},
+		{"Canonicalize", func(p *pak.PakFile) (string, error) {
+			c := p.Clone()
+			err := pak.Canonicalize(c)
+			return written(c, err)
+		}},
+		{"FindDuplicates", func(p *pak.PakFile) (string, error) {
+			r, err := pak.FindDuplicates(p, p)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprint(*r), nil
+		}},
+		{"HashIndex", func(p *pak.PakFile) (string, error) {
+			x := pak.NewHashIndex()
+			err := x.Add("sample", p)
+			if err != nil {
+				return "", err
+			}
+			var b strings.Builder
+			for resId, resData := range paktest.Sample(5, pak.EncodingUTF8).All() {
+				fmt.Fprintln(&b, resId, x.FindByContent(resData))
+			}
+			return b.String(), nil
+		}},
+		{"String", func(p *pak.PakFile) (string, error) {
+			return p.String(), nil
+		}},
+		{"Dump", func(p *pak.PakFile) (string, error) {
+			var buf bytes.Buffer
+			err := p.Dump(&buf, &pak.DumpOptions{Hex: 16})
+			return buf.String(), err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eager, lazy := readLazySample(t, false)
+			want, err := tt.f(eager)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.f(lazy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("lazy pak gives\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
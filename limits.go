@@ -0,0 +1,50 @@
+package pak
+
+import (
+	"fmt"
+)
+
+// Limits on what a parsed pak may claim, guarding against hostile input.
+// Limits are checked against the index before any resource data is allocated.
+// Zero value of a field means no limit.
+type Limits struct {
+	MaxResources    uint32 // maximum number of resources
+	MaxResourceSize uint32 // maximum size of a single resource in bytes
+	MaxTotalSize    uint64 // maximum size of all resource data, padding and trailing data in bytes
+}
+
+// Returned by reading functions when a pak exceeds one of the configured limits
+type LimitError struct {
+	Limit string // "resource count", "resource size" or "total size"
+	Id    uint16 // resource id, set for "resource size" only, 0 if unknown
+	Value uint64 // value claimed by the pak
+	Max   uint64 // configured limit
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == "resource size" && e.Id != 0 {
+		return fmt.Sprintf("pak limit exceeded: resource id=%d size %d > %d", e.Id, e.Value, e.Max)
+	}
+	return fmt.Sprintf("pak limit exceeded: %s %d > %d", e.Limit, e.Value, e.Max)
+}
+
+func (l Limits) checkCount(n uint32) error {
+	if l.MaxResources != 0 && n > l.MaxResources {
+		return &LimitError{Limit: "resource count", Value: uint64(n), Max: uint64(l.MaxResources)}
+	}
+	return nil
+}
+
+func (l Limits) checkResourceSize(id uint16, n uint32) error {
+	if l.MaxResourceSize != 0 && n > l.MaxResourceSize {
+		return &LimitError{Limit: "resource size", Id: id, Value: uint64(n), Max: uint64(l.MaxResourceSize)}
+	}
+	return nil
+}
+
+func (l Limits) checkTotalSize(n uint64) error {
+	if l.MaxTotalSize != 0 && n > l.MaxTotalSize {
+		return &LimitError{Limit: "total size", Value: n, Max: l.MaxTotalSize}
+	}
+	return nil
+}
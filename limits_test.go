@@ -0,0 +1,86 @@
+package pak_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+func TestLimits(t *testing.T) {
+	data := paktest.SampleBytes(5, pak.EncodingUTF8)
+	sample := paktest.Sample(5, pak.EncodingUTF8)
+	var largest uint32
+	var total uint64
+	for resId, resData := range sample.Resourses {
+		if _, ok := sample.Aliases[resId]; !ok {
+			largest = max(largest, uint32(len(resData)))
+			total += uint64(len(resData))
+		}
+	}
+	count := uint32(len(sample.Resourses))
+
+	tests := []struct {
+		name   string
+		limits pak.Limits
+		limit  string // "" for no error
+	}{
+		{"none", pak.Limits{}, ""},
+		{"at limits", pak.Limits{MaxResources: count, MaxResourceSize: largest, MaxTotalSize: total}, ""},
+		{"resource count", pak.Limits{MaxResources: count - 1}, "resource count"},
+		{"resource size", pak.Limits{MaxResourceSize: largest - 1}, "resource size"},
+		{"total size", pak.Limits{MaxTotalSize: total - 1}, "total size"},
+	}
+
+	read := map[string]func(opts *pak.ReadOptions) error{
+		"Read": func(opts *pak.ReadOptions) error {
+			_, err := pak.ReadWithOptions(bytes.NewReader(data), opts)
+			return err
+		},
+		"ReadAt": func(opts *pak.ReadOptions) error {
+			_, err := pak.ReadAt(bytes.NewReader(data), int64(len(data)), opts)
+			return err
+		},
+	}
+
+	for _, tt := range tests {
+		for name, read := range read {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				err := read(&pak.ReadOptions{Limits: tt.limits})
+				var le *pak.LimitError
+				switch {
+				case tt.limit == "" && err != nil:
+					t.Errorf("unexpected error %v", err)
+				case tt.limit != "" && (!errors.As(err, &le) || le.Limit != tt.limit):
+					t.Errorf("error %v, want %s limit error", err, tt.limit)
+				}
+			})
+		}
+	}
+}
+
+func TestLimitsTrailer(t *testing.T) {
+	data := append(paktest.SampleBytes(5, pak.EncodingUTF8), make([]byte, 1000)...)
+	_, err := pak.ReadWithOptions(bytes.NewReader(data), &pak.ReadOptions{Limits: pak.Limits{MaxTotalSize: 1000}})
+	var le *pak.LimitError
+	if !errors.As(err, &le) || le.Limit != "total size" {
+		t.Errorf("error %v, want total size limit error for trailing data", err)
+	}
+}
+
+func TestLimitsPadding(t *testing.T) {
+	raw := rawPak{version: 5, encoding: 1, ids: []uint16{1}, data: [][]byte{[]byte("data")}, padding: make([]byte, 1000)}
+	_, err := pak.ReadWithOptions(bytes.NewReader(raw.bytes()), &pak.ReadOptions{Limits: pak.Limits{MaxTotalSize: 1000}})
+	var le *pak.LimitError
+	if !errors.As(err, &le) || le.Limit != "total size" {
+		t.Errorf("error %v, want total size limit error for padding", err)
+	}
+
+	raw.padding = make([]byte, 996)
+	_, err = pak.ReadWithOptions(bytes.NewReader(raw.bytes()), &pak.ReadOptions{Limits: pak.Limits{MaxTotalSize: 1000}})
+	if err != nil {
+		t.Errorf("padding and data within limit: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package pak
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Paks of a locales directory, e.g. locales/ of chrome, by locale name
+type Locales map[string]*PakFile
+
+// Returns paths of locale paks in dir, sorted
+func localeFiles(dir string) ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.pak"))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("error reading locales: no paks in %s", dir)
+	}
+	return names, nil
+}
+
+// Reads every *.pak of locales directory
+func ReadLocales(dir string) (Locales, error) {
+	names, err := localeFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := make(Locales, len(names))
+	for _, name := range names {
+		p, err := ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		l[LocaleName(name)] = p
+	}
+	return l, nil
+}
+
+// Writes every locale pak to dir/<locale>.pak atomically
+func (l Locales) WriteDir(dir string) error {
+	for _, locale := range l.Names() {
+		err := WriteFileWithOptions(filepath.Join(dir, locale+".pak"), l[locale], &WriteOptions{Atomic: true})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns locale names, sorted
+func (l Locales) Names() []string {
+	names := make([]string, 0, len(l))
+	for locale := range l {
+		names = append(names, locale)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Strings of a locale compared with the base locale, ids ascending
+type LocaleDiff struct {
+	Locale  string
+	Missing []uint16 // in base but not in locale
+	Extra   []uint16 // in locale but not in base
+	Same    []uint16 // identical to base, possibly left untranslated
+}
+
+// Compares every locale other than base with it
+func (l Locales) Diff(base string) ([]LocaleDiff, error) {
+	ref, ok := l[base]
+	if !ok {
+		return nil, fmt.Errorf("error comparing locales: no %s locale", base)
+	}
+
+	var diffs []LocaleDiff
+	for _, locale := range l.Names() {
+		if locale == base {
+			continue
+		}
+		p := l[locale]
+		d := LocaleDiff{Locale: locale}
+		for resId := range ref.IDs() {
+			data, ok := p.Resourses[resId]
+			if !ok {
+				d.Missing = append(d.Missing, resId)
+			} else if bytes.Equal(data, ref.Resourses[resId]) {
+				d.Same = append(d.Same, resId)
+			}
+		}
+		for resId := range p.IDs() {
+			if !ref.Has(resId) {
+				d.Extra = append(d.Extra, resId)
+			}
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
+
+// Checks placeholders of strings of every locale other than base against
+// base, see CheckPlaceholders. Returns mismatches by locale, only locales
+// with mismatches included.
+func (l Locales) CheckPlaceholders(base string) (map[string][]PlaceholderMismatch, error) {
+	ref, ok := l[base]
+	if !ok {
+		return nil, fmt.Errorf("error checking placeholders: no %s locale", base)
+	}
+	refStrings := ExportStrings(ref)
+
+	mismatches := make(map[string][]PlaceholderMismatch)
+	for locale, p := range l {
+		if locale == base {
+			continue
+		}
+		if m := CheckPlaceholders(refStrings, ExportStrings(p)); len(m) > 0 {
+			mismatches[locale] = m
+		}
+	}
+	return mismatches, nil
+}
+
+// Checks ICU syntax of strings of every locale with the plural rules of
+// that locale, see CheckICU. Returns findings by locale, only locales with
+// findings included.
+func (l Locales) CheckICU() map[string][]Finding {
+	findings := make(map[string][]Finding)
+	for locale, p := range l {
+		if fs := CheckICU(ExportStrings(p), locale); len(fs) > 0 {
+			findings[locale] = fs
+		}
+	}
+	return findings
+}
+
+// Sets string in every locale, encoded as each pak declares
+func (l Locales) SetString(id uint16, s string) error {
+	for _, locale := range l.Names() {
+		err := ImportStrings(l[locale], Catalog{id: s})
+		if err != nil {
+			return fmt.Errorf("error setting string in %s: %v", locale, err)
+		}
+	}
+	return nil
+}
+
+// Size of a locale pak
+type LocaleSize struct {
+	Locale  string
+	Count   int   // number of resources, aliases included
+	Stored  int64 // total size of resource data, aliases counted once
+	Written int64 // size of the pak file as Write stores it
+}
+
+// Returns size of every locale pak in locale order, loading lazy resources
+func (l Locales) Sizes() ([]LocaleSize, error) {
+	var sizes []LocaleSize
+	for _, locale := range l.Names() {
+		p, err := l[locale].loaded()
+		if err != nil {
+			return nil, err
+		}
+		r, err := Report(p)
+		if err != nil {
+			return nil, err
+		}
+		wp := p.plan(nil)
+		written := int64(wp.dataEnd(p)) + int64(len(wp.trailer))
+		sizes = append(sizes, LocaleSize{Locale: locale, Count: p.Len(), Stored: r.Stored, Written: written})
+	}
+	return sizes, nil
+}
+
+// Checks structure of every *.pak of locales directory, see ValidateFile.
+// Returns findings by locale, locales without findings included.
+func ValidateLocales(dir string) (map[string][]Finding, error) {
+	names, err := localeFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	findings := make(map[string][]Finding, len(names))
+	for _, name := range names {
+		fs, err := ValidateFile(name)
+		if err != nil {
+			return nil, err
+		}
+		findings[LocaleName(name)] = fs
+	}
+	return findings, nil
+}
@@ -0,0 +1,166 @@
+package pak
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Pak files of a browser or Electron app installation
+type Installation struct {
+	Product   string            // "Chrome", "Chromium", "Edge", "Electron" or empty if not recognized
+	Dir       string            // directory holding resources.pak
+	Resources string            // resources.pak
+	Scale100  string            // chrome_100_percent.pak, empty if missing
+	Scale200  string            // chrome_200_percent.pak, empty if missing
+	Locales   map[string]string // locale paks by locale name
+}
+
+// Returned by LocateInstallation for directories without resources.pak
+var ErrNoInstallation = errors.New("no resources.pak found")
+
+// Depth of directories searched for resources.pak below an installation
+// directory, enough for macOS framework bundles
+const locateDepth = 7
+
+// Returns usual installation directories of Chromium based browsers on the
+// running platform
+func InstallDirs() []string {
+	switch runtime.GOOS {
+	case "windows":
+		var dirs []string
+		for _, root := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)"), os.Getenv("LOCALAPPDATA")} {
+			if root == "" {
+				continue
+			}
+			dirs = append(dirs,
+				filepath.Join(root, `Google\Chrome\Application`),
+				filepath.Join(root, `Chromium\Application`),
+				filepath.Join(root, `Microsoft\Edge\Application`))
+		}
+		return dirs
+	case "darwin":
+		return []string{
+			"/Applications/Google Chrome.app",
+			"/Applications/Chromium.app",
+			"/Applications/Microsoft Edge.app",
+		}
+	}
+	return []string{
+		"/opt/google/chrome",
+		"/usr/lib/chromium",
+		"/usr/lib/chromium-browser",
+		"/usr/lib64/chromium-browser",
+		"/opt/microsoft/msedge",
+	}
+}
+
+// Locates installations in the usual directories of the running platform,
+// skipping directories that do not exist
+func FindInstallations() []*Installation {
+	var found []*Installation
+	for _, dir := range InstallDirs() {
+		inst, err := LocateInstallation(dir)
+		if err == nil {
+			found = append(found, inst)
+		}
+	}
+	return found
+}
+
+// Locates paks of installation in dir: a Chrome, Chromium or Edge
+// installation directory, a macOS .app bundle or the root of an Electron
+// app. Where several versions sit side by side, as on Windows, the most
+// recently modified resources.pak wins. Locale paks are taken from a locales
+// directory next to it or, in macOS bundles, from <locale>.lproj/locale.pak.
+func LocateInstallation(dir string) (*Installation, error) {
+	var candidates []string
+	root := filepath.Clean(dir)
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() && strings.Count(name[len(root):], string(filepath.Separator)) >= locateDepth {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && d.Name() == "resources.pak" {
+			candidates = append(candidates, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoInstallation
+	}
+
+	resources := newest(candidates)
+	inst := &Installation{
+		Product:   productName(resources),
+		Dir:       filepath.Dir(resources),
+		Resources: resources,
+		Locales:   make(map[string]string),
+	}
+	inst.Scale100 = existing(filepath.Join(inst.Dir, "chrome_100_percent.pak"))
+	inst.Scale200 = existing(filepath.Join(inst.Dir, "chrome_200_percent.pak"))
+
+	names, _ := filepath.Glob(filepath.Join(inst.Dir, "locales", "*.pak"))
+	for _, name := range names {
+		inst.Locales[LocaleName(name)] = name
+	}
+	names, _ = filepath.Glob(filepath.Join(inst.Dir, "*.lproj", "locale.pak"))
+	for _, name := range names {
+		locale := strings.TrimSuffix(filepath.Base(filepath.Dir(name)), ".lproj")
+		inst.Locales[strings.ReplaceAll(locale, "_", "-")] = name
+	}
+	return inst, nil
+}
+
+// Returns most recently modified of files, the last by name on ties
+func newest(names []string) string {
+	sort.Strings(names)
+	best := names[len(names)-1]
+	var bestTime int64
+	for _, name := range names {
+		fi, err := os.Stat(name)
+		if err == nil && fi.ModTime().UnixNano() > bestTime {
+			best, bestTime = name, fi.ModTime().UnixNano()
+		}
+	}
+	return best
+}
+
+// Returns name if the file exists, empty string otherwise
+func existing(name string) string {
+	if _, err := os.Stat(name); err != nil {
+		return ""
+	}
+	return name
+}
+
+// Guesses product from path of resources.pak and files next to it
+func productName(resources string) string {
+	dir := filepath.Dir(resources)
+	lower := strings.ToLower(filepath.ToSlash(resources))
+	switch {
+	case strings.Contains(lower, "edge"):
+		return "Edge"
+	case strings.Contains(lower, "google/chrome") || strings.Contains(lower, "google chrome"):
+		return "Chrome"
+	case strings.Contains(lower, "chromium"):
+		return "Chromium"
+	case existing(filepath.Join(dir, "resources", "app.asar")) != "" ||
+		existing(filepath.Join(dir, "resources", "app")) != "" ||
+		strings.Contains(lower, "electron"):
+		return "Electron"
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package pak
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SHA-256 digests of resource data by resource id
+type Manifest map[uint16][sha256.Size]byte
+
+// Returns manifest of all resources of pak, aliases and lazy resources
+// included
+func NewManifest(p *PakFile) (Manifest, error) {
+	ids, _ := p.ListIDs()
+	m := make(Manifest, len(ids))
+	for _, resId := range ids {
+		resData, err := p.Load(resId)
+		if err != nil {
+			return nil, err
+		}
+		m[resId] = sha256.Sum256(resData)
+	}
+	return m, nil
+}
+
+// Writes manifest as text, one "id digest" line per resource in ascending id
+// order, digests in hex
+func WriteManifest(w io.Writer, m Manifest) error {
+	ids := make([]uint16, 0, len(m))
+	for resId := range m {
+		ids = append(ids, resId)
+	}
+	sortIds(ids)
+
+	bw := bufio.NewWriter(w)
+	for _, resId := range ids {
+		sum := m[resId]
+		fmt.Fprintf(bw, "%d %s\n", resId, hex.EncodeToString(sum[:]))
+	}
+	return bw.Flush()
+}
+
+// Writes manifest to file
+func WriteManifestFile(name string, m Manifest) error {
+	var buf bytes.Buffer
+	WriteManifest(&buf, m)
+	return os.WriteFile(name, buf.Bytes(), 0644)
+}
+
+// Reads manifest written by WriteManifest. Empty lines and lines starting
+// with # are ignored.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	m := make(Manifest)
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("error reading manifest line %d: expected id and digest", n)
+		}
+		resId, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("error reading manifest line %d: bad id %q", n, fields[0])
+		}
+		digest, err := hex.DecodeString(fields[1])
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("error reading manifest line %d: bad digest %q", n, fields[1])
+		}
+		if _, ok := m[uint16(resId)]; ok {
+			return nil, fmt.Errorf("error reading manifest line %d: duplicate id %d", n, resId)
+		}
+		m[uint16(resId)] = [sha256.Size]byte(digest)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Reads manifest from file
+func ReadManifestFile(name string) (Manifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadManifest(f)
+}
+
+// Differences between a pak and its manifest, ids in ascending order
+type ManifestReport struct {
+	Modified []uint16 // data does not match the digest
+	Missing  []uint16 // listed in manifest but absent from pak
+	Extra    []uint16 // present in pak but not listed in manifest
+}
+
+// Reports whether pak matches manifest exactly
+func (r *ManifestReport) OK() bool {
+	return len(r.Modified) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+// Checks every resource of pak against manifest, reporting which resources
+// were modified, removed or added. Fails if a lazy resource cannot be read.
+func VerifyManifest(p *PakFile, m Manifest) (*ManifestReport, error) {
+	r := &ManifestReport{}
+
+	ids, _ := p.ListIDs()
+	for _, resId := range ids {
+		sum, ok := m[resId]
+		if !ok {
+			r.Extra = append(r.Extra, resId)
+			continue
+		}
+		resData, err := p.Load(resId)
+		if err != nil {
+			return nil, err
+		}
+		if sha256.Sum256(resData) != sum {
+			r.Modified = append(r.Modified, resId)
+		}
+	}
+
+	for resId := range m {
+		if !p.Has(resId) {
+			r.Missing = append(r.Missing, resId)
+		}
+	}
+	sortIds(r.Missing)
+
+	return r, nil
+}
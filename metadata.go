@@ -0,0 +1,139 @@
+package pak
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Name of metadata file kept in directories written by ExtractDir. It is a
+// hidden file, so PackDir does not take it for a resource.
+const MetadataFileName = ".pakmeta.json"
+
+// Human-readable annotation of a resource
+type ResourceMeta struct {
+	Name    string   `json:"name,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+// Annotations of resources by resource id, kept in a sidecar file next to a
+// pak, see SidecarName, or in a directory of extracted resources, see
+// MetadataFileName. Stored as JSON object keyed by id:
+//
+//	{
+//	  "12345": {"name": "IDR_NEW_TAB_PAGE_HTML", "tags": ["ntp"], "comment": "..."}
+//	}
+type Metadata map[uint16]*ResourceMeta
+
+// Returns name of sidecar metadata file of pak file
+func SidecarName(pakName string) string {
+	return pakName + ".meta.json"
+}
+
+// Returns annotation of resource, creating an empty one if needed
+func (m Metadata) Get(id uint16) *ResourceMeta {
+	rm, ok := m[id]
+	if !ok {
+		rm = &ResourceMeta{}
+		m[id] = rm
+	}
+	return rm
+}
+
+// Adds tag to resource unless it has it already
+func (m Metadata) Tag(id uint16, tag string) {
+	rm := m.Get(id)
+	if !slices.Contains(rm.Tags, tag) {
+		rm.Tags = append(rm.Tags, tag)
+	}
+}
+
+// Returns ids of resources having tag in ascending order
+func (m Metadata) Tagged(tag string) []uint16 {
+	var ids []uint16
+	for resId, rm := range m {
+		if slices.Contains(rm.Tags, tag) {
+			ids = append(ids, resId)
+		}
+	}
+	sortIds(ids)
+	return ids
+}
+
+// Returns id -> name mapping of named resources, e.g. for NameFromMap
+func (m Metadata) Names() map[uint16]string {
+	names := make(map[uint16]string)
+	for resId, rm := range m {
+		if rm.Name != "" {
+			names[resId] = rm.Name
+		}
+	}
+	return names
+}
+
+// Drops annotations of ids missing from pak and empty annotations
+func (m Metadata) Prune(p *PakFile) {
+	for resId, rm := range m {
+		if !p.Has(resId) || (rm.Name == "" && len(rm.Tags) == 0 && rm.Comment == "") {
+			delete(m, resId)
+		}
+	}
+}
+
+// Reads metadata written by WriteMetadata
+func ReadMetadata(r io.Reader) (Metadata, error) {
+	m := make(Metadata)
+	err := json.NewDecoder(r).Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metadata: %v", err)
+	}
+	for resId, rm := range m {
+		if rm == nil {
+			delete(m, resId)
+		}
+	}
+	return m, nil
+}
+
+// Writes metadata as indented JSON, ids in ascending order
+func WriteMetadata(w io.Writer, m Metadata) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Reads metadata file, a missing file gives empty metadata
+func ReadMetadataFile(name string) (Metadata, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return make(Metadata), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadMetadata(f)
+}
+
+// Writes metadata file atomically
+func WriteMetadataFile(name string, m Metadata) error {
+	var buf bytes.Buffer
+	err := WriteMetadata(&buf, m)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(name, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// Reads metadata kept in directory of extracted resources
+func ReadDirMetadata(dir string) (Metadata, error) {
+	return ReadMetadataFile(filepath.Join(dir, MetadataFileName))
+}
@@ -0,0 +1,58 @@
+package pak
+
+import (
+	"sync"
+	"time"
+)
+
+// Names of metrics reported to Metrics
+const (
+	MetricFilesRead          = "pak_files_read"          // paks read by ReadFile and friends
+	MetricResourcesParsed    = "pak_resources_parsed"    // resources read, aliases excluded
+	MetricBytesDecompressed  = "pak_bytes_decompressed"  // bytes produced by Decompress
+	MetricValidationFailures = "pak_validation_failures" // validations with error findings
+	MetricReadErrors         = "pak_read_errors"         // reads that failed
+	MetricReadTime           = "pak_read_seconds"        // time taken by reads
+	MetricWriteTime          = "pak_write_seconds"       // time taken by writes
+)
+
+// Receives counters and timings of pak processing. Implementations bridge
+// them to a monitoring system such as Prometheus or expvar and must be safe
+// for concurrent use.
+type Metrics interface {
+	Add(name string, delta int64)
+	Observe(name string, d time.Duration)
+}
+
+var metrics struct {
+	sync.RWMutex
+	m Metrics
+}
+
+// Registers m to receive metrics of all pak processing in the program, nil
+// turns metrics off
+func SetMetrics(m Metrics) {
+	metrics.Lock()
+	metrics.m = m
+	metrics.Unlock()
+}
+
+func currentMetrics() Metrics {
+	metrics.RLock()
+	defer metrics.RUnlock()
+	return metrics.m
+}
+
+// Adds delta to counter if metrics are registered
+func countMetric(name string, delta int64) {
+	if m := currentMetrics(); m != nil && delta != 0 {
+		m.Add(name, delta)
+	}
+}
+
+// Records time elapsed since start if metrics are registered
+func timeMetric(name string, start time.Time) {
+	if m := currentMetrics(); m != nil {
+		m.Observe(name, time.Since(start))
+	}
+}
@@ -0,0 +1,152 @@
+package pak
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Single modification of a pak, stored in JSON as e.g.
+//
+//	{"op": "replace", "id": 12345, "text": "<html>...</html>"}
+//	{"op": "add", "symbol": "IDR_MY_ICON", "file": "icon.png"}
+//	{"op": "remove", "id": 678}
+//
+// Resource is selected by Id or, once resolved with ResolveOps, by Symbol.
+// Data of add and replace is given as Text, base64 encoded Data, or File
+// relative to the ops file, loaded by ReadOpsFile.
+type Op struct {
+	Op     string `json:"op"` // "add", "remove" or "replace"
+	Id     uint16 `json:"id,omitempty"`
+	Symbol string `json:"symbol,omitempty"`
+	Text   string `json:"text,omitempty"`
+	Data   []byte `json:"data,omitempty"`
+	File   string `json:"file,omitempty"`
+}
+
+// Returns data the op sets
+func (op *Op) data() []byte {
+	if op.Data != nil {
+		return op.Data
+	}
+	return []byte(op.Text)
+}
+
+// Reads JSON list of ops
+func ReadOps(r io.Reader) ([]Op, error) {
+	var ops []Op
+	err := json.NewDecoder(r).Decode(&ops)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ops: %v", err)
+	}
+	return ops, nil
+}
+
+// Reads JSON list of ops from file and loads data of ops referring to files,
+// relative paths are resolved against the directory of the ops file.
+func ReadOpsFile(name string) ([]Op, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ops, err := ReadOps(f)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ops {
+		if ops[i].File == "" {
+			continue
+		}
+		path := ops[i].File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(name), path)
+		}
+		ops[i].Data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ops, nil
+}
+
+// Writes JSON list of ops
+func WriteOps(w io.Writer, ops []Op) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ops)
+}
+
+// Sets ids of ops selecting resources by symbol using symbol -> id mapping
+func ResolveOps(ops []Op, symbols map[string]uint16) error {
+	for i := range ops {
+		if ops[i].Symbol == "" {
+			continue
+		}
+		id, ok := symbols[ops[i].Symbol]
+		if !ok {
+			return fmt.Errorf("error resolving op %d: unknown symbol %s", i, ops[i].Symbol)
+		}
+		ops[i].Id = id
+	}
+	return nil
+}
+
+// Applies ops to pak struct in order. All ops are checked before any is
+// applied, so on error p is left unchanged: add fails for existing ids,
+// remove and replace fail for missing ones, add and replace fail for id 0.
+func ApplyOps(p *PakFile, ops []Op) error {
+	ids, _ := p.ListIDs()
+	exists := make(map[uint16]bool, len(ids))
+	for _, resId := range ids {
+		exists[resId] = true
+	}
+
+	for i, op := range ops {
+		if op.Symbol != "" && op.Id == 0 {
+			return fmt.Errorf("error applying op %d: symbol %s is not resolved", i, op.Symbol)
+		}
+		if op.File != "" && op.Data == nil {
+			return fmt.Errorf("error applying op %d: file %s is not loaded", i, op.File)
+		}
+
+		if op.Id == 0 && (op.Op == "add" || op.Op == "replace") {
+			return fmt.Errorf("error applying op %d: %w", i, ErrReservedID)
+		}
+
+		switch op.Op {
+		case "add":
+			if exists[op.Id] {
+				return fmt.Errorf("error applying op %d: resource id=%d already exists", i, op.Id)
+			}
+			exists[op.Id] = true
+		case "remove", "replace":
+			if !exists[op.Id] {
+				return fmt.Errorf("error applying op %d: resource id=%d does not exist", i, op.Id)
+			}
+			exists[op.Id] = op.Op == "replace"
+		default:
+			return fmt.Errorf("error applying op %d: unknown op %q", i, op.Op)
+		}
+	}
+
+	for _, op := range ops {
+		delete(p.Aliases, op.Id)
+		delete(p.Lazy, op.Id)
+		if op.Op == "remove" {
+			delete(p.Resourses, op.Id)
+		} else {
+			if p.Resourses == nil {
+				p.Resourses = make(map[uint16][]byte)
+			}
+			p.Resourses[op.Id] = op.data()
+		}
+	}
+
+	return nil
+}
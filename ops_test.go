@@ -0,0 +1,27 @@
+package pak_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+func TestApplyOpsReservedID(t *testing.T) {
+	for _, op := range []string{"add", "replace"} {
+		p := paktest.Sample(5, pak.EncodingUTF8)
+		if op == "replace" {
+			p.Resourses[0] = []byte("read from a pak with id 0")
+		}
+
+		ops := []pak.Op{{Op: "replace", Id: paktest.IDHTML, Text: "new"}, {Op: op, Id: 0, Text: "zero"}}
+		err := pak.ApplyOps(p, ops)
+		if !errors.Is(err, pak.ErrReservedID) {
+			t.Errorf("%s of id 0: error %v, want %v", op, err, pak.ErrReservedID)
+		}
+		if string(p.Resourses[paktest.IDHTML]) == "new" {
+			t.Errorf("%s of id 0 applied other ops", op)
+		}
+	}
+}
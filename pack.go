@@ -0,0 +1,210 @@
+package pak
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Options controlling how a directory is packed
+type PackOptions struct {
+	Version  Version // pak format version, zero means 5
+	Encoding Encoding
+
+	// OnInput, if set, is called with the path of every file and directory
+	// read, e.g. to collect inputs for WriteDepfile
+	OnInput func(name string)
+}
+
+// Reports path of input read while packing
+func (opts *PackOptions) input(name string) {
+	if opts.OnInput != nil {
+		opts.OnInput(name)
+	}
+}
+
+// Returns version to pack, checking it
+func (opts *PackOptions) version() (Version, error) {
+	version := opts.Version
+	if version == 0 {
+		version = 5
+	}
+	return version, version.Validate()
+}
+
+// Reads resource id from file name: the part before the first dot, so files
+// written by ExtractDir with or without extensions are accepted
+func idFromFileName(name string) (uint16, error) {
+	base, _, _ := strings.Cut(name, ".")
+	resId, err := strconv.ParseUint(base, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("file name %s is not a resource id", name)
+	}
+	return uint16(resId), nil
+}
+
+// Builds pak struct from files in dir named by resource id, the inverse of
+// ExtractDir with default naming. Hidden files and subdirectories are
+// skipped. Identical files are stored once for version 5, see Canonicalize.
+// Nil options mean version 5 with UTF-8 encoding. The directory itself is
+// reported as an input too, so adding or removing files triggers a rebuild.
+func PackDir(dir string, opts *PackOptions) (*PakFile, error) {
+	if opts == nil {
+		opts = &PackOptions{Encoding: EncodingUTF8}
+	}
+	version, err := opts.version()
+	if err != nil {
+		return nil, fmt.Errorf("error packing %s: %v", dir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	opts.input(dir)
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		names = append(names, filepath.Join(dir, e.Name()))
+	}
+	return packFiles(names, version, opts)
+}
+
+// Builds pak struct from files named by resource id like PackDir, e.g. from a
+// file list written by a build system. Nil options mean version 5 with UTF-8
+// encoding.
+func PackFiles(names []string, opts *PackOptions) (*PakFile, error) {
+	if opts == nil {
+		opts = &PackOptions{Encoding: EncodingUTF8}
+	}
+	version, err := opts.version()
+	if err != nil {
+		return nil, fmt.Errorf("error packing files: %v", err)
+	}
+	return packFiles(names, version, opts)
+}
+
+func packFiles(names []string, version Version, opts *PackOptions) (*PakFile, error) {
+	p := &PakFile{Version: version, Encoding: opts.Encoding, Resourses: make(map[uint16][]byte)}
+	read := make(map[uint16]string)
+
+	for _, name := range names {
+		resId, err := idFromFileName(filepath.Base(name))
+		if err != nil {
+			return nil, err
+		}
+		if resId == 0 {
+			return nil, fmt.Errorf("error packing %s: %w", name, ErrReservedID)
+		}
+		if other, ok := read[resId]; ok {
+			return nil, fmt.Errorf("error packing %s: resource id=%d already read from %s", filepath.Base(name), resId, filepath.Base(other))
+		}
+		read[resId] = name
+
+		p.Resourses[resId], err = os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		opts.input(name)
+	}
+
+	err := Canonicalize(p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Builds pak struct from files referenced by <include> and <structure>
+// elements of .grd, resolving names to ids with symbol table of the build and
+// file paths relative to dir. Resources are compressed as their compress
+// attribute asks, brotli needs a codec, see RegisterBrotli. Messages are not
+// packed, they come from translations. Entries inside <if> are skipped when
+// their symbol or file is missing.
+func PackGrd(g *Grd, symbols SymbolTable, dir string, opts *PackOptions) (*PakFile, error) {
+	if opts == nil {
+		opts = &PackOptions{Encoding: EncodingUTF8}
+	}
+	version, err := opts.version()
+	if err != nil {
+		return nil, fmt.Errorf("error packing grd: %v", err)
+	}
+
+	p := &PakFile{Version: version, Encoding: opts.Encoding, Resourses: make(map[uint16][]byte)}
+
+	for _, e := range g.Entries {
+		if e.File == "" {
+			continue
+		}
+
+		resId, ok := symbols[e.Name]
+		if !ok {
+			if e.Conditional {
+				continue
+			}
+			return nil, fmt.Errorf("error packing %s: not in symbol table", e.Name)
+		}
+		if strings.Contains(e.File, "${") {
+			return nil, fmt.Errorf("error packing %s: variables in file path %s are not supported", e.Name, e.File)
+		}
+
+		name := filepath.Join(dir, filepath.FromSlash(e.File))
+		data, err := os.ReadFile(name)
+		if os.IsNotExist(err) && e.Conditional {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		opts.input(name)
+
+		switch e.Compress {
+		case "gzip":
+			data, err = Compress(data, CompressionGzip)
+		case "brotli":
+			data, err = Compress(data, CompressionBrotli)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error packing %s: %v", e.Name, err)
+		}
+
+		if _, ok := p.Resourses[resId]; ok {
+			return nil, fmt.Errorf("error packing %s: resource id=%d already packed", e.Name, resId)
+		}
+		p.Resourses[resId] = data
+	}
+
+	err = Canonicalize(p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Writes Make/Ninja depfile stating that target depends on inputs. Spaces,
+// '#' and '$' in paths are escaped the way both tools read them.
+func WriteDepfile(w io.Writer, target string, inputs []string) error {
+	var buf bytes.Buffer
+	buf.WriteString(escapeDepPath(target))
+	buf.WriteString(":")
+	for _, name := range inputs {
+		buf.WriteString(" \\\n  ")
+		buf.WriteString(escapeDepPath(name))
+	}
+	buf.WriteString("\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+var depPathReplacer = strings.NewReplacer(" ", "\\ ", "#", "\\#", "$", "$$")
+
+func escapeDepPath(name string) string {
+	return depPathReplacer.Replace(filepath.ToSlash(name))
+}
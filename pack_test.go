@@ -0,0 +1,28 @@
+package pak_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/pak"
+)
+
+func TestPackReservedID(t *testing.T) {
+	for _, file := range []string{"0", "0.png", "00.txt"} {
+		dir := t.TempDir()
+		for _, name := range []string{"1.txt", file} {
+			err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := pak.PackDir(dir, nil)
+		if !errors.Is(err, pak.ErrReservedID) || !strings.Contains(err.Error(), filepath.Join(dir, file)) {
+			t.Errorf("PackDir with %s: error %v, want %v naming the file", file, err, pak.ErrReservedID)
+		}
+	}
+}
@@ -2,151 +2,818 @@
 package pak
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"os"
 	"sort"
+	"time"
 )
 
 type PakFile struct {
-	Version   uint32
-	Encoding  uint8
+	Version   Version
+	Encoding  Encoding
 	Resourses map[uint16][]byte // maps resource id -> resource data
+	Aliases   map[uint16]uint16 // maps alias id -> id of resource it shares data with (version 5)
+	Layout    *Layout           // physical layout recorded by Read, nil for paks built in memory
+
+	// Lazy holds resources left unread by ReadAt, keyed by id like Resourses.
+	// Write and Load read them from their source.
+	Lazy map[uint16]*LazyResource
+
+	// Symbols, if set, names resources for Lookup, GetByName and Name, e.g.
+	// read by ReadSymbolsFile from the header generated along with the pak
+	Symbols SymbolTable
+
+	arena []byte // resource data buffer reused by ReadInto
 }
 
-const (
-	EncodingBinary = iota
-	EncodingUTF8
-	EncodingUTF16
-)
+// Returned by Write when resource data would end past 4 GiB, the largest
+// offset the index can hold, see SplitBySize
+var ErrTooLarge = errors.New("pak: resource data exceeds 4 GiB offset limit")
+
+// Returned by Write when Order, Less or Hot of WriteOptions would write the
+// index out of ascending id order without Unsorted, see WriteOptions
+var ErrUnsortedIndex = errors.New("pak: index would not be in ascending id order")
+
+// Physical layout of a pak file as it was read.
+// Write reproduces it byte-for-byte as long as the set of resource and alias
+// ids is unchanged, otherwise the layout is ignored and resources are written
+// in ascending id order. Changed resource data keeps the recorded order.
+type Layout struct {
+	Order         []uint16 // ids of stored resources in index order
+	AliasOrder    []uint16 // alias ids in alias table order (version 5)
+	HeaderPadding [3]byte  // reserved header bytes (version 5)
+	Padding       []byte   // unreferenced bytes between index and first resource
+	Trailer       []byte   // unreferenced bytes after last resource
+}
+
+type header struct {
+	version   uint32
+	encoding  uint8
+	resources uint32 // number of index entries, not counting the terminator
+	aliases   uint32 // number of alias entries (version 5)
+	padding   [3]byte
+}
+
+// Returns header length in bytes
+func (h *header) length() uint64 {
+	if h.version == 5 {
+		return 4 + 1 + 3 + 2 + 2
+	}
+	return 4 + 4 + 1
+}
+
+// Returns offset of the first byte after index and alias table
+func (h *header) indexEnd() uint64 {
+	return h.length() + (2+4)*(uint64(h.resources)+1) + (2+2)*uint64(h.aliases)
+}
 
 type resourceInfo struct {
 	id     uint16
 	offset uint32
 }
 
+type aliasInfo struct {
+	id    uint16
+	index uint16 // index of the aliased entry in resource index
+}
+
+// Called after each resource of a long operation, done and total are bytes
+// of resource data processed so far and overall
+type ProgressFunc func(id uint16, done, total int64)
+
+// Options controlling how a pak is read
+type ReadOptions struct {
+	Limits   Limits       // checked while parsing, zero fields mean no limit
+	Progress ProgressFunc // called after each resource is read
+	Logger   *slog.Logger // traces parse decisions at debug level
+
+	// Key, if set, decrypts resources encrypted by Encrypter as they are read
+	Key []byte
+
+	// Decompress, if set, replaces compressed resource data with decompressed
+	// data as the pak is read, using Workers goroutines, zero meaning one per
+	// CPU. Written back, such a pak stores resources uncompressed. Limits
+	// apply to decompressed data, sizes claimed by compression headers are
+	// not trusted.
+	Decompress bool
+	Workers    int
+
+	// LazyThreshold, if positive, makes ReadAt keep resources longer than it
+	// as lazy placeholders instead of reading them. Ignored by Read.
+	LazyThreshold int64
+}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// Returns logger of options, discarding output when none is set
+func (opts *ReadOptions) logger() *slog.Logger {
+	if opts == nil || opts.Logger == nil {
+		return discardLogger
+	}
+	return opts.Logger
+}
+
 // Reads pak struct from io.Reader
 func Read(r io.Reader) (*PakFile, error) {
-	var err error
+	return ReadWithOptions(r, nil)
+}
+
+// Reads pak struct from io.Reader using the given options (nil means defaults).
+// The reader is consumed to EOF, bytes following the last resource are kept in
+// Layout.Trailer.
+func ReadWithOptions(r io.Reader, opts *ReadOptions) (*PakFile, error) {
+	return readPak(context.Background(), r, opts)
+}
 
-	// Read header:
-	// 4 byte version number
-	// 4 byte number of resources
-	// 1 byte encoding
+// Reads pak struct from io.Reader, giving up with ctx.Err() once ctx is done.
+// Cancellation is checked between resources.
+func ReadContext(ctx context.Context, r io.Reader, opts *ReadOptions) (*PakFile, error) {
+	return readPak(ctx, r, opts)
+}
+
+func readPak(ctx context.Context, r io.Reader, opts *ReadOptions) (*PakFile, error) {
+	return readPakInto(ctx, r, opts, nil, true)
+}
 
-	const headerLength = 4 + 4 + 1
-	var version uint32
-	var numberOfResources uint32
-	var encoding uint8
+// Reads pak into into, or a new pak for nil into. Bytes following the last
+// resource are read to EOF as trailer if trailer is set and left unread
+// otherwise.
+func readPakInto(ctx context.Context, r io.Reader, opts *ReadOptions, into *PakFile, trailer bool) (*PakFile, error) {
+	defer timeMetric(MetricReadTime, time.Now())
 
-	err = binary.Read(r, binary.LittleEndian, &version)
+	log := opts.logger()
+	pak, err := readResources(ctx, r, opts, log, into, trailer)
+	if err == nil && opts != nil && opts.Decompress {
+		err = decompressAll(ctx, pak, opts.Workers, opts.Limits)
+	}
 	if err != nil {
+		log.Debug("pak read failed", "err", err)
+		countMetric(MetricReadErrors, 1)
 		return nil, err
 	}
+	countMetric(MetricResourcesParsed, int64(len(pak.Layout.Order)))
+	return pak, nil
+}
+
+func readResources(ctx context.Context, r io.Reader, opts *ReadOptions, log *slog.Logger, into *PakFile, trailer bool) (*PakFile, error) {
+	var err error
+
+	if opts == nil {
+		opts = &ReadOptions{}
+	}
+	limits := opts.Limits
 
-	err = binary.Read(r, binary.LittleEndian, &numberOfResources)
+	h, err := readHeader(r)
 	if err != nil {
 		return nil, err
 	}
+	log.Debug("pak header", "version", h.version, "encoding", h.encoding, "resources", h.resources, "aliases", h.aliases)
+	if h.version != 4 && h.version != 5 {
+		return nil, fmt.Errorf("error reading pak: unsupported version %d", h.version)
+	}
+	err = Encoding(h.encoding).Validate()
+	if err != nil {
+		return nil, fmt.Errorf("error reading pak: %v", err)
+	}
 
-	err = binary.Read(r, binary.LittleEndian, &encoding)
+	err = limits.checkCount(h.resources + h.aliases)
 	if err != nil {
 		return nil, err
 	}
 
-	pak := &PakFile{
-		Version:   version,
-		Encoding:  encoding,
-		Resourses: make(map[uint16][]byte),
+	pak := into.reset()
+	pak.Version, pak.Encoding = Version(h.version), Encoding(h.encoding)
+	pak.Layout.HeaderPadding = h.padding
+
+	scratch := indexPool.Get().(*indexScratch)
+	defer indexPool.Put(scratch)
+
+	resInfos, aliasInfos, err := readIndexInto(r, h, scratch)
+	if err != nil {
+		return nil, err
 	}
 
-	// For each resource read info:
-	// 2 byte resource id
-	// 4 byte resource offset in file
-	// Extra resource entry at the end with ID 0 giving the end of the last resource
+	numberOfResources := h.resources
 
-	resInfos := make([]resourceInfo, numberOfResources+1, numberOfResources+1)
+	if resInfos[numberOfResources].id != 0 {
+		return nil, fmt.Errorf("error reading resources: last id != 0")
+	}
 
 	var i uint32
 
-	for i = 0; i < numberOfResources+1; i++ {
-		ri := resourceInfo{}
-
-		err = binary.Read(r, binary.LittleEndian, &ri.id)
-		if err != nil {
-			return nil, err
+	for i = 0; i < numberOfResources; i++ {
+		if resInfos[i+1].offset < resInfos[i].offset {
+			return nil, fmt.Errorf("error reading resource id=%d: offsets are not ascending", resInfos[i].id)
 		}
+	}
 
-		err = binary.Read(r, binary.LittleEndian, &ri.offset)
-		if err != nil {
-			return nil, err
-		}
+	dataStart := uint64(resInfos[0].offset)
+	if dataStart < h.indexEnd() {
+		return nil, fmt.Errorf("error reading resources: data offset %d overlaps index", dataStart)
+	}
 
-		resInfos[i] = ri
+	// Padding between index and data counts towards the total
+	err = limits.checkTotalSize(uint64(resInfos[numberOfResources].offset) - h.indexEnd())
+	if err != nil {
+		return nil, err
 	}
 
-	if resInfos[numberOfResources].id != 0 {
-		return nil, fmt.Errorf("error reading resources: last id != 0")
+	// Unreferenced bytes between index and data
+	pak.Layout.Padding, err = readBytes(r, dataStart-h.indexEnd())
+	if err != nil {
+		return nil, err
+	}
+	if len(pak.Layout.Padding) > 0 {
+		log.Debug("pak padding after index", "bytes", len(pak.Layout.Padding))
 	}
 
 	// Read resources
+	total := int64(resInfos[numberOfResources].offset) - int64(dataStart)
+
+	// Reused paks keep all resource data in one buffer, checked against
+	// limits before growing
+	var arena []byte
+	if into != nil {
+		for i = 0; i < numberOfResources; i++ {
+			err = limits.checkResourceSize(resInfos[i].id, resInfos[i+1].offset-resInfos[i].offset)
+			if err != nil {
+				return nil, err
+			}
+		}
+		arena, err = readArena(r, pak.arena, total)
+		if err != nil {
+			return nil, err
+		}
+		pak.arena = arena
+	}
+
 	for i = 0; i < numberOfResources; i++ {
+		err = ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
 		resId := resInfos[i].id
 		resLength := resInfos[i+1].offset - resInfos[i].offset
-		resData := make([]byte, resLength, resLength)
 
-		n, err := r.Read(resData)
+		err = limits.checkResourceSize(resId, resLength)
 		if err != nil {
 			return nil, err
 		}
-		if uint32(n) != resLength {
-			return nil, fmt.Errorf("error reading resource id=%d", resId)
+
+		var resData []byte
+		if arena != nil {
+			start := resInfos[i].offset - uint32(dataStart)
+			resData = arena[start : start+resLength : start+resLength]
+		} else {
+			resData = make([]byte, resLength, resLength)
+
+			n, err := io.ReadFull(r, resData)
+			if err != nil {
+				return nil, err
+			}
+			if uint32(n) != resLength {
+				return nil, fmt.Errorf("error reading resource id=%d", resId)
+			}
+		}
+
+		if opts.Key != nil {
+			resData, err = DecryptResource(resId, resData, opts.Key)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		pak.Resourses[resId] = resData
+		pak.Layout.Order = append(pak.Layout.Order, resId)
+
+		if opts.Progress != nil {
+			opts.Progress(resId, int64(resInfos[i+1].offset)-int64(dataStart), total)
+		}
+	}
+
+	// Resolve aliases to the data of entries they point to
+	for _, ai := range aliasInfos {
+		if uint32(ai.index) >= numberOfResources {
+			return nil, fmt.Errorf("error reading alias id=%d: entry index %d out of range", ai.id, ai.index)
+		}
+		if _, ok := pak.Resourses[ai.id]; ok {
+			return nil, fmt.Errorf("error reading alias id=%d: duplicate id", ai.id)
+		}
+		if pak.Aliases == nil {
+			pak.Aliases = make(map[uint16]uint16)
+		}
+		target := resInfos[ai.index].id
+		pak.Resourses[ai.id] = pak.Resourses[target]
+		pak.Aliases[ai.id] = target
+		pak.Layout.AliasOrder = append(pak.Layout.AliasOrder, ai.id)
+	}
+
+	if !trailer {
+		return pak, nil
+	}
+
+	// Trailing data, limited by the same budget as resources
+	tr := io.Reader(r)
+	if limits.MaxTotalSize != 0 {
+		tr = io.LimitReader(r, int64(limits.MaxTotalSize-(uint64(resInfos[numberOfResources].offset)-h.indexEnd()))+1)
+	}
+	pak.Layout.Trailer, err = io.ReadAll(tr)
+	if err != nil {
+		return nil, err
+	}
+	err = limits.checkTotalSize(uint64(resInfos[numberOfResources].offset) - h.indexEnd() + uint64(len(pak.Layout.Trailer)))
+	if err != nil {
+		return nil, err
+	}
+	if len(pak.Layout.Trailer) == 0 {
+		pak.Layout.Trailer = nil
+	} else {
+		log.Debug("pak trailing data", "bytes", len(pak.Layout.Trailer))
 	}
 
 	return pak, nil
 }
 
+// Reads pak header.
+// Version 4:
+// 4 byte version number
+// 4 byte number of resources
+// 1 byte encoding
+// Version 5:
+// 4 byte version number
+// 1 byte encoding
+// 3 bytes padding
+// 2 byte number of resources
+// 2 byte number of aliases
+func readHeader(r io.Reader) (h header, err error) {
+	err = binary.Read(r, binary.LittleEndian, &h.version)
+	if err != nil {
+		return
+	}
+
+	if h.version != 5 {
+		err = binary.Read(r, binary.LittleEndian, &h.resources)
+		if err != nil {
+			return
+		}
+
+		err = binary.Read(r, binary.LittleEndian, &h.encoding)
+		return
+	}
+
+	err = binary.Read(r, binary.LittleEndian, &h.encoding)
+	if err != nil {
+		return
+	}
+
+	_, err = io.ReadFull(r, h.padding[:])
+	if err != nil {
+		return
+	}
+
+	var resources, aliases uint16
+
+	err = binary.Read(r, binary.LittleEndian, &resources)
+	if err != nil {
+		return
+	}
+
+	err = binary.Read(r, binary.LittleEndian, &aliases)
+	h.resources, h.aliases = uint32(resources), uint32(aliases)
+	return
+}
+
+// Writes pak header, see readHeader
+func writeHeader(w io.Writer, h header) error {
+	var err error
+
+	err = binary.Write(w, binary.LittleEndian, h.version)
+	if err != nil {
+		return err
+	}
+
+	if h.version != 5 {
+		err = binary.Write(w, binary.LittleEndian, h.resources)
+		if err != nil {
+			return err
+		}
+
+		return binary.Write(w, binary.LittleEndian, h.encoding)
+	}
+
+	err = binary.Write(w, binary.LittleEndian, h.encoding)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(h.padding[:])
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.LittleEndian, uint16(h.resources))
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.LittleEndian, uint16(h.aliases))
+}
+
+// Reads resource index including the terminator entry and alias table.
+// For each resource:
+// 2 byte resource id
+// 4 byte resource offset in file
+// Extra resource entry at the end with ID 0 giving the end of the last resource
+// For each alias (version 5):
+// 2 byte resource id
+// 2 byte index of the aliased entry
+func readIndex(r io.Reader, h header) ([]resourceInfo, []aliasInfo, error) {
+	return readIndexInto(r, h, &indexScratch{})
+}
+
+// Reads single alias table entry:
+// 2 byte resource id
+// 2 byte index of the aliased entry
+func readAliasInfo(r io.Reader) (aliasInfo, error) {
+	ai := aliasInfo{}
+
+	err := binary.Read(r, binary.LittleEndian, &ai.id)
+	if err != nil {
+		return ai, err
+	}
+
+	err = binary.Read(r, binary.LittleEndian, &ai.index)
+	return ai, err
+}
+
+// Reads single resource index entry:
+// 2 byte resource id
+// 4 byte resource offset in file
+func readResourceInfo(r io.Reader) (resourceInfo, error) {
+	ri := resourceInfo{}
+
+	err := binary.Read(r, binary.LittleEndian, &ri.id)
+	if err != nil {
+		return ri, err
+	}
+
+	err = binary.Read(r, binary.LittleEndian, &ri.offset)
+	return ri, err
+}
+
+// Reads exactly n bytes, returns nil for n == 0
+func readBytes(r io.Reader, n uint64) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	_, err := io.CopyN(&buf, r, int64(n))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), err
+}
+
 // Reads pak struct from file
 func ReadFile(name string) (*PakFile, error) {
+	return ReadFileWithOptions(name, nil)
+}
+
+// Reads pak struct from file using the given options (nil means defaults)
+func ReadFileWithOptions(name string, opts *ReadOptions) (*PakFile, error) {
 	f, err := os.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return Read(f)
+	countMetric(MetricFilesRead, 1)
+	return ReadWithOptions(f, opts)
+}
+
+// Options controlling how a pak is written
+type WriteOptions struct {
+	// Ids listed in Order are written first, in the given order, the remaining
+	// ids follow in ascending order. Less, if set, is used instead of Order to
+	// sort all stored resources. Either one overrides Layout recorded by Read.
+	// Data order always follows index order, and chromium looks resources up
+	// by binary search, so it only reads paks written in ascending id order.
+	// Write fails with ErrUnsortedIndex when they break it, unless Unsorted.
+	Order []uint16
+	Less  func(a, b uint16) bool
+
+	// Hot resources are moved to the start of the data section, keeping their
+	// relative order, so embedders reading them at startup touch fewer pages.
+	// The format has no resource lengths, data is located by the offset of the
+	// next index entry, so moving data moves index entries with it. Like
+	// Order, Hot fails with ErrUnsortedIndex unless Unsorted is set.
+	Hot []uint16
+
+	// Unsorted allows Order, Less and Hot to write the index out of ascending
+	// id order. Such paks are read by this package but not by chromium.
+	Unsorted bool
+
+	// Align, if greater than 1, pads the start offset of every resource to a
+	// multiple of it with zero bytes. The format has no resource lengths, so
+	// readers (chromium included) see the padding as trailing bytes of the
+	// preceding resource. Padding replaces that recorded in Layout.
+	Align uint32
+
+	// Reserve leaves room for that many additional index entries between the
+	// index and resource data, so resources can later be added by Append
+	// without moving existing data.
+	Reserve uint32
+
+	// Progress is called after each resource is written
+	Progress ProgressFunc
+
+	// Transforms are applied in order to the data of every resource as it is
+	// written, the pak struct itself is left unchanged.
+	Transforms []Transformer
+
+	// Atomic makes file writes go to a temporary file in the same directory,
+	// which is synced and renamed over the target, so a crash never leaves a
+	// truncated pak behind. Patching with Atomic set never modifies in place.
+	Atomic bool
+
+	// Backup keeps the previous file contents as name+".bak" when a file is
+	// written or patched.
+	Backup bool
+}
+
+// Physical layout Write is going to produce
+type writePlan struct {
+	header  header
+	order   []uint16 // ids of stored resources in index order
+	aliases []aliasInfo
+	padding []byte
+	gaps    []uint32 // zero bytes written after each resource in order
+	trailer []byte
+}
+
+// Splits resources into stored entries and aliases and decides their order.
+// An alias is kept only for version 5 when its target is a stored resource
+// with identical data, otherwise the alias id is stored as a regular resource.
+func (p *PakFile) plan(opts *WriteOptions) *writePlan {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	wp := &writePlan{header: header{version: uint32(p.Version), encoding: uint8(p.Encoding)}}
+
+	aliases := make(map[uint16]uint16)
+	if p.Version == 5 {
+		for aliasId, target := range p.Aliases {
+			data, ok := p.Resourses[aliasId]
+			if !ok || aliasId == target {
+				continue
+			}
+			targetData, ok := p.Resourses[target]
+			if !ok || !bytes.Equal(data, targetData) {
+				continue
+			}
+			if _, ok := p.Aliases[target]; ok {
+				continue
+			}
+			aliases[aliasId] = target
+		}
+	}
+
+	stored := make([]uint16, 0, len(p.Resourses)-len(aliases))
+	for resId := range p.Resourses {
+		if _, ok := aliases[resId]; !ok {
+			stored = append(stored, resId)
+		}
+	}
+	aliasIds := make([]uint16, 0, len(aliases))
+	for aliasId := range aliases {
+		aliasIds = append(aliasIds, aliasId)
+	}
+
+	custom := opts.Less != nil || len(opts.Order) > 0
+
+	if l := p.Layout; !custom && l != nil && sameIds(l.Order, stored) && sameIds(l.AliasOrder, aliasIds) {
+		wp.order = l.Order
+		aliasIds = l.AliasOrder
+		wp.header.padding = l.HeaderPadding
+		wp.padding = l.Padding
+		wp.trailer = l.Trailer
+	} else {
+		sortIds(stored)
+		sortIds(aliasIds)
+		wp.order = orderIds(stored, opts)
+	}
+
+	if len(opts.Hot) > 0 {
+		wp.order = hotFirst(wp.order, opts.Hot)
+	}
+
+	index := make(map[uint16]uint16, len(wp.order))
+	for i, resId := range wp.order {
+		index[resId] = uint16(i)
+	}
+	for _, aliasId := range aliasIds {
+		wp.aliases = append(wp.aliases, aliasInfo{id: aliasId, index: index[aliases[aliasId]]})
+	}
+
+	wp.header.resources = uint32(len(wp.order))
+	wp.header.aliases = uint32(len(wp.aliases))
+
+	if opts.Reserve > 0 || opts.Align > 1 {
+		wp.padding = make([]byte, (2+4)*uint64(opts.Reserve))
+	}
+	if opts.Align > 1 {
+		wp.align(p, uint64(opts.Align))
+	}
+
+	return wp
+}
+
+// Pads start offset of every resource to a multiple of align
+func (wp *writePlan) align(p *PakFile, align uint64) {
+	alignUp := func(off uint64) uint64 {
+		return (off + align - 1) / align * align
+	}
+
+	off := wp.header.indexEnd() + uint64(len(wp.padding))
+	wp.padding = append(wp.padding, make([]byte, alignUp(off)-off)...)
+	off = alignUp(off)
+
+	wp.gaps = make([]uint32, len(wp.order))
+	for i, resId := range wp.order {
+		off += uint64(len(p.Resourses[resId]))
+		if i+1 < len(wp.order) {
+			wp.gaps[i] = uint32(alignUp(off) - off)
+			off += uint64(wp.gaps[i])
+		}
+	}
+}
+
+// Returns offset of the end of resource data in the written file
+func (wp *writePlan) dataEnd(p *PakFile) uint64 {
+	end := wp.header.indexEnd() + uint64(len(wp.padding))
+	for i, resId := range wp.order {
+		end += uint64(len(p.Resourses[resId])) + uint64(wp.gap(i))
+	}
+	return end
+}
+
+// Returns zero bytes written after i-th resource
+func (wp *writePlan) gap(i int) uint32 {
+	if wp.gaps == nil {
+		return 0
+	}
+	return wp.gaps[i]
+}
+
+// Reports whether a and b contain the same ids, a is expected to have no duplicates
+func sameIds(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[uint16]bool, len(b))
+	for _, id := range b {
+		set[id] = true
+	}
+	for _, id := range a {
+		if !set[id] {
+			return false
+		}
+		delete(set, id)
+	}
+	return len(set) == 0
+}
+
+func sortIds(ids []uint16) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
+
+// Moves hot ids to the front, otherwise keeping order
+func hotFirst(ids []uint16, hot []uint16) []uint16 {
+	isHot := make(map[uint16]bool, len(hot))
+	for _, id := range hot {
+		isHot[id] = true
+	}
+
+	ordered := make([]uint16, 0, len(ids))
+	for _, id := range ids {
+		if isHot[id] {
+			ordered = append(ordered, id)
+		}
+	}
+	for _, id := range ids {
+		if !isHot[id] {
+			ordered = append(ordered, id)
+		}
+	}
+	return ordered
 }
 
-// Writes pak struct to io.Writer
+// Reports whether ids are in ascending order
+func ascending(ids []uint16) bool {
+	for i := 1; i < len(ids); i++ {
+		if ids[i-1] > ids[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Reorders ascending ids according to write options
+func orderIds(ids []uint16, opts *WriteOptions) []uint16 {
+	if opts.Less != nil {
+		sort.SliceStable(ids, func(i, j int) bool { return opts.Less(ids[i], ids[j]) })
+		return ids
+	}
+	if len(opts.Order) == 0 {
+		return ids
+	}
+
+	present := make(map[uint16]bool, len(ids))
+	for _, id := range ids {
+		present[id] = true
+	}
+
+	ordered := make([]uint16, 0, len(ids))
+	for _, id := range opts.Order {
+		if present[id] {
+			ordered = append(ordered, id)
+			delete(present, id) // skip duplicates in Order
+		}
+	}
+	for _, id := range ids {
+		if present[id] {
+			ordered = append(ordered, id)
+		}
+	}
+	return ordered
+}
+
+// Writes pak struct to io.Writer.
+// Layout recorded by Read is reproduced when it still matches the resources.
 func Write(w io.Writer, p *PakFile) error {
+	return WriteWithOptions(w, p, nil)
+}
+
+// Writes pak struct to io.Writer using the given options (nil means defaults)
+func WriteWithOptions(w io.Writer, p *PakFile, opts *WriteOptions) error {
+	return writePak(context.Background(), w, p, opts)
+}
+
+// Writes pak struct to io.Writer, giving up with ctx.Err() once ctx is done.
+// Cancellation is checked between resources, so w may be left with a
+// partially written pak.
+func WriteContext(ctx context.Context, w io.Writer, p *PakFile, opts *WriteOptions) error {
+	return writePak(ctx, w, p, opts)
+}
+
+func writePak(ctx context.Context, w io.Writer, p *PakFile, opts *WriteOptions) error {
 	var err error
 
+	defer timeMetric(MetricWriteTime, time.Now())
+
 	if p == nil {
 		return fmt.Errorf("error writing pak: p == nil")
 	}
-
-	// Write header:
-	// 4 byte version number
-	// 4 byte number of resources
-	// 1 byte encoding
-
-	const headerLength = 4 + 4 + 1
-	numberOfResources := uint32(len(p.Resourses))
-
-	err = binary.Write(w, binary.LittleEndian, p.Version)
+	err = p.Version.Validate()
+	if err == nil {
+		err = p.Encoding.Validate()
+	}
 	if err != nil {
-		return err
+		return fmt.Errorf("error writing pak: %v", err)
 	}
 
-	err = binary.Write(w, binary.LittleEndian, numberOfResources)
+	p, err = p.loaded()
 	if err != nil {
 		return err
 	}
 
-	err = binary.Write(w, binary.LittleEndian, p.Encoding)
+	if opts != nil && len(opts.Transforms) > 0 {
+		p, err = p.transformed(opts.Transforms)
+		if err != nil {
+			return err
+		}
+	}
+
+	wp := p.plan(opts)
+	if opts != nil && !opts.Unsorted && (opts.Less != nil || len(opts.Order) > 0 || len(opts.Hot) > 0) && !ascending(wp.order) {
+		return ErrUnsortedIndex
+	}
+	if p.Version == 5 && (wp.header.resources > 0xffff || wp.header.aliases > 0xffff) {
+		return fmt.Errorf("error writing pak: too many resources for version 5")
+	}
+	if wp.dataEnd(p) > math.MaxUint32 {
+		return ErrTooLarge
+	}
+
+	err = writeHeader(w, wp.header)
 	if err != nil {
 		return err
 	}
@@ -155,18 +822,9 @@ func Write(w io.Writer, p *PakFile) error {
 	// 2 byte resource id
 	// 4 byte resource offset in file
 
-	indexLength := (2 + 4) * (numberOfResources + 1) // count one extra entry for last offset
-	curOffset := headerLength + indexLength          // start offset for resource data
-
-	// Sort resource ids
-	ids := make([]int, 0, numberOfResources)
-	for resId, _ := range p.Resourses {
-		ids = append(ids, int(resId)) // use int type for easy sorting
-	}
-	sort.Ints(ids)
+	curOffset := uint32(wp.header.indexEnd()) + uint32(len(wp.padding)) // start offset for resource data
 
-	for _, id := range ids {
-		resId := uint16(id)
+	for i, resId := range wp.order {
 		err = binary.Write(w, binary.LittleEndian, resId)
 		if err != nil {
 			return err
@@ -176,7 +834,7 @@ func Write(w io.Writer, p *PakFile) error {
 			return err
 		}
 
-		curOffset += uint32(len(p.Resourses[resId]))
+		curOffset += uint32(len(p.Resourses[resId])) + wp.gap(i)
 	}
 
 	// Extra resource entry at the end with ID 0 giving the end of the last resource
@@ -189,9 +847,36 @@ func Write(w io.Writer, p *PakFile) error {
 		return err
 	}
 
+	// For each alias write info:
+	// 2 byte resource id
+	// 2 byte index of the aliased entry
+	for _, ai := range wp.aliases {
+		err = binary.Write(w, binary.LittleEndian, ai.id)
+		if err != nil {
+			return err
+		}
+		err = binary.Write(w, binary.LittleEndian, ai.index)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Write(wp.padding)
+	if err != nil {
+		return err
+	}
+
 	// Write resources
-	for _, id := range ids {
-		resId := uint16(id)
+	var done, total int64
+	for _, resId := range wp.order {
+		total += int64(len(p.Resourses[resId]))
+	}
+	for i, resId := range wp.order {
+		err = ctx.Err()
+		if err != nil {
+			return err
+		}
+
 		resData := p.Resourses[resId]
 		resLength := len(resData)
 
@@ -202,17 +887,90 @@ func Write(w io.Writer, p *PakFile) error {
 		if n != resLength {
 			return fmt.Errorf("error writing resource id=%d", resId)
 		}
+
+		if gap := wp.gap(i); gap > 0 {
+			_, err = w.Write(make([]byte, gap))
+			if err != nil {
+				return err
+			}
+		}
+
+		done += int64(resLength)
+		if opts != nil && opts.Progress != nil {
+			opts.Progress(resId, done, total)
+		}
 	}
 
-	return nil
+	_, err = w.Write(wp.trailer)
+	return err
 }
 
 // Writes pak struct to file
 func WriteFile(name string, p *PakFile) error {
+	return WriteFileWithOptions(name, p, nil)
+}
+
+// Writes pak struct to file using the given options (nil means defaults)
+func WriteFileWithOptions(name string, p *PakFile, opts *WriteOptions) error {
+	if opts != nil && opts.Backup {
+		err := backupFile(name)
+		if err != nil {
+			return err
+		}
+	}
+	if opts != nil && opts.Atomic {
+		return writeFileAtomic(name, func(w io.Writer) error {
+			return WriteWithOptions(w, p, opts)
+		})
+	}
+
 	f, err := os.Create(name)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	return Write(f, p)
+	return WriteWithOptions(f, p, opts)
+}
+
+// Counts bytes passed through io.Writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Counts bytes passed through io.Reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	return n, err
+}
+
+// Writes pak struct to io.Writer, implementing io.WriterTo
+func (p *PakFile) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := Write(cw, p)
+	return cw.n, err
+}
+
+// Reads pak struct from io.Reader until EOF, replacing contents of p,
+// implementing io.ReaderFrom. On error p is left unchanged.
+func (p *PakFile) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	pak, err := Read(cr)
+	if err != nil {
+		return cr.n, err
+	}
+	*p = *pak
+	return cr.n, nil
 }
@@ -0,0 +1,184 @@
+package pak
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Version number of the pak64 format, an extension of version 5 with 64-bit
+// offsets for bundles past the 4 GiB limit of the index. Chromium does not
+// read it, Read rejects it, and Write never produces it: pak64 files are
+// only handled by Read64 and Write64.
+//
+// Pak64 file layout, all integers little endian:
+// 4 byte version number 64
+// 1 byte encoding
+// 3 bytes padding
+// 4 byte number of resources
+// 4 byte number of aliases
+// For each resource: 2 byte id, 8 byte offset in file
+// Terminator entry with id 0 giving the end of the last resource
+// For each alias: 2 byte id, 2 byte index of the aliased entry
+const Version64 Version = 64
+
+const pak64HeaderLength = 4 + 1 + 3 + 4 + 4
+
+// Writes pak in pak64 format, aliases are stored as in version 5.
+// Resources are written in ascending id order without padding or trailing
+// data.
+func Write64(w io.Writer, p *PakFile) error {
+	p, err := p.loaded()
+	if err != nil {
+		return err
+	}
+	err = p.Encoding.Validate()
+	if err != nil {
+		return fmt.Errorf("error writing pak64: %v", err)
+	}
+
+	c := *p
+	c.Version, c.Layout = 5, nil
+	wp := c.plan(nil)
+
+	bw := bufio.NewWriter(w)
+
+	var hdr [pak64HeaderLength]byte
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(Version64))
+	hdr[4] = uint8(p.Encoding)
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(wp.order)))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(len(wp.aliases)))
+	bw.Write(hdr[:])
+
+	offset := uint64(pak64HeaderLength) + (2+8)*uint64(len(wp.order)+1) + (2+2)*uint64(len(wp.aliases))
+	var entry [2 + 8]byte
+	for _, resId := range append(wp.order, 0) {
+		binary.LittleEndian.PutUint16(entry[0:], resId)
+		binary.LittleEndian.PutUint64(entry[2:], offset)
+		bw.Write(entry[:])
+		offset += uint64(len(p.Resourses[resId]))
+	}
+
+	for _, ai := range wp.aliases {
+		binary.LittleEndian.PutUint16(entry[0:], ai.id)
+		binary.LittleEndian.PutUint16(entry[2:], ai.index)
+		bw.Write(entry[:2+2])
+	}
+
+	for _, resId := range wp.order {
+		bw.Write(p.Resourses[resId])
+	}
+
+	return bw.Flush()
+}
+
+// Reads pak in pak64 format, the result has version 5
+func Read64(r io.Reader) (*PakFile, error) {
+	var hdr [pak64HeaderLength]byte
+	_, err := io.ReadFull(r, hdr[:])
+	if err != nil {
+		return nil, err
+	}
+
+	version := Version(binary.LittleEndian.Uint32(hdr[0:]))
+	if version != Version64 {
+		return nil, fmt.Errorf("error reading pak64: unsupported version %d", version)
+	}
+	encoding := Encoding(hdr[4])
+	err = encoding.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("error reading pak64: %v", err)
+	}
+	resources := binary.LittleEndian.Uint32(hdr[8:])
+	aliases := binary.LittleEndian.Uint32(hdr[12:])
+	if resources > math.MaxUint16 || aliases > math.MaxUint16 {
+		return nil, fmt.Errorf("error reading pak64: too many resources")
+	}
+
+	index, err := readBytes(r, (2+8)*uint64(resources+1)+(2+2)*uint64(aliases))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PakFile{Version: 5, Encoding: encoding, Resourses: make(map[uint16][]byte, resources+aliases)}
+
+	ids := make([]uint16, resources+1)
+	offsets := make([]uint64, resources+1)
+	for i := range ids {
+		ids[i] = binary.LittleEndian.Uint16(index[(2+8)*i:])
+		offsets[i] = binary.LittleEndian.Uint64(index[(2+8)*i+2:])
+	}
+	if ids[resources] != 0 {
+		return nil, fmt.Errorf("error reading pak64: last id != 0")
+	}
+
+	dataStart := uint64(pak64HeaderLength) + uint64(len(index))
+	if offsets[0] != dataStart {
+		return nil, fmt.Errorf("error reading pak64: data offset %d does not follow index", offsets[0])
+	}
+
+	for i := uint32(0); i < resources; i++ {
+		if offsets[i+1] < offsets[i] {
+			return nil, fmt.Errorf("error reading pak64 resource id=%d: offsets are not ascending", ids[i])
+		}
+		if _, ok := p.Resourses[ids[i]]; ok {
+			return nil, fmt.Errorf("error reading pak64 resource id=%d: duplicate id", ids[i])
+		}
+		p.Resourses[ids[i]], err = readBytes(r, offsets[i+1]-offsets[i])
+		if err != nil {
+			return nil, fmt.Errorf("error reading pak64 resource id=%d: %v", ids[i], err)
+		}
+		if p.Resourses[ids[i]] == nil {
+			p.Resourses[ids[i]] = []byte{}
+		}
+	}
+
+	aliasTable := index[(2+8)*(resources+1):]
+	for i := uint32(0); i < aliases; i++ {
+		aliasId := binary.LittleEndian.Uint16(aliasTable[(2+2)*i:])
+		entry := binary.LittleEndian.Uint16(aliasTable[(2+2)*i+2:])
+		if uint32(entry) >= resources {
+			return nil, fmt.Errorf("error reading pak64 alias id=%d: entry index %d out of range", aliasId, entry)
+		}
+		if _, ok := p.Resourses[aliasId]; ok {
+			return nil, fmt.Errorf("error reading pak64 alias id=%d: duplicate id", aliasId)
+		}
+		if p.Aliases == nil {
+			p.Aliases = make(map[uint16]uint16)
+		}
+		p.Resourses[aliasId] = p.Resourses[ids[entry]]
+		p.Aliases[aliasId] = ids[entry]
+	}
+
+	return p, nil
+}
+
+// Reads pak64 file
+func ReadFile64(name string) (*PakFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read64(f)
+}
+
+// Writes pak64 file atomically
+func WriteFile64(name string, p *PakFile) error {
+	return writeFileAtomic(name, func(w io.Writer) error {
+		return Write64(w, p)
+	})
+}
+
+// Converts pak64 read from r to a standard version 5 pak written to w,
+// failing with ErrTooLarge when its contents do not fit the 4 GiB limit
+func ConvertPak64(w io.Writer, r io.Reader) error {
+	p, err := Read64(r)
+	if err != nil {
+		return err
+	}
+	return Write(w, p)
+}
@@ -0,0 +1,159 @@
+package pak_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+// Returns ids of stored resources in index order
+func indexOrder(t *testing.T, data []byte) []uint16 {
+	t.Helper()
+	pr, err := pak.Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint16
+	for _, info := range pr.Index() {
+		if !info.Alias {
+			ids = append(ids, info.Id)
+		}
+	}
+	return ids
+}
+
+func TestWriteOrder(t *testing.T) {
+	reverse := func(a, b uint16) bool { return a > b }
+
+	tests := []struct {
+		name string
+		opts pak.WriteOptions
+		err  error
+	}{
+		{"none", pak.WriteOptions{}, nil},
+		{"order ascending", pak.WriteOptions{Order: []uint16{paktest.IDHTML, paktest.IDCSS}}, nil},
+		{"order", pak.WriteOptions{Order: []uint16{paktest.IDImage}}, pak.ErrUnsortedIndex},
+		{"less", pak.WriteOptions{Less: reverse}, pak.ErrUnsortedIndex},
+		{"hot first already", pak.WriteOptions{Hot: []uint16{paktest.IDHTML}}, nil},
+		{"hot", pak.WriteOptions{Hot: []uint16{paktest.IDImage}}, pak.ErrUnsortedIndex},
+		{"order unsorted", pak.WriteOptions{Order: []uint16{paktest.IDImage}, Unsorted: true}, nil},
+		{"less unsorted", pak.WriteOptions{Less: reverse, Unsorted: true}, nil},
+		{"hot unsorted", pak.WriteOptions{Hot: []uint16{paktest.IDImage}, Unsorted: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := paktest.Sample(5, pak.EncodingUTF8)
+			var buf bytes.Buffer
+			err := pak.WriteWithOptions(&buf, p, &tt.opts)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("WriteWithOptions error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			ids := indexOrder(t, buf.Bytes())
+			if sorted := slices.IsSorted(ids); sorted == tt.opts.Unsorted {
+				t.Errorf("index order %v, sorted = %v", ids, sorted)
+			}
+			q, err := pak.Read(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			paktest.RequireEqual(t, p, q)
+		})
+	}
+}
+
+// Pak file built byte by byte, independently of Write
+type rawPak struct {
+	version       uint32
+	encoding      uint8
+	headerPadding [3]byte
+	ids           []uint16 // index order
+	data          [][]byte
+	aliases       [][2]uint16 // alias id, index of target entry
+	padding       []byte
+	trailer       []byte
+}
+
+func (r rawPak) bytes() []byte {
+	le := binary.LittleEndian
+	var b []byte
+	if r.version == 5 {
+		b = le.AppendUint32(b, 5)
+		b = append(b, r.encoding)
+		b = append(b, r.headerPadding[:]...)
+		b = le.AppendUint16(b, uint16(len(r.ids)))
+		b = le.AppendUint16(b, uint16(len(r.aliases)))
+	} else {
+		b = le.AppendUint32(b, r.version)
+		b = le.AppendUint32(b, uint32(len(r.ids)))
+		b = append(b, r.encoding)
+	}
+
+	offset := len(b) + 6*(len(r.ids)+1) + 4*len(r.aliases) + len(r.padding)
+	for i, id := range r.ids {
+		b = le.AppendUint16(b, id)
+		b = le.AppendUint32(b, uint32(offset))
+		offset += len(r.data[i])
+	}
+	b = le.AppendUint16(b, 0)
+	b = le.AppendUint32(b, uint32(offset))
+	for _, a := range r.aliases {
+		b = le.AppendUint16(b, a[0])
+		b = le.AppendUint16(b, a[1])
+	}
+
+	b = append(b, r.padding...)
+	for _, data := range r.data {
+		b = append(b, data...)
+	}
+	return append(b, r.trailer...)
+}
+
+func TestRoundTripBytes(t *testing.T) {
+	data := [][]byte{[]byte("first"), []byte("second\x00"), {}, []byte("fourth")}
+
+	tests := []struct {
+		name string
+		raw  rawPak
+	}{
+		{"v4", rawPak{version: 4, encoding: 1, ids: []uint16{1, 2, 3, 4}, data: data}},
+		{"v4 padding trailer", rawPak{version: 4, encoding: 1, ids: []uint16{1, 2, 3, 4}, data: data, padding: []byte{0, 0, 0xff}, trailer: []byte("trailer")}},
+		{"v4 unsorted index", rawPak{version: 4, encoding: 0, ids: []uint16{4, 1, 3, 2}, data: data}},
+		{"v5", rawPak{version: 5, encoding: 1, ids: []uint16{1, 2, 3, 4}, data: data}},
+		{"v5 aliases", rawPak{version: 5, encoding: 1, ids: []uint16{1, 2, 3, 4}, data: data, aliases: [][2]uint16{{10, 0}, {11, 3}}}},
+		{"v5 header padding", rawPak{version: 5, encoding: 2, headerPadding: [3]byte{1, 2, 3}, ids: []uint16{1, 2}, data: data[:2]}},
+		{"v5 padding trailer", rawPak{version: 5, encoding: 1, ids: []uint16{1, 2, 3, 4}, data: data, aliases: [][2]uint16{{5, 1}}, padding: make([]byte, 13), trailer: []byte{0, 1, 2}}},
+		{"v5 empty trailer", rawPak{version: 5, encoding: 1, trailer: []byte("x")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.raw.bytes()
+			p, err := pak.Read(bytes.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(p.Layout.Padding, tt.raw.padding) || !bytes.Equal(p.Layout.Trailer, tt.raw.trailer) || p.Layout.HeaderPadding != tt.raw.headerPadding {
+				t.Errorf("layout = %+v, want padding %q, trailer %q, header padding %v", p.Layout, tt.raw.padding, tt.raw.trailer, tt.raw.headerPadding)
+			}
+
+			var out bytes.Buffer
+			err = pak.Write(&out, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), in) {
+				t.Errorf("written pak differs from read one:\n got %x\nwant %x", out.Bytes(), in)
+			}
+		})
+	}
+}
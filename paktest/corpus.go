@@ -0,0 +1,81 @@
+package paktest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/disintegration/pak"
+)
+
+// Returns inputs for fuzzing pak parsers: every sample pak, plus variants
+// with truncated data, inflated counts, broken offsets and a compressed
+// resource with corrupt headers
+func FuzzSeeds() [][]byte {
+	var seeds [][]byte
+	for _, version := range []pak.Version{4, 5} {
+		for _, encoding := range []pak.Encoding{pak.EncodingBinary, pak.EncodingUTF8, pak.EncodingUTF16} {
+			data := SampleBytes(version, encoding)
+			seeds = append(seeds, data)
+
+			// Truncated in header, index and data
+			for _, n := range []int{3, 10, 20, len(data) / 2, len(data) - 1} {
+				seeds = append(seeds, data[:n])
+			}
+
+			// Inflated resource count
+			inflated := append([]byte(nil), data...)
+			if version == 4 {
+				binary.LittleEndian.PutUint32(inflated[4:], 0xffffffff)
+			} else {
+				binary.LittleEndian.PutUint16(inflated[8:], 0xffff)
+				binary.LittleEndian.PutUint16(inflated[10:], 0xffff)
+			}
+			seeds = append(seeds, inflated)
+
+			// Descending offset in the first index entry
+			broken := append([]byte(nil), data...)
+			offset := 9
+			if version == 5 {
+				offset = 12
+			}
+			binary.LittleEndian.PutUint32(broken[offset+2:], 0xfffffff0)
+			seeds = append(seeds, broken)
+		}
+	}
+
+	// Brotli header claiming a huge size and a gzip header with no stream
+	p := &pak.PakFile{Version: 5, Encoding: pak.EncodingUTF8, Resourses: map[uint16][]byte{
+		1: {0x1e, 0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00},
+		2: {0x1f, 0x8b, 0x08, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	}}
+	var buf bytes.Buffer
+	pak.Write(&buf, p)
+	seeds = append(seeds, buf.Bytes())
+
+	return seeds
+}
+
+// Writes seeds to dir in the corpus format of go test fuzzing, one file per
+// seed named by its hash. Use testdata/fuzz/<FuzzName> of a package as dir to
+// make go test run them.
+func WriteCorpus(dir string, seeds [][]byte) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, seed := range seeds {
+		sum := sha256.Sum256(seed)
+		content := fmt.Sprintf("go test fuzz v1\n[]byte(%s)\n", strconv.Quote(string(seed)))
+		err = os.WriteFile(filepath.Join(dir, hex.EncodeToString(sum[:8])), []byte(content), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package paktest provides sample paks and comparison helpers for testing
+// code built on package pak.
+package paktest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/disintegration/pak"
+)
+
+// Environment variable that makes golden helpers write golden files instead
+// of comparing with them
+const UpdateEnv = "PAKTEST_UPDATE"
+
+// Minimal 1x1 PNG image
+var samplePNG = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+	0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+	0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+	0x0d, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0xf8, 0xcf, 0xc0, 0xf0,
+	0x1f, 0x00, 0x05, 0x00, 0x01, 0xff, 0x89, 0x99, 0x3d, 0x1d, 0x00, 0x00,
+	0x00, 0x00, 0x49, 0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+}
+
+// Ids of resources in sample paks
+const (
+	IDHTML       uint16 = 100 // HTML page
+	IDCSS        uint16 = 101 // stylesheet
+	IDScript     uint16 = 102 // gzip compressed JavaScript
+	IDImage      uint16 = 103 // PNG image
+	IDText       uint16 = 104 // text in the pak encoding
+	IDEmpty      uint16 = 105 // empty resource
+	IDAlias      uint16 = 200 // alias of IDHTML in version 5, a copy in version 4
+	IDScriptCopy uint16 = 201 // alias of IDScript in version 5, a copy in version 4
+)
+
+// Returns sample pak of the given version and encoding with HTML, CSS, gzip
+// compressed JavaScript, PNG, text and empty resources. Version 5 samples
+// alias two resources, version 4 samples store them twice. Text is UTF-16LE
+// encoded for pak.EncodingUTF16.
+func Sample(version pak.Version, encoding pak.Encoding) *pak.PakFile {
+	script, err := pak.Compress([]byte("document.title = 'sample';\n"), pak.CompressionGzip)
+	if err != nil {
+		panic(err)
+	}
+
+	text := []byte("Sample text\n")
+	if encoding == pak.EncodingUTF16 {
+		units := utf16.Encode([]rune("Sample text\n"))
+		text = make([]byte, 2*len(units))
+		for i, u := range units {
+			binary.LittleEndian.PutUint16(text[2*i:], u)
+		}
+	}
+
+	html := []byte("<!DOCTYPE html><html><body><h1>Sample</h1></body></html>\n")
+
+	p := &pak.PakFile{
+		Version:  version,
+		Encoding: encoding,
+		Resourses: map[uint16][]byte{
+			IDHTML:       html,
+			IDCSS:        []byte("body { margin: 0; }\n"),
+			IDScript:     script,
+			IDImage:      samplePNG,
+			IDText:       text,
+			IDEmpty:      {},
+			IDAlias:      html,
+			IDScriptCopy: script,
+		},
+	}
+	if version == 5 {
+		p.Aliases = map[uint16]uint16{IDAlias: IDHTML, IDScriptCopy: IDScript}
+	}
+	return p
+}
+
+// Returns sample paks of all supported versions and encodings keyed by names
+// such as "v5-utf8"
+func Samples() map[string]*pak.PakFile {
+	encodings := map[pak.Encoding]string{pak.EncodingBinary: "binary", pak.EncodingUTF8: "utf8", pak.EncodingUTF16: "utf16"}
+
+	samples := make(map[string]*pak.PakFile)
+	for _, version := range []pak.Version{4, 5} {
+		for encoding, name := range encodings {
+			samples[fmt.Sprintf("v%d-%s", version, name)] = Sample(version, encoding)
+		}
+	}
+	return samples
+}
+
+// Returns sample pak serialized, see Sample
+func SampleBytes(version pak.Version, encoding pak.Encoding) []byte {
+	var buf bytes.Buffer
+	err := pak.Write(&buf, Sample(version, encoding))
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// Returns differences between paks at the resource level, empty if their
+// contents are equal. Layout is not compared.
+func Diff(a, b *pak.PakFile) []string {
+	var diffs []string
+	if a.Version != b.Version {
+		diffs = append(diffs, fmt.Sprintf("version: %d != %d", a.Version, b.Version))
+	}
+	if a.Encoding != b.Encoding {
+		diffs = append(diffs, fmt.Sprintf("encoding: %d != %d", a.Encoding, b.Encoding))
+	}
+
+	for _, resId := range unionIds(a.Resourses, b.Resourses) {
+		da, okA := a.Resourses[resId]
+		db, okB := b.Resourses[resId]
+		switch {
+		case !okB:
+			diffs = append(diffs, fmt.Sprintf("resource id=%d: only in first pak (%d bytes)", resId, len(da)))
+		case !okA:
+			diffs = append(diffs, fmt.Sprintf("resource id=%d: only in second pak (%d bytes)", resId, len(db)))
+		case !bytes.Equal(da, db):
+			diffs = append(diffs, fmt.Sprintf("resource id=%d: data differs at byte %d (%d bytes != %d bytes)", resId, firstDiff(da, db), len(da), len(db)))
+		}
+	}
+
+	for _, resId := range unionIds(a.Aliases, b.Aliases) {
+		ta, okA := a.Aliases[resId]
+		tb, okB := b.Aliases[resId]
+		switch {
+		case !okB:
+			diffs = append(diffs, fmt.Sprintf("alias id=%d: only in first pak (of %d)", resId, ta))
+		case !okA:
+			diffs = append(diffs, fmt.Sprintf("alias id=%d: only in second pak (of %d)", resId, tb))
+		case ta != tb:
+			diffs = append(diffs, fmt.Sprintf("alias id=%d: target %d != %d", resId, ta, tb))
+		}
+	}
+
+	return diffs
+}
+
+// Fails test if paks differ, listing every differing resource
+func RequireEqual(t testing.TB, a, b *pak.PakFile) {
+	t.Helper()
+	diffs := Diff(a, b)
+	if len(diffs) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	for _, d := range diffs {
+		fmt.Fprintf(&buf, "\n\t%s", d)
+	}
+	t.Fatalf("paks differ:%s", buf.String())
+}
+
+// Compares data with golden file testdata/name, failing test if they differ.
+// With PAKTEST_UPDATE set in the environment the golden file is written
+// instead.
+func Golden(t testing.TB, name string, data []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if os.Getenv(UpdateEnv) != "" {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			t.Fatalf("error updating golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading golden file: %v (set %s=1 to create it)", err, UpdateEnv)
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("%s: output differs from golden file at byte %d (%d bytes, want %d)", path, firstDiff(data, want), len(data), len(want))
+	}
+}
+
+// Compares pak with golden pak file testdata/name at the resource level,
+// see Golden and RequireEqual
+func GoldenPak(t testing.TB, name string, p *pak.PakFile) {
+	t.Helper()
+
+	if os.Getenv(UpdateEnv) != "" {
+		var buf bytes.Buffer
+		err := pak.Write(&buf, p)
+		if err != nil {
+			t.Fatalf("error writing pak: %v", err)
+		}
+		Golden(t, name, buf.Bytes())
+		return
+	}
+
+	want, err := pak.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("error reading golden file: %v (set %s=1 to create it)", err, UpdateEnv)
+	}
+	RequireEqual(t, p, want)
+}
+
+// Returns ids present in either map in ascending order
+func unionIds[V any](a, b map[uint16]V) []uint16 {
+	var ids []uint16
+	for resId := range a {
+		ids = append(ids, resId)
+	}
+	for resId := range b {
+		if _, ok := a[resId]; !ok {
+			ids = append(ids, resId)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Returns index of first differing byte
+func firstDiff(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package pak
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Replaces data of a single resource in pak file.
+// Only the affected region is rewritten when the new data has the same length
+// as the old one, or when the resource is stored last in the file. Otherwise,
+// and for new ids, aliases and resources aliased by others, the whole file is
+// rewritten.
+func PatchFile(name string, id uint16, data []byte) error {
+	return PatchFileWithOptions(name, id, data, nil)
+}
+
+// Replaces data of a single resource in pak file using the given write options
+// (nil means defaults), see PatchFile. With opts.Atomic the whole file is
+// always rewritten through a temporary file.
+func PatchFileWithOptions(name string, id uint16, data []byte, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	if opts.Backup {
+		err := backupFile(name)
+		if err != nil {
+			return err
+		}
+		// Backup is taken once, not again by the full rewrite below
+		o := *opts
+		o.Backup = false
+		opts = &o
+	}
+
+	if opts.Atomic {
+		return rewriteFile(name, id, data, opts)
+	}
+
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	patched, err := patchInPlace(f, id, data)
+	if err != nil || patched {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+		return err
+	}
+	f.Close()
+
+	return rewriteFile(name, id, data, opts)
+}
+
+// Rewrites the whole pak file with resource replaced
+func rewriteFile(name string, id uint16, data []byte, opts *WriteOptions) error {
+	p, err := ReadFile(name)
+	if err != nil {
+		return err
+	}
+	p.Resourses[id] = data
+	return WriteFileWithOptions(name, p, opts)
+}
+
+// Overwrites resource data in place, reports false if it is not possible
+func patchInPlace(f *os.File, id uint16, data []byte) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	r := io.NewSectionReader(f, 0, fi.Size())
+
+	h, err := readHeader(r)
+	if err != nil {
+		return false, err
+	}
+	if h.version != 4 && h.version != 5 {
+		return false, fmt.Errorf("error patching pak: unsupported version %d", h.version)
+	}
+
+	resInfos, aliasInfos, err := readIndex(r, h)
+	if err != nil {
+		return false, err
+	}
+
+	i := -1
+	for j := uint32(0); j < h.resources; j++ {
+		if resInfos[j].id == id {
+			i = int(j)
+			break
+		}
+	}
+	if i < 0 {
+		return false, nil // new id or alias
+	}
+	for _, ai := range aliasInfos {
+		if int(ai.index) == i {
+			return false, nil // data shared with aliases
+		}
+	}
+
+	start, end := resInfos[i].offset, resInfos[i+1].offset
+	if end < start || int64(end) > fi.Size() {
+		return false, fmt.Errorf("error patching pak: resource id=%d has invalid range", id)
+	}
+
+	if uint64(len(data)) == uint64(end-start) {
+		_, err = f.WriteAt(data, int64(start))
+		return true, err
+	}
+
+	// The last resource can grow or shrink unless followed by trailing data
+	last := uint32(i) == h.resources-1
+	if !last || int64(end) != fi.Size() || uint64(start)+uint64(len(data)) > math.MaxUint32 {
+		return false, nil
+	}
+
+	_, err = f.WriteAt(data, int64(start))
+	if err != nil {
+		return true, err
+	}
+
+	// Update terminator offset, its offset field follows the 2 byte id
+	newEnd := start + uint32(len(data))
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, newEnd)
+	_, err = f.WriteAt(buf, int64(h.length())+(2+4)*int64(h.resources)+2)
+	if err != nil {
+		return true, err
+	}
+
+	return true, f.Truncate(int64(newEnd))
+}
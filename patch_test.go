@@ -0,0 +1,82 @@
+package pak_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/paktest"
+)
+
+func TestPatchFile(t *testing.T) {
+	html := paktest.Sample(5, pak.EncodingUTF8).Resourses[paktest.IDHTML]
+
+	// Resources fitting their slot, or stored last, are patched in place, the
+	// others exhaust it and make PatchFile rewrite the file
+	tests := []struct {
+		name string
+		id   uint16
+		data []byte
+	}{
+		{"same length", paktest.IDCSS, bytes.Repeat([]byte("x"), 20)},
+		{"last grows", paktest.IDEmpty, []byte("now longer than before")},
+		{"last shrinks", paktest.IDEmpty, []byte{}},
+		{"slot too small", paktest.IDCSS, bytes.Repeat([]byte("x"), 21)},
+		{"slot too large", paktest.IDText, []byte("short")},
+		{"aliased resource", paktest.IDHTML, bytes.Repeat([]byte("y"), len(html))},
+		{"alias", paktest.IDAlias, []byte("own data")},
+		{"new id", 300, []byte("new")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.pak")
+			before := paktest.SampleBytes(5, pak.EncodingUTF8)
+			err := os.WriteFile(name, before, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := pak.Read(bytes.NewReader(before))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = want.Set(tt.id, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Aliases of a patched resource keep the old data as their own
+			for aliasId, target := range want.Aliases {
+				if !bytes.Equal(want.Resourses[aliasId], want.Resourses[target]) {
+					delete(want.Aliases, aliasId)
+				}
+			}
+
+			err = pak.PatchFile(name, tt.id, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			after, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := pak.Read(bytes.NewReader(after))
+			if err != nil {
+				t.Fatal(err)
+			}
+			paktest.RequireEqual(t, want, p)
+
+			// Patched in place or rewritten, the file is what Write produces
+			var buf bytes.Buffer
+			err = pak.Write(&buf, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(after, buf.Bytes()) {
+				t.Errorf("patched file differs from written pak:\n got %x\nwant %x", after, buf.Bytes())
+			}
+		})
+	}
+}
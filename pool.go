@@ -0,0 +1,119 @@
+package pak
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// Index entries read at a time
+const indexChunk = 4096
+
+// Buffers for parsing an index, pooled across reads
+type indexScratch struct {
+	buf      []byte
+	resInfos []resourceInfo
+	aliases  []aliasInfo
+}
+
+var indexPool = sync.Pool{
+	New: func() any { return &indexScratch{} },
+}
+
+// Reads index like readIndex, returning slices of the scratch buffers. The
+// claimed number of resources is not trusted for preallocation, the index is
+// read in chunks, so a short one fails with an EOF error long before growing
+// large.
+func readIndexInto(r io.Reader, h header, s *indexScratch) ([]resourceInfo, []aliasInfo, error) {
+	s.resInfos = s.resInfos[:0]
+	for n := uint64(h.resources) + 1; uint64(len(s.resInfos)) < n; {
+		chunk := min(n-uint64(len(s.resInfos)), indexChunk)
+		buf, err := s.read(r, int(chunk)*(2+4))
+		if err != nil {
+			return nil, nil, err
+		}
+		for ; len(buf) > 0; buf = buf[2+4:] {
+			s.resInfos = append(s.resInfos, resourceInfo{
+				id:     binary.LittleEndian.Uint16(buf),
+				offset: binary.LittleEndian.Uint32(buf[2:]),
+			})
+		}
+	}
+
+	s.aliases = s.aliases[:0]
+	for uint32(len(s.aliases)) < h.aliases {
+		chunk := min(h.aliases-uint32(len(s.aliases)), indexChunk)
+		buf, err := s.read(r, int(chunk)*(2+2))
+		if err != nil {
+			return nil, nil, err
+		}
+		for ; len(buf) > 0; buf = buf[2+2:] {
+			s.aliases = append(s.aliases, aliasInfo{
+				id:    binary.LittleEndian.Uint16(buf),
+				index: binary.LittleEndian.Uint16(buf[2:]),
+			})
+		}
+	}
+
+	return s.resInfos, s.aliases, nil
+}
+
+// Reads n bytes into scratch buffer
+func (s *indexScratch) read(r io.Reader, n int) ([]byte, error) {
+	if cap(s.buf) < n {
+		s.buf = make([]byte, n)
+	}
+	buf := s.buf[:n]
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+// Reads n bytes of resource data, reusing buf if large enough
+func readArena(r io.Reader, buf []byte, n int64) ([]byte, error) {
+	if int64(cap(buf)) < n {
+		buf = make([]byte, n)
+	}
+	buf = buf[:n]
+	_, err := io.ReadFull(r, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Empties pak for reading into, keeping its maps and buffers. Returns new
+// pak for nil p.
+func (p *PakFile) reset() *PakFile {
+	if p == nil {
+		return &PakFile{Resourses: make(map[uint16][]byte), Layout: &Layout{}}
+	}
+
+	if p.Resourses == nil {
+		p.Resourses = make(map[uint16][]byte)
+	}
+	clear(p.Resourses)
+	clear(p.Aliases)
+	p.Lazy = nil
+
+	if p.Layout == nil {
+		p.Layout = &Layout{}
+	}
+	*p.Layout = Layout{Order: p.Layout.Order[:0], AliasOrder: p.Layout.AliasOrder[:0]}
+	return p
+}
+
+// Reads pak from r into p, replacing its contents while reusing its maps and
+// buffers, so reading many paks in turn into one PakFile allocates little.
+// Resource data of all resources shares one buffer that the next ReadInto
+// overwrites, slices of previous contents must not be kept. On error the
+// contents of p are undefined.
+func ReadInto(p *PakFile, r io.Reader) error {
+	return ReadIntoWithOptions(p, r, nil)
+}
+
+// Same as ReadInto using the given options (nil means defaults)
+func ReadIntoWithOptions(p *PakFile, r io.Reader, opts *ReadOptions) error {
+	_, err := readPakInto(context.Background(), r, opts, p, true)
+	return err
+}
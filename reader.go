@@ -0,0 +1,297 @@
+package pak
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Location of a resource in a pak file
+type ResourceInfo struct {
+	Id      uint16
+	Offset  int64 // position of data in file
+	Length  int64
+	AliasOf uint16 // id of aliased resource for aliases, 0 otherwise
+	Alias   bool
+}
+
+// Reads resources of a pak on demand instead of loading it whole. Only the
+// header and index are read when opened. Safe for concurrent use by multiple
+// goroutines.
+type Reader struct {
+	r        io.ReaderAt
+	closer   io.Closer
+	version  Version
+	encoding Encoding
+	header   header
+	dataEnd  int64 // end of the last resource
+	infos    map[uint16]ResourceInfo
+	ids      []uint16 // sorted resource ids
+
+	mu    sync.Mutex
+	cache *lruCache // decompressed data, nil if disabled
+}
+
+// Opens pak of the given size for reading resources on demand. The index is
+// checked the way Read checks it.
+func Open(r io.ReaderAt, size int64) (*Reader, error) {
+	sr := io.NewSectionReader(r, 0, size)
+
+	h, err := readHeader(sr)
+	if err != nil {
+		return nil, err
+	}
+	if h.version != 4 && h.version != 5 {
+		return nil, fmt.Errorf("error reading pak: unsupported version %d", h.version)
+	}
+	err = Encoding(h.encoding).Validate()
+	if err != nil {
+		return nil, fmt.Errorf("error reading pak: %v", err)
+	}
+	if h.indexEnd() > uint64(size) {
+		return nil, fmt.Errorf("error reading pak: index needs %d bytes, file is %d bytes", h.indexEnd(), size)
+	}
+
+	resInfos, aliasInfos, err := readIndex(sr, h)
+	if err != nil {
+		return nil, err
+	}
+	if resInfos[h.resources].id != 0 {
+		return nil, fmt.Errorf("error reading resources: last id != 0")
+	}
+	if uint64(resInfos[0].offset) < h.indexEnd() {
+		return nil, fmt.Errorf("error reading resources: data offset %d overlaps index", resInfos[0].offset)
+	}
+	if int64(resInfos[h.resources].offset) > size {
+		return nil, fmt.Errorf("error reading resources: data ends at %d, file is %d bytes", resInfos[h.resources].offset, size)
+	}
+
+	pr := &Reader{r: r, version: Version(h.version), encoding: Encoding(h.encoding), header: h, dataEnd: int64(resInfos[h.resources].offset), infos: make(map[uint16]ResourceInfo, len(resInfos)+len(aliasInfos))}
+
+	for i := uint32(0); i < h.resources; i++ {
+		ri, next := resInfos[i], resInfos[i+1]
+		if next.offset < ri.offset {
+			return nil, fmt.Errorf("error reading resource id=%d: offsets are not ascending", ri.id)
+		}
+		if _, ok := pr.infos[ri.id]; ok {
+			return nil, fmt.Errorf("error reading resource id=%d: duplicate id", ri.id)
+		}
+		pr.infos[ri.id] = ResourceInfo{Id: ri.id, Offset: int64(ri.offset), Length: int64(next.offset - ri.offset)}
+	}
+
+	for _, ai := range aliasInfos {
+		if uint32(ai.index) >= h.resources {
+			return nil, fmt.Errorf("error reading alias id=%d: entry index %d out of range", ai.id, ai.index)
+		}
+		if _, ok := pr.infos[ai.id]; ok {
+			return nil, fmt.Errorf("error reading alias id=%d: duplicate id", ai.id)
+		}
+		target := pr.infos[resInfos[ai.index].id]
+		pr.infos[ai.id] = ResourceInfo{Id: ai.id, Offset: target.Offset, Length: target.Length, AliasOf: target.Id, Alias: true}
+	}
+
+	pr.ids = make([]uint16, 0, len(pr.infos))
+	for resId := range pr.infos {
+		pr.ids = append(pr.ids, resId)
+	}
+	sortIds(pr.ids)
+
+	return pr, nil
+}
+
+// Opens pak file for reading resources on demand, the file stays open until
+// Close is called
+func OpenFile(name string) (*Reader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	pr, err := Open(f, fi.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	pr.closer = f
+	return pr, nil
+}
+
+// Closes file opened by OpenFile, does nothing for readers made by Open
+func (pr *Reader) Close() error {
+	if pr.closer == nil {
+		return nil
+	}
+	return pr.closer.Close()
+}
+
+// Returns pak format version
+func (pr *Reader) Version() Version {
+	return pr.version
+}
+
+// Returns text encoding declared by the pak
+func (pr *Reader) Encoding() Encoding {
+	return pr.encoding
+}
+
+// Returns resource ids in ascending order, aliases included
+func (pr *Reader) IDs() []uint16 {
+	return append([]uint16(nil), pr.ids...)
+}
+
+// Returns number of resources, aliases included
+func (pr *Reader) Len() int {
+	return len(pr.ids)
+}
+
+// Returns location of resource
+func (pr *Reader) Info(id uint16) (ResourceInfo, bool) {
+	info, ok := pr.infos[id]
+	return info, ok
+}
+
+// Reads resource data as stored
+func (pr *Reader) Get(id uint16) ([]byte, error) {
+	info, ok := pr.infos[id]
+	if !ok {
+		return nil, fmt.Errorf("resource id=%d not found", id)
+	}
+
+	data := make([]byte, info.Length)
+	n, err := pr.r.ReadAt(data, info.Offset)
+	if n == len(data) {
+		err = nil // data may end at EOF
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading resource id=%d: %v", id, err)
+	}
+	return data, nil
+}
+
+// Reads all resources into pak struct
+func (pr *Reader) PakFile() (*PakFile, error) {
+	p := &PakFile{Version: pr.version, Encoding: pr.encoding, Resourses: make(map[uint16][]byte, len(pr.ids))}
+	for _, resId := range pr.ids {
+		info := pr.infos[resId]
+		if info.Alias {
+			continue
+		}
+		data, err := pr.Get(resId)
+		if err != nil {
+			return nil, err
+		}
+		p.Resourses[resId] = data
+	}
+	for _, resId := range pr.ids {
+		info := pr.infos[resId]
+		if !info.Alias {
+			continue
+		}
+		if p.Aliases == nil {
+			p.Aliases = make(map[uint16]uint16)
+		}
+		p.Aliases[resId] = info.AliasOf
+		p.Resourses[resId] = p.Resourses[info.AliasOf]
+	}
+	return p, nil
+}
+
+// Enables caching of data returned by Decompressed up to limit bytes in
+// total, least recently used data is evicted first. Zero or negative limit
+// disables the cache.
+func (pr *Reader) SetCacheLimit(limit int64) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if limit <= 0 {
+		pr.cache = nil
+		return
+	}
+	pr.cache = newLRUCache(limit)
+}
+
+// Reads resource data and decompresses it, see Decompress. With a cache set
+// by SetCacheLimit repeated calls return the same slice, which must not be
+// modified. Aliases share cache entries with their targets.
+func (pr *Reader) Decompressed(id uint16) ([]byte, error) {
+	info, ok := pr.infos[id]
+	if ok && info.Alias {
+		id = info.AliasOf
+	}
+
+	pr.mu.Lock()
+	if pr.cache != nil {
+		if data, ok := pr.cache.get(id); ok {
+			pr.mu.Unlock()
+			return data, nil
+		}
+	}
+	pr.mu.Unlock()
+
+	data, err := pr.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if IsEncrypted(data) {
+		return nil, ErrEncrypted
+	}
+	data, err = Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing resource id=%d: %v", id, err)
+	}
+
+	pr.mu.Lock()
+	if pr.cache != nil {
+		pr.cache.put(id, data)
+	}
+	pr.mu.Unlock()
+
+	return data, nil
+}
+
+// Returns size of resource data as stored in the pak
+func (pr *Reader) SizeStored(id uint16) (int64, bool) {
+	info, ok := pr.infos[id]
+	return info.Length, ok
+}
+
+// Returns size of resource data after decompression, reading only the
+// compression header and trailer, see DecompressedSize
+func (pr *Reader) SizeRaw(id uint16) (int64, error) {
+	info, ok := pr.infos[id]
+	if !ok {
+		return 0, fmt.Errorf("resource id=%d not found", id)
+	}
+
+	// Brotli header at the start, gzip size in the last 4 bytes
+	head := make([]byte, min(info.Length, brotliHeaderLength))
+	_, err := pr.r.ReadAt(head, info.Offset)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if bytes.HasPrefix(head, brotliMagic) || bytes.HasPrefix(head, gzipMagic) {
+		// Stitch header and trailer for DetectCompression and DecompressedSize
+		data := make([]byte, 0, len(head)+18)
+		data = append(data, head...)
+		if info.Length > int64(len(head)) {
+			tail := make([]byte, min(info.Length-int64(len(head)), 18))
+			_, err = pr.r.ReadAt(tail, info.Offset+info.Length-int64(len(tail)))
+			if err != nil && err != io.EOF {
+				return 0, err
+			}
+			data = append(data, tail...)
+		}
+		return DecompressedSize(data), nil
+	}
+	return info.Length, nil
+}
@@ -0,0 +1,134 @@
+package pak
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Chooses compression of a resource from its id and decompressed data
+type CompressionPolicy func(id uint16, data []byte) Compression
+
+// Returns policy compressing resources larger than minSize bytes with c and
+// storing smaller ones uncompressed
+func CompressOver(minSize int, c Compression) CompressionPolicy {
+	return func(id uint16, data []byte) Compression {
+		if len(data) > minSize {
+			return c
+		}
+		return CompressionNone
+	}
+}
+
+// Parses policy of form "compression[>size]", e.g. "brotli>1K" compresses
+// resources over 1 KiB with brotli, "gzip" compresses all resources and
+// "none" decompresses them. Size takes an optional K or M suffix.
+func ParseCompressionPolicy(s string) (CompressionPolicy, error) {
+	name, size, _ := strings.Cut(s, ">")
+
+	var c Compression
+	switch strings.TrimSpace(name) {
+	case "none":
+		c = CompressionNone
+	case "gzip":
+		c = CompressionGzip
+	case "brotli":
+		c = CompressionBrotli
+	default:
+		return nil, fmt.Errorf("error parsing compression policy %q: unknown compression %q", s, name)
+	}
+
+	minSize := 0
+	if size = strings.TrimSpace(size); size != "" {
+		unit := 1
+		switch {
+		case strings.HasSuffix(size, "K"):
+			size, unit = size[:len(size)-1], 1<<10
+		case strings.HasSuffix(size, "M"):
+			size, unit = size[:len(size)-1], 1<<20
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("error parsing compression policy %q: bad size", s)
+		}
+		minSize = n * unit
+	}
+	return CompressOver(minSize, c), nil
+}
+
+// Resource whose compression was changed by Recompress
+type RecompressChange struct {
+	Id     uint16
+	From   Compression
+	To     Compression
+	Before int64 // stored size before
+	After  int64 // stored size after
+}
+
+// Result of Recompress. Totals cover stored data of all resources that are
+// not aliases, changed or not.
+type RecompressReport struct {
+	Changes []RecompressChange // in ascending id order
+	Before  int64
+	After   int64
+}
+
+// Converts resources of pak between compressions as policy chooses, passing
+// decompressed data to it. Resources already compressed as chosen are left
+// alone, encrypted ones are skipped. Aliases get the new data of their
+// targets. On error p is left unchanged.
+func Recompress(p *PakFile, policy CompressionPolicy) (*RecompressReport, error) {
+	err := p.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RecompressReport{}
+	updated := make(map[uint16][]byte)
+	for _, resId := range sortedIds(p) {
+		if _, ok := p.Aliases[resId]; ok {
+			continue
+		}
+		resData := p.Resourses[resId]
+		report.Before += int64(len(resData))
+		if IsEncrypted(resData) {
+			report.After += int64(len(resData))
+			continue
+		}
+
+		from := DetectCompression(resData)
+		raw, err := Decompress(resData)
+		if err != nil {
+			return nil, fmt.Errorf("error recompressing resource id=%d: %v", resId, err)
+		}
+		to := policy(resId, raw)
+		if to == from {
+			report.After += int64(len(resData))
+			continue
+		}
+		out, err := Compress(raw, to)
+		if err != nil {
+			return nil, fmt.Errorf("error recompressing resource id=%d: %v", resId, err)
+		}
+
+		updated[resId] = out
+		report.After += int64(len(out))
+		report.Changes = append(report.Changes, RecompressChange{
+			Id:     resId,
+			From:   from,
+			To:     to,
+			Before: int64(len(resData)),
+			After:  int64(len(out)),
+		})
+	}
+
+	for resId, resData := range updated {
+		p.Resourses[resId] = resData
+	}
+	for aliasId, target := range p.Aliases {
+		if resData, ok := updated[target]; ok {
+			p.Resourses[aliasId] = resData
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,142 @@
+package pak
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Describes the outcome of recovering a damaged pak
+type RecoveryReport struct {
+	Recovered      []uint16 // ids of resources recovered intact, in index order
+	Lost           []uint16 // ids present in the index whose data could not be recovered
+	MissingEntries int      // number of index entries lost entirely (ids unknown)
+}
+
+// Recovers every intact resource from a truncated or partially corrupted pak.
+// A resource is recovered when both its index entry and the entry following it
+// are readable and describe a data range that lies within the available data.
+// An error is returned only when not even the header can be read.
+func Recover(r io.Reader) (*PakFile, *RecoveryReport, error) {
+	return RecoverWithOptions(r, nil)
+}
+
+// Same as Recover, logging every repair to opts.Logger. Other options are
+// ignored, the whole input is read into memory.
+func RecoverWithOptions(r io.Reader, opts *ReadOptions) (*PakFile, *RecoveryReport, error) {
+	log := opts.logger()
+
+	data, err := io.ReadAll(r)
+	if err != nil && len(data) == 0 {
+		return nil, nil, err
+	}
+
+	br := bytes.NewReader(data)
+
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error recovering pak: %v", err)
+	}
+	numberOfResources := h.resources
+	log.Debug("pak header", "version", h.version, "encoding", h.encoding, "resources", h.resources, "aliases", h.aliases)
+
+	pak := &PakFile{
+		Version:   Version(h.version),
+		Encoding:  Encoding(h.encoding),
+		Resourses: make(map[uint16][]byte),
+	}
+	report := &RecoveryReport{}
+
+	// Read as many index entries as are available, including the terminator.
+	// The claimed resource count is not trusted for preallocation.
+	numberOfEntries := uint64(numberOfResources) + 1
+	var resInfos []resourceInfo
+	for uint64(len(resInfos)) < numberOfEntries {
+		ri, err := readResourceInfo(br)
+		if err != nil {
+			break
+		}
+		resInfos = append(resInfos, ri)
+	}
+	report.MissingEntries = int(numberOfEntries - uint64(len(resInfos)))
+
+	// Alias table follows the index in version 5
+	var aliasInfos []aliasInfo
+	for report.MissingEntries == 0 && uint32(len(aliasInfos)) < h.aliases {
+		ai, err := readAliasInfo(br)
+		if err != nil {
+			break
+		}
+		aliasInfos = append(aliasInfos, ai)
+	}
+	if report.MissingEntries > 0 {
+		report.MissingEntries += int(h.aliases)
+	} else {
+		report.MissingEntries += int(h.aliases) - len(aliasInfos)
+	}
+	if report.MissingEntries > 0 {
+		log.Debug("pak index truncated", "missing", report.MissingEntries)
+	}
+
+	dataStart := h.indexEnd()
+	dataEnd := uint64(len(data))
+
+	for i := 0; i < len(resInfos) && uint64(i) < uint64(numberOfResources); i++ {
+		resId := resInfos[i].id
+
+		if i+1 == len(resInfos) {
+			// Entry giving the end of this resource is lost
+			log.Debug("pak resource lost", "id", resId, "reason", "end offset missing")
+			report.Lost = append(report.Lost, resId)
+			continue
+		}
+
+		start, end := uint64(resInfos[i].offset), uint64(resInfos[i+1].offset)
+		_, dup := pak.Resourses[resId]
+		if dup || start < dataStart || end < start || end > dataEnd {
+			log.Debug("pak resource lost", "id", resId, "start", start, "end", end, "duplicate", dup)
+			report.Lost = append(report.Lost, resId)
+			continue
+		}
+
+		resData := make([]byte, end-start)
+		copy(resData, data[start:end])
+		pak.Resourses[resId] = resData
+		report.Recovered = append(report.Recovered, resId)
+	}
+
+	for _, ai := range aliasInfos {
+		_, dup := pak.Resourses[ai.id]
+		if int(ai.index) >= len(resInfos)-1 || dup {
+			log.Debug("pak alias lost", "id", ai.id, "index", ai.index, "duplicate", dup)
+			report.Lost = append(report.Lost, ai.id)
+			continue
+		}
+		target := resInfos[ai.index].id
+		targetData, ok := pak.Resourses[target]
+		if !ok {
+			log.Debug("pak alias lost", "id", ai.id, "target", target)
+			report.Lost = append(report.Lost, ai.id)
+			continue
+		}
+		if pak.Aliases == nil {
+			pak.Aliases = make(map[uint16]uint16)
+		}
+		pak.Resourses[ai.id] = targetData
+		pak.Aliases[ai.id] = target
+		report.Recovered = append(report.Recovered, ai.id)
+	}
+
+	return pak, report, nil
+}
+
+// Recovers intact resources from a damaged pak file
+func RecoverFile(name string) (*PakFile, *RecoveryReport, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return Recover(f)
+}
@@ -0,0 +1,65 @@
+package pak_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/disintegration/pak"
+)
+
+func TestRecoverTruncated(t *testing.T) {
+	// 12 byte header, 5 index entries and 1 alias entry take 46 bytes, data
+	// of resources 1-4 spans 46-51, 51-58, 58-58 and 58-64
+	raw := rawPak{
+		version:  5,
+		encoding: 1,
+		ids:      []uint16{1, 2, 3, 4},
+		data:     [][]byte{[]byte("first"), []byte("second\x00"), {}, []byte("fourth")},
+		aliases:  [][2]uint16{{10, 0}},
+	}
+	data := raw.bytes()
+
+	tests := []struct {
+		name      string
+		size      int
+		recovered []uint16
+		lost      []uint16
+		missing   int
+	}{
+		{"intact", 64, []uint16{1, 2, 3, 4, 10}, nil, 0},
+		{"last resource cut", 60, []uint16{1, 2, 3, 10}, []uint16{4}, 0},
+		{"data cut", 55, []uint16{1, 10}, []uint16{2, 3, 4}, 0},
+		{"data lost", 46, nil, []uint16{1, 2, 3, 4, 10}, 0},
+		{"alias table cut", 44, nil, []uint16{1, 2, 3, 4}, 1},
+		{"index cut", 30, nil, []uint16{1, 2, 3}, 3},
+		{"index cut mid entry", 33, nil, []uint16{1, 2, 3}, 3},
+		{"header only", 12, nil, nil, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, r, err := pak.Recover(bytes.NewReader(data[:tt.size]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(r.Recovered, tt.recovered) || !slices.Equal(r.Lost, tt.lost) || r.MissingEntries != tt.missing {
+				t.Errorf("report = %+v, want recovered %v, lost %v, missing %d", r, tt.recovered, tt.lost, tt.missing)
+			}
+			for _, resId := range tt.recovered {
+				i := slices.Index(raw.ids, resId)
+				if resId == 10 {
+					i = 0
+				}
+				if !bytes.Equal(p.Resourses[resId], raw.data[i]) {
+					t.Errorf("resource %d = %q, want %q", resId, p.Resourses[resId], raw.data[i])
+				}
+			}
+		})
+	}
+
+	_, _, err := pak.Recover(bytes.NewReader(data[:8]))
+	if err == nil {
+		t.Error("Recover of truncated header succeeded")
+	}
+}
@@ -0,0 +1,73 @@
+package pak
+
+import (
+	"fmt"
+)
+
+// Renumbers resources according to old id -> new id mapping, ids missing from
+// mapping keep their number. Aliases, lazy resources and symbols follow their
+// resources. Fails without modifying p when a resource would get reserved id
+// 0 or two resources would end up with the same id. Recorded layout is
+// dropped, so the result is written in ascending id order.
+func Remap(p *PakFile, mapping map[uint16]uint16) error {
+	owner := make(map[uint16]uint16, p.Len()) // new id -> old id
+	ids, _ := p.ListIDs()
+	for _, oldId := range ids {
+		newId, ok := mapping[oldId]
+		if !ok {
+			newId = oldId
+		}
+		if newId == 0 {
+			return fmt.Errorf("error remapping resource %d: %v", oldId, ErrReservedID)
+		}
+		if prev, ok := owner[newId]; ok {
+			a, b := prev, oldId
+			if a > b {
+				a, b = b, a
+			}
+			return fmt.Errorf("error remapping: resources %d and %d both map to id %d", a, b, newId)
+		}
+		owner[newId] = oldId
+	}
+
+	remap := func(id uint16) uint16 {
+		if newId, ok := mapping[id]; ok {
+			return newId
+		}
+		return id
+	}
+
+	resources := make(map[uint16][]byte, len(p.Resourses))
+	for oldId, resData := range p.Resourses {
+		resources[remap(oldId)] = resData
+	}
+	p.Resourses = resources
+
+	if p.Lazy != nil {
+		lazy := make(map[uint16]*LazyResource, len(p.Lazy))
+		for oldId, lr := range p.Lazy {
+			lazy[remap(oldId)] = lr
+		}
+		p.Lazy = lazy
+	}
+
+	if p.Symbols != nil {
+		symbols := make(SymbolTable, len(p.Symbols))
+		for name, resId := range p.Symbols {
+			symbols[name] = remap(resId)
+		}
+		p.Symbols = symbols
+	}
+
+	if p.Aliases != nil {
+		aliases := make(map[uint16]uint16, len(p.Aliases))
+		for aliasId, target := range p.Aliases {
+			aliases[remap(aliasId)] = remap(target)
+		}
+		p.Aliases = aliases
+	}
+
+	p.Layout = nil
+
+	return nil
+}
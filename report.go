@@ -0,0 +1,110 @@
+package pak
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Number of largest resources listed per type in a size report
+const reportLargest = 5
+
+// Sizes of a single resource
+type ResourceSize struct {
+	Id     uint16
+	Stored int64 // size in pak
+	Raw    int64 // size after decompression
+}
+
+// Sizes of resources of one content type
+type TypeStats struct {
+	Type    string // MIME type without parameters
+	Count   int
+	Stored  int64
+	Raw     int64
+	Largest []ResourceSize // largest by stored size, descending
+}
+
+// Summary of what takes up space in a pak
+type SizeReport struct {
+	Count   int   // number of stored resources
+	Aliases int   // number of aliases, taking no space
+	Stored  int64 // total stored size of resources
+	Raw     int64 // total size after decompression
+	Types   []TypeStats
+}
+
+// Summarizes sizes of stored resources per detected content type, largest
+// types first. Decompressed sizes are taken from compression headers, so
+// brotli resources are measured without a registered codec. Lazy resources
+// are loaded, failing if they cannot be read.
+func Report(p *PakFile) (*SizeReport, error) {
+	p, err := p.loaded()
+	if err != nil {
+		return nil, err
+	}
+
+	wp := p.plan(nil)
+	r := &SizeReport{Count: len(wp.order), Aliases: len(wp.aliases)}
+
+	byType := make(map[string]*TypeStats)
+	for _, resId := range wp.order {
+		resData := p.Resourses[resId]
+		rs := ResourceSize{Id: resId, Stored: int64(len(resData)), Raw: DecompressedSize(resData)}
+
+		t := sniffContentType(resData, p.Encoding)
+		if i := strings.IndexByte(t, ';'); i >= 0 {
+			t = t[:i]
+		}
+		ts, ok := byType[t]
+		if !ok {
+			ts = &TypeStats{Type: t}
+			byType[t] = ts
+		}
+		ts.Count++
+		ts.Stored += rs.Stored
+		ts.Raw += rs.Raw
+		ts.Largest = append(ts.Largest, rs)
+
+		r.Stored += rs.Stored
+		r.Raw += rs.Raw
+	}
+
+	for _, ts := range byType {
+		sort.Slice(ts.Largest, func(i, j int) bool {
+			a, b := ts.Largest[i], ts.Largest[j]
+			return a.Stored > b.Stored || a.Stored == b.Stored && a.Id < b.Id
+		})
+		if len(ts.Largest) > reportLargest {
+			ts.Largest = ts.Largest[:reportLargest]
+		}
+		r.Types = append(r.Types, *ts)
+	}
+	sort.Slice(r.Types, func(i, j int) bool {
+		a, b := r.Types[i], r.Types[j]
+		return a.Stored > b.Stored || a.Stored == b.Stored && a.Type < b.Type
+	})
+
+	return r, nil
+}
+
+// Formats report as a human readable table
+func (r *SizeReport) String() string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "type\tcount\tstored\traw\tlargest ids\n")
+	for _, ts := range r.Types {
+		ids := make([]string, len(ts.Largest))
+		for i, rs := range ts.Largest {
+			ids[i] = fmt.Sprintf("%d (%d)", rs.Id, rs.Stored)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", ts.Type, ts.Count, ts.Stored, ts.Raw, strings.Join(ids, ", "))
+	}
+	fmt.Fprintf(tw, "total\t%d\t%d\t%d\t%d aliases\n", r.Count, r.Stored, r.Raw, r.Aliases)
+	tw.Flush()
+
+	return buf.String()
+}
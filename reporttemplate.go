@@ -0,0 +1,78 @@
+package pak
+
+import "io"
+
+// Template executed by ReportTemplate, satisfied by *text/template.Template
+// and *html/template.Template
+type Template interface {
+	Execute(w io.Writer, data any) error
+}
+
+// Resource as seen by report templates
+type ReportEntry struct {
+	Id          uint16
+	Name        string // symbol name of resource, empty without symbol table
+	Alias       bool   // entry of version 5 alias table, sizes and type are zero
+	AliasOf     uint16
+	Stored      int64  // size in pak
+	Raw         int64  // size after decompression
+	Compression string // "none", "gzip" or "brotli"
+	Type        string // detected content type, e.g. "text/html; charset=utf-8"
+	Data        []byte // stored data, compressed as in pak
+}
+
+// Data passed to report templates. Entries are in ascending id order.
+//
+// A template listing resources larger than 100 KB:
+//
+//	{{range .Entries}}{{if gt .Stored 100000}}{{.Id}} {{.Name}} {{.Stored}}
+//	{{end}}{{end}}
+type ReportData struct {
+	Version  Version
+	Encoding Encoding
+	Summary  *SizeReport
+	Entries  []ReportEntry
+}
+
+// Returns report template data of pak. Lazy resources are loaded, failing if
+// they cannot be read.
+func NewReportData(p *PakFile) (*ReportData, error) {
+	p, err := p.loaded()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := Report(p)
+	if err != nil {
+		return nil, err
+	}
+	d := &ReportData{Version: p.Version, Encoding: p.Encoding, Summary: summary}
+
+	for _, resId := range sortedIds(p) {
+		resData := p.Resourses[resId]
+		e := ReportEntry{Id: resId, Name: p.Name(resId)}
+
+		if target, ok := p.Aliases[resId]; ok && p.Version == 5 {
+			e.Alias, e.AliasOf = true, target
+			d.Entries = append(d.Entries, e)
+			continue
+		}
+
+		e.Stored = int64(len(resData))
+		e.Raw = DecompressedSize(resData)
+		e.Compression = DetectCompression(resData).String()
+		e.Type = sniffContentType(resData, p.Encoding)
+		e.Data = resData
+		d.Entries = append(d.Entries, e)
+	}
+	return d, nil
+}
+
+// Executes template with ReportData of pak, for custom audit formats in text
+// or HTML
+func ReportTemplate(p *PakFile, tmpl Template, w io.Writer) error {
+	d, err := NewReportData(p)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, d)
+}
@@ -0,0 +1,347 @@
+package pak
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Range of ids GRIT allocates to one kind of resources of a .grd file
+type IDRange struct {
+	Grd   string // .grd path as written in resource_ids, relative to SRCDIR
+	Kind  string // "includes", "structures", "messages"...
+	Start uint16
+	Last  uint16 // inclusive
+}
+
+// Reports whether id falls into the range
+func (r IDRange) Contains(id uint16) bool {
+	return id >= r.Start && id <= r.Last
+}
+
+// Id allocations parsed from chromium's resource_ids file
+// (tools/gritsettings/resource_ids.spec)
+type ResourceIDs struct {
+	Ranges []IDRange // sorted by Start
+}
+
+// Reads GRIT resource_ids allocation file. Every allocated start id opens a
+// range that ends right before the next allocated start id.
+func ReadResourceIDs(r io.Reader) (*ResourceIDs, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := parsePyLiteral(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("error reading resource_ids: %v", err)
+	}
+	top, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("error reading resource_ids: top level is not a dict")
+	}
+
+	ids := &ResourceIDs{}
+	for grd, entry := range top {
+		kinds, ok := entry.(map[string]interface{})
+		if !ok || grd == "SRCDIR" {
+			continue
+		}
+		for kind, starts := range kinds {
+			list, ok := starts.([]interface{})
+			if !ok || kind == "META" {
+				continue
+			}
+			for _, start := range list {
+				n, ok := start.(int64)
+				if !ok || n < 0 || n > 0xffff {
+					return nil, fmt.Errorf("error reading resource_ids: %s %s: bad start id %v", grd, kind, start)
+				}
+				ids.Ranges = append(ids.Ranges, IDRange{Grd: grd, Kind: kind, Start: uint16(n)})
+			}
+		}
+	}
+
+	sort.Slice(ids.Ranges, func(i, j int) bool {
+		a, b := ids.Ranges[i], ids.Ranges[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		if a.Grd != b.Grd {
+			return a.Grd < b.Grd
+		}
+		return a.Kind < b.Kind
+	})
+
+	for i := range ids.Ranges {
+		ids.Ranges[i].Last = 0xffff
+		for j := i + 1; j < len(ids.Ranges); j++ {
+			if ids.Ranges[j].Start > ids.Ranges[i].Start {
+				ids.Ranges[i].Last = ids.Ranges[j].Start - 1
+				break
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// Reads GRIT resource_ids allocation file by name
+func ReadResourceIDsFile(name string) (*ResourceIDs, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadResourceIDs(f)
+}
+
+// Returns range allocated to kind of resources of .grd file, grd is matched
+// as a path suffix, so "browser_resources.grd" finds
+// "chrome/browser/browser_resources.grd".
+func (ids *ResourceIDs) Range(grd, kind string) (IDRange, bool) {
+	for _, r := range ids.Ranges {
+		if r.Kind == kind && ownedBy(r, []string{grd}) {
+			return r, true
+		}
+	}
+	return IDRange{}, false
+}
+
+// Returns range the id falls into
+func (ids *ResourceIDs) Owner(id uint16) (IDRange, bool) {
+	i := sort.Search(len(ids.Ranges), func(i int) bool { return ids.Ranges[i].Last >= id })
+	if i < len(ids.Ranges) && ids.Ranges[i].Contains(id) {
+		return ids.Ranges[i], true
+	}
+	return IDRange{}, false
+}
+
+// Resource outside ranges allocated to components of its pak
+type IDViolation struct {
+	Id    uint16
+	Name  string  // symbol name, empty without symbol table
+	Owner IDRange // range the id falls into, zero if none
+}
+
+// Result of checking a pak against resource_ids allocations
+type AllocationReport struct {
+	Outside    []IDViolation // ids of pak outside ranges of its components, ascending
+	Collisions [][]IDRange   // ranges of the pak's components sharing a start id with others
+}
+
+// Reports whether all ids are where allocations put them
+func (r *AllocationReport) OK() bool {
+	return len(r.Outside) == 0 && len(r.Collisions) == 0
+}
+
+// Returns ranges of different .grd files starting at the same id, so GRIT
+// hands out the same ids twice
+func (ids *ResourceIDs) Collisions() [][]IDRange {
+	var collisions [][]IDRange
+	for i := 0; i < len(ids.Ranges); {
+		j := i + 1
+		for j < len(ids.Ranges) && ids.Ranges[j].Start == ids.Ranges[i].Start {
+			j++
+		}
+		group := ids.Ranges[i:j]
+		for _, r := range group[1:] {
+			if r.Grd != group[0].Grd {
+				collisions = append(collisions, append([]IDRange(nil), group...))
+				break
+			}
+		}
+		i = j
+	}
+	return collisions
+}
+
+// Checks that every id of pak falls into a range allocated to one of the
+// .grd files it is built from, grds matched as in Range, and that none of
+// their ranges collide with others. An id owned by
+// another .grd collides with that component's resources and makes lookups
+// return the wrong asset at run time.
+func (ids *ResourceIDs) Check(p *PakFile, grds ...string) *AllocationReport {
+	r := &AllocationReport{}
+	for _, c := range ids.Collisions() {
+		for _, rg := range c {
+			if ownedBy(rg, grds) {
+				r.Collisions = append(r.Collisions, c)
+				break
+			}
+		}
+	}
+
+	for resId := range p.IDs() {
+		owner, ok := ids.Owner(resId)
+		if ok && ownedBy(owner, grds) {
+			continue
+		}
+		r.Outside = append(r.Outside, IDViolation{Id: resId, Name: p.Name(resId), Owner: owner})
+	}
+	return r
+}
+
+// Reports whether range belongs to one of .grd files
+func ownedBy(r IDRange, grds []string) bool {
+	for _, grd := range grds {
+		if r.Grd == grd || strings.HasSuffix(r.Grd, "/"+grd) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses python literal made of dicts, lists, strings, integers, True, False
+// and None, with comments and trailing commas, as used by GRIT config files.
+func parsePyLiteral(src string) (interface{}, error) {
+	p := &pyParser{src: src}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return v, nil
+}
+
+type pyParser struct {
+	src string
+	pos int
+}
+
+func (p *pyParser) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(p.src[:p.pos], "\n")
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *pyParser) skipSpace() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *pyParser) value() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		p.pos++
+		m := make(map[string]interface{})
+		for {
+			p.skipSpace()
+			if p.pos < len(p.src) && p.src[p.pos] == '}' {
+				p.pos++
+				return m, nil
+			}
+			k, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, p.errorf("dict key is not a string")
+			}
+			p.skipSpace()
+			if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+				return nil, p.errorf("expected ':'")
+			}
+			p.pos++
+			m[key], err = p.value()
+			if err != nil {
+				return nil, err
+			}
+			if !p.comma('}') {
+				return nil, p.errorf("expected ',' or '}'")
+			}
+		}
+
+	case c == '[' || c == '(':
+		end := byte(']')
+		if c == '(' {
+			end = ')'
+		}
+		p.pos++
+		var l []interface{}
+		for {
+			p.skipSpace()
+			if p.pos < len(p.src) && p.src[p.pos] == end {
+				p.pos++
+				return l, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+			if !p.comma(end) {
+				return nil, p.errorf("expected ',' or '%c'", end)
+			}
+		}
+
+	case c == '\'' || c == '"':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end < 0 {
+			return nil, p.errorf("unterminated string")
+		}
+		s := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return s, nil
+
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == 'x' || p.src[p.pos] >= 'a' && p.src[p.pos] <= 'f' || p.src[p.pos] >= 'A' && p.src[p.pos] <= 'F') {
+			p.pos++
+		}
+		n, err := strconv.ParseInt(p.src[start:p.pos], 0, 64)
+		if err != nil {
+			return nil, p.errorf("bad number %q", p.src[start:p.pos])
+		}
+		return n, nil
+	}
+
+	for _, kw := range []struct {
+		word  string
+		value interface{}
+	}{{"True", true}, {"False", false}, {"None", nil}} {
+		if strings.HasPrefix(p.src[p.pos:], kw.word) {
+			p.pos += len(kw.word)
+			return kw.value, nil
+		}
+	}
+
+	return nil, p.errorf("unexpected %q", p.src[p.pos])
+}
+
+// Consumes a comma or peeks the closing bracket, reports false otherwise
+func (p *pyParser) comma(end byte) bool {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return false
+	}
+	if p.src[p.pos] == ',' {
+		p.pos++
+		return true
+	}
+	return p.src[p.pos] == end
+}
@@ -0,0 +1,92 @@
+package pak
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Returns grit-style symbol for file at slash-separated path, e.g.
+// IDR_IMAGES_LOGO_PNG for images/logo.png
+func SymbolName(path string) string {
+	var b strings.Builder
+	b.WriteString("IDR_")
+	underscore := true
+	for _, c := range strings.ToUpper(path) {
+		if c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			b.WriteRune(c)
+			underscore = false
+		} else if !underscore {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// Scans directory of assets and proposes .grd including every file, for
+// packing with PackGrd. Files keep ids of their symbols in known, e.g. read
+// from an existing resources.h; other files get free ids counting up from
+// first, in path order. Hidden files and directories are skipped, as are
+// .grd and .h files and metadata. Returns the .grd and the symbol table
+// assigning its ids.
+func ScaffoldGrd(dir string, known SymbolTable, first uint16) (*Grd, SymbolTable, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(name)
+		if !d.Type().IsRegular() || ext == ".grd" || ext == ".h" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g := &Grd{}
+	symbols := make(SymbolTable, len(paths))
+	used := make(map[uint16]bool)
+	for _, resId := range known {
+		used[resId] = true
+	}
+
+	next := uint32(first)
+	for _, path := range paths {
+		name := SymbolName(path)
+		if _, ok := symbols[name]; ok {
+			return nil, nil, fmt.Errorf("error scaffolding %s: symbol %s already used by another file", path, name)
+		}
+
+		resId, ok := known[name]
+		if !ok {
+			for next <= math.MaxUint16 && used[uint16(next)] {
+				next++
+			}
+			if next > math.MaxUint16 {
+				return nil, nil, fmt.Errorf("error scaffolding %s: no free resource ids left", path)
+			}
+			resId = uint16(next)
+			used[resId] = true
+		}
+
+		symbols[name] = resId
+		g.Entries = append(g.Entries, GrdEntry{Name: name, Kind: "include", File: path, Type: "BINDATA"})
+	}
+	return g, symbols, nil
+}
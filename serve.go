@@ -0,0 +1,107 @@
+package pak
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var serveIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>pak</title></head>
+<body>
+<ul>
+{{range .}}<li><a href="/{{.}}">{{.}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// Serves resources over HTTP at /id, optionally followed by an extension,
+// e.g. /1234 or /1234.png, and a list of resources at /. Content type is
+// detected from data. Compressed resources are sent compressed with matching
+// Content-Encoding to clients accepting it, decompressed otherwise.
+func (s *Snapshot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" {
+		var names []string
+		for _, resId := range s.ids {
+			names = append(names, strconv.Itoa(int(resId))+GuessExtension(s.p.Resourses[resId], s.p.Encoding))
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		serveIndexTemplate.Execute(w, names)
+		return
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	resId, err := strconv.ParseUint(base, 10, 16)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data, ok := s.Get(uint16(resId))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if IsEncrypted(data) {
+		http.Error(w, "resource is encrypted", http.StatusForbidden)
+		return
+	}
+
+	c := DetectCompression(data)
+	raw, err := Decompress(data)
+	if err != nil && err != ErrBrotliUnsupported {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "application/octet-stream"
+	if err == nil {
+		contentType = sniffContentType(raw, s.p.Encoding)
+	}
+
+	body := raw
+	if c != CompressionNone {
+		w.Header().Add("Vary", "Accept-Encoding")
+		switch {
+		case c == CompressionGzip && acceptsEncoding(r, "gzip"):
+			w.Header().Set("Content-Encoding", "gzip")
+			body = data
+		case c == CompressionBrotli && acceptsEncoding(r, "br"):
+			w.Header().Set("Content-Encoding", "br")
+			body = data[brotliHeaderLength:]
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusNotAcceptable)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// Serves the current snapshot, see Snapshot.ServeHTTP. Responds with 503
+// Service Unavailable until a snapshot is stored.
+func (l *Live) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := l.Load()
+	if s == nil {
+		http.Error(w, "no pak loaded", http.StatusServiceUnavailable)
+		return
+	}
+	s.ServeHTTP(w, r)
+}
+
+// Reports whether request accepts content encoding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(name) == encoding && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
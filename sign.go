@@ -0,0 +1,68 @@
+package pak
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+)
+
+// Returned by Verify when signature does not match pak contents
+var ErrInvalidSignature = errors.New("pak: invalid signature")
+
+// Returns canonical serialization of pak contents, see Canonicalize. Layout
+// details such as order, padding and trailing data are not part of it.
+func canonicalBytes(p *PakFile) ([]byte, error) {
+	p, err := p.loaded()
+	if err != nil {
+		return nil, err
+	}
+
+	// Canonicalize replaces map entries only, data can be shared
+	c := &PakFile{Version: p.Version, Encoding: p.Encoding, Resourses: make(map[uint16][]byte, len(p.Resourses))}
+	for resId, resData := range p.Resourses {
+		c.Resourses[resId] = resData
+	}
+	err = Canonicalize(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = Write(&buf, c)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Returns detached ed25519 signature of pak contents. The signature covers
+// the canonical serialization, so it stays valid when the pak is rewritten
+// with a different layout and breaks when any resource, alias, version or
+// encoding changes.
+func Sign(p *PakFile, priv ed25519.PrivateKey) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("error signing pak: bad private key length %d", len(priv))
+	}
+	data, err := canonicalBytes(p)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.Sign(priv, data), nil
+}
+
+// Checks detached signature made by Sign, returning ErrInvalidSignature if it
+// does not match
+func Verify(p *PakFile, pub ed25519.PublicKey, sig []byte) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("error verifying pak: bad public key length %d", len(pub))
+	}
+	data, err := canonicalBytes(p)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
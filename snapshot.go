@@ -0,0 +1,94 @@
+package pak
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Read-only view of a pak, safe for concurrent use by multiple goroutines.
+// Data slices returned by Get are shared between callers and must not be
+// modified.
+type Snapshot struct {
+	p   *PakFile
+	ids []uint16 // sorted resource ids
+}
+
+// Returns an immutable snapshot of pak struct. The snapshot holds a deep copy,
+// so later changes to p are not visible through it. Lazy resources are loaded
+// into the copy.
+func (p *PakFile) Snapshot() (*Snapshot, error) {
+	l, err := p.loaded()
+	if err != nil {
+		return nil, err
+	}
+	c := l.Clone()
+	return &Snapshot{p: c, ids: sortedIds(c)}, nil
+}
+
+// Returns pak format version
+func (s *Snapshot) Version() Version {
+	return s.p.Version
+}
+
+// Returns text encoding declared by the pak
+func (s *Snapshot) Encoding() Encoding {
+	return s.p.Encoding
+}
+
+// Returns resource data, which must not be modified
+func (s *Snapshot) Get(id uint16) ([]byte, bool) {
+	data, ok := s.p.Resourses[id]
+	return data, ok
+}
+
+// Returns number of resources, aliases included
+func (s *Snapshot) Len() int {
+	return len(s.ids)
+}
+
+// Returns resource ids in ascending order
+func (s *Snapshot) IDs() []uint16 {
+	return append([]uint16(nil), s.ids...)
+}
+
+// Returns a modifiable deep copy of the snapshot contents
+func (s *Snapshot) PakFile() *PakFile {
+	return s.p.Clone()
+}
+
+// Writes snapshot to io.Writer in pak format
+func (s *Snapshot) Write(w io.Writer) error {
+	return Write(w, s.p)
+}
+
+// Holds the current snapshot of a pak that is replaced as a whole, e.g. by a
+// goroutine reloading it from disk, while others keep reading from it.
+// The zero value holds no snapshot and is ready to use.
+type Live struct {
+	cur atomic.Pointer[Snapshot]
+}
+
+// Returns the current snapshot, nil if none was stored yet
+func (l *Live) Load() *Snapshot {
+	return l.cur.Load()
+}
+
+// Replaces the current snapshot
+func (l *Live) Store(s *Snapshot) {
+	l.cur.Store(s)
+}
+
+// Reads pak file and makes it the current snapshot.
+// On error the previous snapshot stays in place.
+func (l *Live) ReloadFile(name string) error {
+	p, err := ReadFile(name)
+	if err != nil {
+		return err
+	}
+	s, err := p.Snapshot()
+	if err != nil {
+		return err
+	}
+	l.Store(s)
+	return nil
+}
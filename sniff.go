@@ -0,0 +1,136 @@
+package pak
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Detects MIME type of resource data, looking through compression wrappers
+// when possible. Brotli data without a registered codec is reported as
+// "application/x-brotli". Encoding is the declared pak encoding, text in
+// UTF-16 paks gets a utf-16le charset.
+func sniffContentType(data []byte, encoding Encoding) string {
+	c := DetectCompression(data)
+	if c != CompressionNone {
+		raw, err := Decompress(data)
+		if err != nil {
+			if c == CompressionBrotli {
+				return "application/x-brotli"
+			}
+			return "application/gzip"
+		}
+		data = raw
+	}
+
+	if len(data) == 0 {
+		return "application/octet-stream"
+	}
+
+	if isUTF16Text(data, encoding) {
+		t, _, _ := strings.Cut(sniffText(utf16ToUTF8(data)), ";")
+		return t + "; charset=utf-16le"
+	}
+
+	ct := http.DetectContentType(data)
+	if strings.HasPrefix(ct, "text/plain") {
+		return sniffText(data)
+	}
+	if ct == "application/octet-stream" && utf8.Valid(data) && !bytes.ContainsRune(data, 0) {
+		return sniffText(data)
+	}
+	return ct
+}
+
+// Refines plain text into common WebUI source types
+func sniffText(data []byte) string {
+	s := strings.TrimSpace(string(data[:min(len(data), 512)]))
+	s = strings.TrimPrefix(s, "\uFEFF")
+
+	switch {
+	case strings.HasPrefix(s, "<svg") || strings.HasPrefix(s, "<?xml") && strings.Contains(s, "<svg"):
+		return "image/svg+xml"
+	case strings.HasPrefix(s, "<!--") || strings.HasPrefix(s, "<link") || strings.HasPrefix(s, "<style") ||
+		strings.HasPrefix(s, "<template") || strings.HasPrefix(s, "<dom-module") || strings.HasPrefix(s, "<div"):
+		return "text/html; charset=utf-8"
+	case strings.HasPrefix(s, "{") || strings.HasPrefix(s, "["):
+		if jsonLike(data) {
+			return "application/json"
+		}
+	}
+
+	for _, prefix := range []string{"// ", "/*", "import ", "export ", "'use strict'", "\"use strict\"", "function ", "const ", "let ", "var ", "class ", "(function"} {
+		if strings.HasPrefix(s, prefix) {
+			if prefix == "/*" && looksLikeCSS(s) {
+				return "text/css; charset=utf-8"
+			}
+			return "text/javascript; charset=utf-8"
+		}
+	}
+	if looksLikeCSS(s) {
+		return "text/css; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func jsonLike(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 1 && (data[0] == '{' && data[len(data)-1] == '}' || data[0] == '[' && data[len(data)-1] == ']')
+}
+
+func looksLikeCSS(s string) bool {
+	i := strings.Index(s, "{")
+	if i < 0 {
+		return false
+	}
+	head := s[:i]
+	return strings.ContainsAny(head, ".#:@") && !strings.ContainsAny(head, "=();")
+}
+
+// Reports whether data looks like UTF-16LE text
+func isUTF16Text(data []byte, encoding Encoding) bool {
+	if len(data) < 2 || len(data)%2 != 0 {
+		return false
+	}
+	if bytes.HasPrefix(data, []byte{0xff, 0xfe}) {
+		return true
+	}
+	if encoding != EncodingUTF16 {
+		return false
+	}
+	// Mostly ASCII text has zero high bytes
+	zeros := 0
+	for i := 1; i < len(data); i += 2 {
+		if data[i] == 0 {
+			zeros++
+		}
+	}
+	return zeros*2 >= len(data)/2
+}
+
+// Converts UTF-16LE data to UTF-8
+func utf16ToUTF8(data []byte) []byte {
+	u := make([]uint16, len(data)/2)
+	for i := range u {
+		u[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+	}
+	if len(u) > 0 && u[0] == 0xfeff {
+		u = u[1:]
+	}
+	return []byte(string(utf16.Decode(u)))
+}
+
+// Returns MIME type of resource, detected with http.DetectContentType plus
+// pak specific heuristics: compressed data is looked into, WebUI text types
+// (JavaScript, CSS, JSON, SVG) are recognized and UTF-16 text is reported with
+// a utf-16le charset. Returns empty string for missing resources and lazy
+// ones that cannot be read.
+func (p *PakFile) ContentType(id uint16) string {
+	data, err := p.Load(id)
+	if err != nil {
+		return ""
+	}
+	return sniffContentType(data, p.Encoding)
+}
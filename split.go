@@ -0,0 +1,77 @@
+package pak
+
+// Partitions resources of pak into paks of the same version and encoding,
+// each at most max bytes long when written canonically, e.g. when the
+// resources are too large for one file, see ErrTooLarge. Resources are
+// distributed in ascending id order, aliases go with the resource they
+// point to. A resource too large to fit max on its own gets a pak of its own
+// exceeding max. Parts share resource data with p, lazy resources stay lazy.
+func SplitBySize(p *PakFile, max uint32) []*PakFile {
+	var headerLength uint64 = 4 + 4 + 1
+	if p.Version == 5 {
+		headerLength = 4 + 1 + 3 + 2 + 2
+	}
+
+	ids, err := p.ListIDs()
+	if err != nil {
+		return nil
+	}
+
+	// Aliases by target, an alias whose target is gone is a resource
+	aliases := make(map[uint16][]uint16)
+	var targets []uint16
+	for _, resId := range ids {
+		if target, ok := p.Aliases[resId]; ok && p.Has(target) {
+			aliases[target] = append(aliases[target], resId)
+			continue
+		}
+		targets = append(targets, resId)
+	}
+
+	var parts []*PakFile
+	var part *PakFile
+	var size uint64
+
+	for _, resId := range targets {
+		resSize, _ := p.SizeStored(resId)
+
+		// Index entry and data of resource, plus alias table entries of its
+		// aliases, or copies of it for version 4 which has no aliases
+		cost := 2 + 4 + uint64(resSize)
+		if p.Version == 5 {
+			cost += (2 + 2) * uint64(len(aliases[resId]))
+		} else {
+			cost += (2 + 4 + uint64(resSize)) * uint64(len(aliases[resId]))
+		}
+
+		if part == nil || size+cost > uint64(max) && size > headerLength+2+4 {
+			part = &PakFile{Version: p.Version, Encoding: p.Encoding, Resourses: make(map[uint16][]byte)}
+			parts = append(parts, part)
+			size = headerLength + 2 + 4 // header and terminator entry
+		}
+		size += cost
+
+		part.copyResource(p, resId, resId)
+		for _, aliasId := range aliases[resId] {
+			part.copyResource(p, aliasId, resId)
+			if part.Aliases == nil {
+				part.Aliases = make(map[uint16]uint16)
+			}
+			part.Aliases[aliasId] = resId
+		}
+	}
+
+	return parts
+}
+
+// Sets resource id to data or lazy placeholder of resource from of src
+func (p *PakFile) copyResource(src *PakFile, id, from uint16) {
+	if lr, ok := src.Lazy[from]; ok {
+		if p.Lazy == nil {
+			p.Lazy = make(map[uint16]*LazyResource)
+		}
+		p.Lazy[id] = lr
+		return
+	}
+	p.Resourses[id] = src.Resourses[from]
+}
@@ -0,0 +1,80 @@
+package pak
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Summary of a pak read from its header and index bounds
+type Info struct {
+	Version   Version
+	Encoding  Encoding
+	Resources int   // number of stored resources
+	Aliases   int   // number of aliases (version 5)
+	IndexSize int64 // size of index and alias table in bytes
+	DataSize  int64 // size of resource data in bytes, padding excluded
+}
+
+// Reads summary of a pak without parsing it fully: only the header, the first
+// and the terminator index entries are decoded. Entries in between are skipped
+// by seeking if r implements io.Seeker, otherwise they are read and discarded.
+func Stat(r io.Reader) (Info, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return Info{}, err
+	}
+	if h.version != 4 && h.version != 5 {
+		return Info{}, fmt.Errorf("error reading pak: unsupported version %d", h.version)
+	}
+
+	info := Info{
+		Version:   Version(h.version),
+		Encoding:  Encoding(h.encoding),
+		Resources: int(h.resources),
+		Aliases:   int(h.aliases),
+		IndexSize: int64(h.indexEnd() - h.length()),
+	}
+
+	first, err := readResourceInfo(r)
+	if err != nil {
+		return Info{}, err
+	}
+	last := first
+
+	if h.resources > 0 {
+		skip := int64(h.resources-1) * (2 + 4)
+		if s, ok := r.(io.Seeker); ok {
+			_, err = s.Seek(skip, io.SeekCurrent)
+		} else {
+			_, err = io.CopyN(io.Discard, r, skip)
+		}
+		if err != nil {
+			return Info{}, err
+		}
+		last, err = readResourceInfo(r)
+		if err != nil {
+			return Info{}, err
+		}
+	}
+
+	if last.id != 0 {
+		return Info{}, fmt.Errorf("error reading resources: last id != 0")
+	}
+	if last.offset < first.offset {
+		return Info{}, fmt.Errorf("error reading resources: offsets are not ascending")
+	}
+	info.DataSize = int64(last.offset - first.offset)
+
+	return info, nil
+}
+
+// Reads summary of pak file, see Stat
+func StatFile(name string) (Info, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+	return Stat(f)
+}
@@ -0,0 +1,224 @@
+package pak
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Storage resources can be read from. PakFile, Reader and DirStore are
+// sources.
+type Source interface {
+	// Returns ids of stored resources in ascending order
+	ListIDs() ([]uint16, error)
+	// Opens resource data as stored for reading
+	Open(id uint16) (io.ReadCloser, error)
+}
+
+// Storage resources can be written to. PakFile and DirStore are sinks.
+type Sink interface {
+	// Creates or replaces resource, data is committed when the writer is
+	// closed
+	Put(id uint16) (io.WriteCloser, error)
+}
+
+// Copies every resource of src to dst
+func Convert(dst Sink, src Source) error {
+	ids, err := src.ListIDs()
+	if err != nil {
+		return err
+	}
+
+	for _, resId := range ids {
+		err = convertResource(dst, src, resId)
+		if err != nil {
+			return fmt.Errorf("error converting resource id=%d: %v", resId, err)
+		}
+	}
+	return nil
+}
+
+func convertResource(dst Sink, src Source, id uint16) error {
+	rc, err := src.Open(id)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	wc, err := dst.Put(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(wc, rc)
+	if err != nil {
+		wc.Close()
+		return err
+	}
+	return wc.Close()
+}
+
+// Returns resource ids in ascending order, aliases and lazy resources included
+func (p *PakFile) ListIDs() ([]uint16, error) {
+	ids := sortedIds(p)
+	for resId := range p.Lazy {
+		ids = append(ids, resId)
+	}
+	sortIds(ids)
+	return ids, nil
+}
+
+// Opens resource data for reading, see Load
+func (p *PakFile) Open(id uint16) (io.ReadCloser, error) {
+	data, err := p.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Returns writer setting resource data when closed, see Set
+func (p *PakFile) Put(id uint16) (io.WriteCloser, error) {
+	if id == 0 {
+		return nil, ErrReservedID
+	}
+	return &putWriter{commit: func(data []byte) error { return p.Set(id, data) }}, nil
+}
+
+// Buffers data written to it, handing it over on Close
+type putWriter struct {
+	bytes.Buffer
+	commit func(data []byte) error
+}
+
+func (pw *putWriter) Close() error {
+	return pw.commit(pw.Bytes())
+}
+
+// Returns resource ids in ascending order, aliases included
+func (pr *Reader) ListIDs() ([]uint16, error) {
+	return pr.IDs(), nil
+}
+
+// Opens resource data for reading, reading from the underlying pak on demand
+func (pr *Reader) Open(id uint16) (io.ReadCloser, error) {
+	info, ok := pr.infos[id]
+	if !ok {
+		return nil, fmt.Errorf("resource id=%d not found", id)
+	}
+	return io.NopCloser(io.NewSectionReader(pr.r, info.Offset, info.Length)), nil
+}
+
+// Directory of files named by resource id, the layout written by ExtractDir
+// and read by PackDir. Files may have an extension, new files are written
+// without one. The directory is listed once on first use, files added to it
+// by others afterwards are not seen.
+type DirStore struct {
+	Dir string
+
+	mu    sync.Mutex
+	names map[uint16]string // file name by id, nil until listed
+}
+
+// Returns ids of files in directory in ascending order, hidden files and
+// subdirectories are skipped
+func (d *DirStore) ListIDs() ([]uint16, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint16
+	seen := make(map[uint16]bool)
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		resId, err := idFromFileName(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		if seen[resId] {
+			return nil, fmt.Errorf("error listing %s: more than one file for resource id=%d", d.Dir, resId)
+		}
+		seen[resId] = true
+		ids = append(ids, resId)
+	}
+	sortIds(ids)
+	return ids, nil
+}
+
+// Opens file of resource
+func (d *DirStore) Open(id uint16) (io.ReadCloser, error) {
+	name, err := d.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+// Creates file of resource atomically, replacing any file of the same id
+func (d *DirStore) Put(id uint16) (io.WriteCloser, error) {
+	if id == 0 {
+		return nil, ErrReservedID
+	}
+	return &putWriter{commit: func(data []byte) error {
+		err := os.MkdirAll(d.Dir, 0755)
+		if err != nil {
+			return err
+		}
+		old, _ := d.find(id)
+		name := filepath.Join(d.Dir, strconv.Itoa(int(id)))
+		err = writeFileAtomic(name, func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		d.mu.Lock()
+		if d.names != nil {
+			d.names[id] = filepath.Base(name)
+		}
+		d.mu.Unlock()
+		if old == "" || old == name {
+			return nil
+		}
+		return os.Remove(old)
+	}}, nil
+}
+
+// Returns path of file of resource, with or without extension
+func (d *DirStore) find(id uint16) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.names == nil {
+		entries, err := os.ReadDir(d.Dir)
+		if err != nil {
+			return "", err
+		}
+		d.names = make(map[uint16]string, len(entries))
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if resId, err := idFromFileName(e.Name()); err == nil {
+				if _, ok := d.names[resId]; !ok {
+					d.names[resId] = e.Name()
+				}
+			}
+		}
+	}
+
+	name, ok := d.names[id]
+	if !ok {
+		return "", fmt.Errorf("resource id=%d not found in %s", id, d.Dir)
+	}
+	return filepath.Join(d.Dir, name), nil
+}
@@ -0,0 +1,243 @@
+package pak
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resource ids by symbolic name, e.g. IDR_NEW_TAB_PAGE_HTML
+type SymbolTable map[string]uint16
+
+// Reads symbol table from header generated by grit, taking every
+// "#define NAME id" line. Plain "NAME id" lines are accepted too, empty lines
+// and other preprocessor or comment lines are ignored.
+func ReadSymbols(r io.Reader) (SymbolTable, error) {
+	t := make(SymbolTable)
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 3 && fields[0] == "#define" {
+			fields = fields[1:]
+		}
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+
+		resId, err := strconv.ParseUint(fields[1], 10, 16)
+		if err != nil {
+			continue // not a resource id, e.g. an include guard
+		}
+		if prev, ok := t[fields[0]]; ok && prev != uint16(resId) {
+			return nil, fmt.Errorf("error reading symbols line %d: %s defined as both %d and %d", n, fields[0], prev, resId)
+		}
+		t[fields[0]] = uint16(resId)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Reads symbol table from file, see ReadSymbols
+func ReadSymbolsFile(name string) (SymbolTable, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSymbols(f)
+}
+
+// Writes symbol table as "#define NAME id" lines in ascending id order,
+// readable by ReadSymbols
+func WriteSymbols(w io.Writer, t SymbolTable) error {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if t[names[i]] != t[names[j]] {
+			return t[names[i]] < t[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fmt.Fprintf(bw, "#define %s %d\n", name, t[name])
+	}
+	return bw.Flush()
+}
+
+// Returns id -> name mapping, for ids with several names the least one
+// alphabetically is used
+func (t SymbolTable) Names() map[uint16]string {
+	names := make(map[uint16]string, len(t))
+	for name, resId := range t {
+		if prev, ok := names[resId]; !ok || name < prev {
+			names[resId] = name
+		}
+	}
+	return names
+}
+
+// Id of one symbol in two numberings
+type Translation struct {
+	Name string
+	Old  uint16
+	New  uint16 // zero if the resource no longer exists
+}
+
+// Translations of ids between milestones, sorted by old id
+type TranslationTable []Translation
+
+// Returns table translating ids of symbols in from to ids of the same
+// symbols in to
+func NewTranslationTable(from, to SymbolTable) TranslationTable {
+	var t TranslationTable
+	for name, oldId := range from {
+		t = append(t, Translation{Name: name, Old: oldId, New: to[name]})
+	}
+	t.sort()
+	return t
+}
+
+func (t TranslationTable) sort() {
+	sort.Slice(t, func(i, j int) bool {
+		return t[i].Old < t[j].Old || t[i].Old == t[j].Old && t[i].Name < t[j].Name
+	})
+}
+
+// Writes table as text, one "NAME old new" line per symbol, "-" standing
+// for the new id of removed resources
+func WriteTranslationTable(w io.Writer, t TranslationTable) error {
+	bw := bufio.NewWriter(w)
+	for _, tr := range t {
+		newId := "-"
+		if tr.New != 0 {
+			newId = strconv.Itoa(int(tr.New))
+		}
+		fmt.Fprintf(bw, "%s %d %s\n", tr.Name, tr.Old, newId)
+	}
+	return bw.Flush()
+}
+
+// Reads table written by WriteTranslationTable. Empty lines and lines
+// starting with # are ignored.
+func ReadTranslationTable(r io.Reader) (TranslationTable, error) {
+	var t TranslationTable
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("error reading translation table line %d: expected name, old and new id", n)
+		}
+		oldId, err := strconv.ParseUint(fields[1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("error reading translation table line %d: bad id %q", n, fields[1])
+		}
+		var newId uint64
+		if fields[2] != "-" {
+			newId, err = strconv.ParseUint(fields[2], 10, 16)
+			if err != nil || newId == 0 {
+				return nil, fmt.Errorf("error reading translation table line %d: bad id %q", n, fields[2])
+			}
+		}
+		t = append(t, Translation{Name: fields[0], Old: uint16(oldId), New: uint16(newId)})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	t.sort()
+	return t, nil
+}
+
+// Reads translation table from file
+func ReadTranslationTableFile(name string) (TranslationTable, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTranslationTable(f)
+}
+
+// Outcome of migrating a pak between numberings, ids in ascending order
+type MigrationReport struct {
+	Moved   int      // resources whose id changed
+	Removed []uint16 // old ids of resources that no longer exist, dropped from pak
+	Unknown []uint16 // ids missing from the table, kept as they are
+}
+
+// Renumbers resources of pak from one milestone's ids to another's using
+// translation table. Resources removed in the new numbering are dropped and
+// resources missing from the table keep their ids. Fails without changing p
+// if the table is ambiguous or two resources end up with the same id.
+func Migrate(p *PakFile, t TranslationTable) (*MigrationReport, error) {
+	targets := make(map[uint16]uint16, len(t))
+	for _, tr := range t {
+		if prev, ok := targets[tr.Old]; ok && prev != tr.New {
+			return nil, fmt.Errorf("error migrating: id %d translates to both %d and %d", tr.Old, prev, tr.New)
+		}
+		targets[tr.Old] = tr.New
+	}
+
+	r := &MigrationReport{}
+	mapping := make(map[uint16]uint16)
+	ids, _ := p.ListIDs()
+	for _, resId := range ids {
+		newId, ok := targets[resId]
+		switch {
+		case !ok:
+			r.Unknown = append(r.Unknown, resId)
+		case newId == 0:
+			r.Removed = append(r.Removed, resId)
+		case newId != resId:
+			mapping[resId] = newId
+			r.Moved++
+		}
+	}
+
+	// Check collisions on a copy sharing data, so p stays intact on error
+	c := &PakFile{Version: p.Version, Encoding: p.Encoding, Resourses: make(map[uint16][]byte, len(p.Resourses))}
+	for resId, resData := range p.Resourses {
+		c.Resourses[resId] = resData
+	}
+	if p.Aliases != nil {
+		c.Aliases = make(map[uint16]uint16, len(p.Aliases))
+		for aliasId, target := range p.Aliases {
+			c.Aliases[aliasId] = target
+		}
+	}
+	if p.Lazy != nil {
+		c.Lazy = make(map[uint16]*LazyResource, len(p.Lazy))
+		for resId, lr := range p.Lazy {
+			c.Lazy[resId] = lr
+		}
+	}
+	c.Symbols = p.Symbols
+	for _, resId := range r.Removed {
+		c.Delete(resId)
+	}
+	err := Remap(c, mapping)
+	if err != nil {
+		return nil, fmt.Errorf("error migrating: %v", err)
+	}
+
+	*p = *c
+	return r, nil
+}
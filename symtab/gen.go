@@ -0,0 +1,120 @@
+//go:build ignore
+
+// Generates a symbol table from the grit headers of a Chromium or CEF build.
+//
+//	go run gen.go -src out/Release/gen -o tables [-name cef-120]
+//
+// Every header under src carrying the grit banner is read, the merged table
+// is written to <o>/<name>.h. Without -name the chromium milestone is taken
+// from chrome/VERSION of the checkout the gen directory belongs to.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/pak"
+)
+
+// Banner of headers generated by grit
+const gritBanner = "automatically generated by GRIT"
+
+func main() {
+	src := flag.String("src", "", "gen `dir` of a release build")
+	out := flag.String("o", "tables", "output `dir`")
+	name := flag.String("name", "", "table `name`, e.g. cef-120 (default chromium-<MAJOR> from chrome/VERSION)")
+	flag.Parse()
+	log.SetFlags(0)
+
+	if *src == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *name == "" {
+		major, err := readMajor(filepath.Join(*src, "..", "..", "..", "chrome", "VERSION"))
+		if err != nil {
+			log.Fatalf("no -name given and %v", err)
+		}
+		*name = "chromium-" + major
+	}
+
+	t := make(pak.SymbolTable)
+	headers := 0
+	err := filepath.WalkDir(*src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".h") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(data), gritBanner) {
+			return err
+		}
+		symbols, err := pak.ReadSymbols(strings.NewReader(string(data)))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		for sym, resId := range symbols {
+			if prev, ok := t[sym]; ok && prev != resId {
+				log.Printf("%s: %s defined as both %d and %d, keeping %d", path, sym, prev, resId, prev)
+				continue
+			}
+			t[sym] = resId
+		}
+		headers++
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if headers == 0 {
+		log.Fatalf("no grit headers found in %s", *src)
+	}
+
+	err = os.MkdirAll(*out, 0755)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(*out, *name+".h"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = writeTable(f, *name, t)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%s: %d symbols from %d headers", *name, len(t), headers)
+}
+
+// Writes table preceded by a comment naming it
+func writeTable(w io.Writer, name string, t pak.SymbolTable) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "// Resource ids of %s, generated by symtab/gen.go from grit headers.\n", name)
+	err := pak.WriteSymbols(bw, t)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Returns MAJOR of chrome/VERSION file
+func readMajor(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if major, ok := strings.CutPrefix(strings.TrimSpace(line), "MAJOR="); ok {
+			return major, nil
+		}
+	}
+	return "", fmt.Errorf("no MAJOR in %s", name)
+}
@@ -0,0 +1,133 @@
+// Package symtab bundles symbol tables of resource ids generated from grit
+// headers of Chromium and CEF releases, so paks of a known browser version
+// can be read with resource names without its build output at hand.
+//
+// Tables are named by product and milestone, e.g. "chromium-120" or
+// "cef-120", and stored in the tables directory in the format written by
+// pak.WriteSymbols. They are regenerated with
+//
+//	go generate github.com/disintegration/pak/symtab
+//
+// after setting $CHROMIUM_GEN to the gen directory of a release build, see
+// gen.go. Programs with tables of their own, e.g. of a CEF based product,
+// add them with Register.
+//
+// No tables are bundled yet, see tables/README.md: until generated ones are
+// added Load returns ErrNoTable for every version that was not registered.
+package symtab
+
+//go:generate go run gen.go -src $CHROMIUM_GEN -o tables
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/pak"
+)
+
+//go:embed tables
+var tables embed.FS
+
+// Returned by Load when no table is bundled for a version
+var ErrNoTable = errors.New("symtab: no symbol table bundled for version")
+
+// Product of tables without an explicit one
+const defaultProduct = "chromium"
+
+var registered struct {
+	sync.RWMutex
+	tables map[string]pak.SymbolTable
+}
+
+// Adds table of version, given as for Load, taking precedence over the
+// bundled one
+func Register(version string, t pak.SymbolTable) error {
+	name, err := Name(version)
+	if err != nil {
+		return err
+	}
+	registered.Lock()
+	if registered.tables == nil {
+		registered.tables = make(map[string]pak.SymbolTable)
+	}
+	registered.tables[name] = t
+	registered.Unlock()
+	return nil
+}
+
+// Returns names of bundled and registered tables sorted by product and
+// milestone
+func Versions() []string {
+	entries, _ := fs.ReadDir(tables, "tables")
+	seen := make(map[string]bool)
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".h"); ok {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	registered.RLock()
+	for name := range registered.tables {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	registered.RUnlock()
+	sort.Slice(names, func(i, j int) bool {
+		pi, mi := splitVersion(names[i])
+		pj, mj := splitVersion(names[j])
+		return pi < pj || pi == pj && mi < mj
+	})
+	return names
+}
+
+// Returns registered or bundled table of version given as table name, e.g.
+// "cef-120", or as full version string, e.g. "120.0.6099.109" or
+// "cef-120.1.8+ge6b45b0", of which only the milestone counts. Versions
+// without a product are taken for chromium ones.
+func Load(version string) (pak.SymbolTable, error) {
+	name, err := Name(version)
+	if err != nil {
+		return nil, err
+	}
+	registered.RLock()
+	t, ok := registered.tables[name]
+	registered.RUnlock()
+	if ok {
+		return t, nil
+	}
+	f, err := tables.Open("tables/" + name + ".h")
+	if err != nil {
+		return nil, fmt.Errorf("%w %s", ErrNoTable, version)
+	}
+	defer f.Close()
+	return pak.ReadSymbols(f)
+}
+
+// Returns table name for version, e.g. "chromium-120" for "120.0.6099.109"
+func Name(version string) (string, error) {
+	product, rest := defaultProduct, version
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		product, rest = version[:i], version[i+1:]
+	}
+	milestone, _, _ := strings.Cut(rest, ".")
+	n, err := strconv.Atoi(milestone)
+	if product == "" || err != nil || n <= 0 {
+		return "", fmt.Errorf("symtab: bad version %q", version)
+	}
+	return product + "-" + strconv.Itoa(n), nil
+}
+
+// Splits table name into product and milestone
+func splitVersion(name string) (string, int) {
+	product, milestone, _ := strings.Cut(name, "-")
+	n, _ := strconv.Atoi(milestone)
+	return product, n
+}
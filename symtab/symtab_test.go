@@ -0,0 +1,73 @@
+package symtab_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/disintegration/pak"
+	"github.com/disintegration/pak/symtab"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		version string
+		name    string // "" for error
+	}{
+		{"chromium-120", "chromium-120"},
+		{"cef-120", "cef-120"},
+		{"120.0.6099.109", "chromium-120"},
+		{"cef-120.1.8+ge6b45b0", "cef-120"},
+		{"120", "chromium-120"},
+		{"", ""},
+		{"cef-", ""},
+		{"-120", ""},
+		{"chromium-0", ""},
+		{"latest", ""},
+	}
+
+	for _, tt := range tests {
+		name, err := symtab.Name(tt.version)
+		if tt.name == "" && err == nil || tt.name != "" && name != tt.name {
+			t.Errorf("Name(%q) = %q, %v, want %q", tt.version, name, err, tt.name)
+		}
+	}
+}
+
+func TestBundled(t *testing.T) {
+	for _, version := range symtab.Versions() {
+		table, err := symtab.Load(version)
+		if err != nil {
+			t.Errorf("bundled table %s: %v", version, err)
+		} else if len(table) == 0 {
+			t.Errorf("bundled table %s is empty", version)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	_, err := symtab.Load("cef-9999")
+	if !errors.Is(err, symtab.ErrNoTable) {
+		t.Fatalf("Load of missing table error = %v, want ErrNoTable", err)
+	}
+
+	want := pak.SymbolTable{"IDR_CEF_INDEX": 100, "IDS_CEF_TITLE": 101}
+	err = symtab.Register("cef-9999.1.0", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := symtab.Load("cef-9999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != len(want) || table["IDR_CEF_INDEX"] != 100 {
+		t.Errorf("Load = %v, want %v", table, want)
+	}
+	if !slices.Contains(symtab.Versions(), "cef-9999") {
+		t.Errorf("Versions = %v, want cef-9999 listed", symtab.Versions())
+	}
+
+	if symtab.Register("bad", want) == nil {
+		t.Error("Register of bad version succeeded")
+	}
+}
@@ -0,0 +1,139 @@
+package pak
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Rewrites resource data while a pak is written, e.g. to minify scripts,
+// inject license headers or optimize images
+type Transformer interface {
+	Transform(id uint16, data []byte) ([]byte, error)
+}
+
+// Adapts an ordinary function to Transformer
+type TransformFunc func(id uint16, data []byte) ([]byte, error)
+
+func (f TransformFunc) Transform(id uint16, data []byte) ([]byte, error) {
+	return f(id, data)
+}
+
+// Returns shallow copy of pak struct with transformers applied in order to the
+// data of every resource. Aliases get the transformed data of their targets,
+// so each distinct resource is transformed once. Input data is not modified
+// by this function, transformers must not modify it either.
+func (p *PakFile) transformed(ts []Transformer) (*PakFile, error) {
+	wp := p.plan(nil)
+
+	t := &PakFile{
+		Version:   p.Version,
+		Encoding:  p.Encoding,
+		Resourses: make(map[uint16][]byte, len(p.Resourses)),
+		Aliases:   p.Aliases,
+		Layout:    p.Layout,
+	}
+
+	for _, resId := range wp.order {
+		data := p.Resourses[resId]
+		for _, tr := range ts {
+			var err error
+			data, err = tr.Transform(resId, data)
+			if err != nil {
+				return nil, fmt.Errorf("error transforming resource id=%d: %v", resId, err)
+			}
+		}
+		t.Resourses[resId] = data
+	}
+
+	for _, ai := range wp.aliases {
+		t.Resourses[ai.id] = t.Resourses[wp.order[ai.index]]
+	}
+
+	return t, nil
+}
+
+// Returns transformer replacing ${NAME} placeholders in text resources (HTML,
+// CSS, JavaScript, JSON, SVG and plain text) with values from vars, so one
+// source tree can produce branded variants of the same pak. Placeholders with
+// names missing from vars are left as is, which keeps JavaScript template
+// literals intact. Compressed resources are decompressed, substituted and
+// compressed again with the same compression. UTF-16 text is not changed,
+// nor are encrypted resources and brotli ones without a registered codec.
+// Other resources that fail to decompress, e.g. truncated gzip, are errors.
+func Substitute(vars map[string]string) Transformer {
+	return TransformFunc(func(id uint16, data []byte) ([]byte, error) {
+		if IsEncrypted(data) {
+			return data, nil
+		}
+		c := DetectCompression(data)
+		raw, err := Decompress(data)
+		if errors.Is(err, ErrBrotliUnsupported) {
+			return data, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing resource id=%d: %w", id, err)
+		}
+		if !substitutable(raw) {
+			return data, nil
+		}
+
+		out, changed := substitute(raw, vars)
+		if !changed {
+			return data, nil
+		}
+		return Compress(out, c)
+	})
+}
+
+// Reports whether data is text that placeholders may be substituted in
+func substitutable(data []byte) bool {
+	t := sniffContentType(data, EncodingUTF8)
+	return strings.HasPrefix(t, "text/") || t == "application/json" || t == "image/svg+xml"
+}
+
+// Replaces ${NAME} placeholders with values of known variables
+func substitute(data []byte, vars map[string]string) ([]byte, bool) {
+	var out []byte
+	last := 0
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] != '$' || data[i+1] != '{' {
+			continue
+		}
+		end := bytes.IndexByte(data[i+2:], '}')
+		if end < 0 {
+			break
+		}
+		name := string(data[i+2 : i+2+end])
+		value, ok := vars[name]
+		if !ok || !validVarName(name) {
+			continue
+		}
+		out = append(out, data[last:i]...)
+		out = append(out, value...)
+		last = i + 2 + end + 1
+		i = last - 1
+	}
+	if last == 0 {
+		return data, false
+	}
+	return append(out, data[last:]...), true
+}
+
+// Reports whether name is a valid placeholder name: letters, digits and
+// underscores, not starting with a digit
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package pak_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/pak"
+)
+
+func TestSubstitute(t *testing.T) {
+	gzipped, err := pak.Compress([]byte("<p>${NAME}</p>"), pak.CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Brotli header followed by a stream no codec is registered to decode
+	brotli := []byte{0x1e, 0x9b, 0x0e, 0, 0, 0, 0, 0, 0x8b, 0x06, 0x80, '$', '{', 'N', 'A', 'M', 'E', '}'}
+	encrypted, err := pak.EncryptResource(1, []byte("<p>${NAME}</p>"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"text", []byte("<p>${NAME}</p>"), []byte("<p>Chromium</p>")},
+		{"unknown variable", []byte("<p>${OTHER}</p>"), []byte("<p>${OTHER}</p>")},
+		{"gzip", gzipped, []byte("<p>Chromium</p>")},
+		{"brotli", brotli, brotli},
+		{"encrypted", encrypted, encrypted},
+	}
+
+	s := pak.Substitute(map[string]string{"NAME": "Chromium"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Transform(1, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c := pak.DetectCompression(tt.data); c == pak.CompressionGzip {
+				if pak.DetectCompression(got) != c {
+					t.Fatalf("Transform changed compression to %s", pak.DetectCompression(got))
+				}
+				got, err = pak.Decompress(got)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Transform = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstituteCorrupt(t *testing.T) {
+	gzipped, err := pak.Compress([]byte("<p>${NAME}</p>"), pak.CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := pak.Substitute(map[string]string{"NAME": "Chromium"})
+	_, err = s.Transform(7, gzipped[:len(gzipped)-4])
+	if err == nil || !strings.Contains(err.Error(), "resource id=7") {
+		t.Errorf("Transform of truncated gzip: error %v, want error naming resource id=7", err)
+	}
+}
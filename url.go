@@ -0,0 +1,66 @@
+package pak
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Resolves chrome:// URLs, as seen in DevTools, to the ids of resources
+// backing them
+type URLResolver struct {
+	Hosts map[string]map[string]uint16 // resource ids by path, by WebUI host
+}
+
+// Returns resolver with no hosts
+func NewURLResolver() *URLResolver {
+	return &URLResolver{Hosts: make(map[string]map[string]uint16)}
+}
+
+// Adds files of .grd as served by WebUI host, e.g. "settings", or
+// "resources" for the shared chrome://resources. Paths follow WebUI data
+// sources: resource_path of the entry or its file relative to the .grd.
+func (r *URLResolver) AddGrd(host string, g *Grd, symbols SymbolTable) {
+	paths := r.Hosts[host]
+	if paths == nil {
+		paths = make(map[string]uint16)
+		r.Hosts[host] = paths
+	}
+	for _, e := range g.Entries {
+		resId, ok := symbols[e.Name]
+		if ok && e.File != "" {
+			paths[e.servedPath()] = resId
+		}
+	}
+}
+
+// Returns id of resource served at chrome:// or chrome-untrusted:// URL.
+// Query and fragment are ignored. As in WebUI data sources, the root path
+// serves index.html, or <host>.html where that is how the page is named.
+func (r *URLResolver) ResolveURL(rawURL string) (uint16, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, fmt.Errorf("error resolving %s: %v", rawURL, err)
+	}
+	if u.Scheme != "chrome" && u.Scheme != "chrome-untrusted" {
+		return 0, fmt.Errorf("error resolving %s: not a chrome:// URL", rawURL)
+	}
+
+	paths, ok := r.Hosts[u.Host]
+	if !ok {
+		return 0, fmt.Errorf("error resolving %s: unknown host %s", rawURL, u.Host)
+	}
+
+	p := strings.TrimPrefix(path.Clean("/"+u.Path), "/")
+	candidates := []string{p}
+	if p == "" {
+		candidates = []string{"index.html", u.Host + ".html"}
+	}
+	for _, c := range candidates {
+		if resId, ok := paths[c]; ok {
+			return resId, nil
+		}
+	}
+	return 0, fmt.Errorf("error resolving %s: no resource at %s", rawURL, u.Host+"/"+p)
+}
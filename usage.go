@@ -0,0 +1,255 @@
+package pak
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Wrapper of pak recording which resources are accessed, e.g. during a test
+// run of the app using it, to ship paks holding only those. Safe for
+// concurrent use as long as the pak is not modified.
+//
+// Tracker is an fs.FS: files are named by resource id or, with a symbol
+// table attached to the pak, by symbol name, and all sit in the root
+// directory, which lists them by id.
+type Tracker struct {
+	p    *PakFile
+	mu   sync.Mutex
+	used map[uint16]bool
+}
+
+// Returns tracker of pak with no resources used yet
+func NewTracker(p *PakFile) *Tracker {
+	return &Tracker{p: p, used: make(map[uint16]bool)}
+}
+
+// Returns resource data like PakFile.Get, recording the id as used
+func (t *Tracker) Get(id uint16) ([]byte, bool) {
+	data, ok := t.p.Get(id)
+	if ok {
+		t.use(id)
+	}
+	return data, ok
+}
+
+// Returns resource data by symbol name like PakFile.GetByName, recording the
+// id as used
+func (t *Tracker) GetByName(name string) ([]byte, bool) {
+	resId, ok := t.p.Symbols[name]
+	if !ok {
+		return nil, false
+	}
+	return t.Get(resId)
+}
+
+func (t *Tracker) use(id uint16) {
+	t.mu.Lock()
+	t.used[id] = true
+	t.mu.Unlock()
+}
+
+// Returns ids of resources used so far in ascending order
+func (t *Tracker) Used() []uint16 {
+	t.mu.Lock()
+	ids := make([]uint16, 0, len(t.used))
+	for resId := range t.used {
+		ids = append(ids, resId)
+	}
+	t.mu.Unlock()
+	sortIds(ids)
+	return ids
+}
+
+// Returns ids of resources of pak not used so far in ascending order
+func (t *Tracker) Unused() []uint16 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ids []uint16
+	for resId := range t.p.IDs() {
+		if !t.used[resId] {
+			ids = append(ids, resId)
+		}
+	}
+	return ids
+}
+
+// Forgets resources used so far
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	clear(t.used)
+	t.mu.Unlock()
+}
+
+// Writes whitelist of used resources, see WriteWhitelist
+func (t *Tracker) WriteWhitelist(w io.Writer) error {
+	return WriteWhitelist(w, t.Used(), t.p.Symbols.Names())
+}
+
+// Opens resource named by id or symbol name, recording it as used. Opening
+// the root directory "." records nothing.
+func (t *Tracker) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		ids, _ := t.p.ListIDs()
+		d := &trackerDir{}
+		for _, resId := range ids {
+			size, _ := t.p.SizeStored(resId)
+			d.files = append(d.files, resourceInfoFS{name: strconv.Itoa(int(resId)), size: size})
+		}
+		return d, nil
+	}
+
+	resId, err := t.p.Lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	data, ok := t.Get(resId)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &trackerFile{Reader: bytes.NewReader(data), info: resourceInfoFS{name: name, size: int64(len(data))}}, nil
+}
+
+// Writes whitelist of resource ids, one per line in ascending order followed
+// by a comment with the symbol name from names if known:
+//
+//	12345 # IDR_NEW_TAB_PAGE_HTML
+func WriteWhitelist(w io.Writer, ids []uint16, names map[uint16]string) error {
+	ids = append([]uint16(nil), ids...)
+	sortIds(ids)
+
+	bw := bufio.NewWriter(w)
+	for _, resId := range ids {
+		if name, ok := names[resId]; ok {
+			fmt.Fprintf(bw, "%d # %s\n", resId, name)
+		} else {
+			fmt.Fprintf(bw, "%d\n", resId)
+		}
+	}
+	return bw.Flush()
+}
+
+// Reads whitelist written by WriteWhitelist. Text after # and empty lines are
+// ignored.
+func ReadWhitelist(r io.Reader) ([]uint16, error) {
+	var ids []uint16
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		resId, err := strconv.ParseUint(line, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("error reading whitelist line %d: bad id %q", n, line)
+		}
+		ids = append(ids, uint16(resId))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Reads whitelist file, see ReadWhitelist
+func ReadWhitelistFile(name string) ([]uint16, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWhitelist(f)
+}
+
+// Removes resources missing from ids, returning removed ids in ascending
+// order. Aliases of removed resources that are kept get their data as
+// resources of their own.
+func (p *PakFile) Retain(ids []uint16) []uint16 {
+	keep := make(map[uint16]bool, len(ids))
+	for _, resId := range ids {
+		keep[resId] = true
+	}
+
+	all, _ := p.ListIDs()
+	var removed []uint16
+	for _, resId := range all {
+		if !keep[resId] {
+			removed = append(removed, resId)
+		}
+	}
+	for _, resId := range removed {
+		p.Delete(resId)
+	}
+	return removed
+}
+
+type trackerFile struct {
+	*bytes.Reader
+	info resourceInfoFS
+}
+
+func (f *trackerFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *trackerFile) Close() error               { return nil }
+
+type trackerDir struct {
+	files []resourceInfoFS
+	pos   int
+}
+
+func (d *trackerDir) Stat() (fs.FileInfo, error) {
+	return resourceInfoFS{name: ".", dir: true}, nil
+}
+func (d *trackerDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+func (d *trackerDir) Close() error { return nil }
+
+// Lists resources by id without recording them as used
+func (d *trackerDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.files[d.pos:]
+	if n > 0 {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		rest = rest[:min(n, len(rest))]
+	}
+	d.pos += len(rest)
+
+	entries := make([]fs.DirEntry, len(rest))
+	for i, fi := range rest {
+		entries[i] = fs.FileInfoToDirEntry(fi)
+	}
+	return entries, nil
+}
+
+// File info of resource or root directory
+type resourceInfoFS struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi resourceInfoFS) Name() string { return fi.name }
+func (fi resourceInfoFS) Size() int64  { return fi.size }
+func (fi resourceInfoFS) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+func (fi resourceInfoFS) ModTime() time.Time { return time.Time{} }
+func (fi resourceInfoFS) IsDir() bool        { return fi.dir }
+func (fi resourceInfoFS) Sys() any           { return nil }
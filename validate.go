@@ -0,0 +1,249 @@
+package pak
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Severity of a validation finding
+type Severity int
+
+const (
+	SeverityInfo    Severity = iota // harmless oddity
+	SeverityWarning                 // tolerated by readers but likely unintended
+	SeverityError                   // pak will be rejected or misread
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Single problem found by validation
+type Finding struct {
+	Severity Severity
+	Id       uint16 // resource id the finding refers to, valid if HasId is set
+	HasId    bool
+	Message  string
+}
+
+func (f Finding) String() string {
+	if f.HasId {
+		return fmt.Sprintf("%s: resource id=%d: %s", f.Severity, f.Id, f.Message)
+	}
+	return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+}
+
+type findings []Finding
+
+func (fs *findings) add(s Severity, format string, args ...interface{}) {
+	*fs = append(*fs, Finding{Severity: s, Message: fmt.Sprintf(format, args...)})
+}
+
+func (fs *findings) addId(s Severity, id uint16, format string, args ...interface{}) {
+	*fs = append(*fs, Finding{Severity: s, Id: id, HasId: true, Message: fmt.Sprintf(format, args...)})
+}
+
+// Reports whether any of the findings has error severity
+func HasErrors(fs []Finding) bool {
+	for _, f := range fs {
+		if f.Severity >= SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks that pak struct can be written as a valid pak file
+func (p *PakFile) Validate() []Finding {
+	fs := p.validate()
+	if HasErrors(fs) {
+		countMetric(MetricValidationFailures, 1)
+	}
+	return fs
+}
+
+func (p *PakFile) validate() []Finding {
+	var fs findings
+
+	if p.Version != 4 && p.Version != 5 {
+		fs.add(SeverityError, "unsupported version %d", p.Version)
+		return fs
+	}
+	if err := p.Encoding.Validate(); err != nil {
+		fs.add(SeverityError, "%v", err)
+	}
+
+	for aliasId, target := range p.Aliases {
+		if _, ok := p.Resourses[aliasId]; !ok {
+			fs.addId(SeverityWarning, aliasId, "alias of %d has no entry in resources and is ignored", target)
+			continue
+		}
+		if p.Version != 5 {
+			fs.addId(SeverityInfo, aliasId, "aliases need version 5, data of %d is stored twice", target)
+			continue
+		}
+		if _, ok := p.Aliases[target]; ok {
+			fs.addId(SeverityWarning, aliasId, "alias target %d is an alias itself, data is stored twice", target)
+		}
+	}
+
+	wp := p.plan(nil)
+	if p.Version == 5 && wp.header.resources > math.MaxUint16 {
+		fs.add(SeverityError, "too many resources for version 5: %d", wp.header.resources)
+	}
+	if p.Version == 5 && wp.header.aliases > math.MaxUint16 {
+		fs.add(SeverityError, "too many aliases for version 5: %d", wp.header.aliases)
+	}
+	if uint64(len(p.Resourses)) >= math.MaxUint32 {
+		fs.add(SeverityError, "too many resources: %d", len(p.Resourses))
+	}
+
+	total := wp.header.indexEnd() + uint64(len(wp.padding)) + uint64(len(wp.trailer))
+	for _, resId := range wp.order {
+		total += uint64(len(p.Resourses[resId]))
+	}
+	for resId := range p.Resourses {
+		if resId == 0 {
+			fs.addId(SeverityWarning, resId, "id 0 is reserved for the index terminator")
+		}
+	}
+	if total > math.MaxUint32 {
+		fs.add(SeverityError, "file size %d overflows 32-bit offsets", total)
+	}
+
+	return fs
+}
+
+// Checks structure of a pak file: header, index and data ranges
+func ValidateFile(name string) ([]Finding, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return ValidateReader(f, fi.Size())
+}
+
+// Checks structure of a pak of the given size read from io.ReaderAt.
+// Only the header and index are read. Structural problems are reported as
+// findings, the returned error is reserved for I/O failures.
+func ValidateReader(r io.ReaderAt, size int64) ([]Finding, error) {
+	fs, err := validateReader(r, size)
+	if HasErrors(fs) {
+		countMetric(MetricValidationFailures, 1)
+	}
+	return fs, err
+}
+
+func validateReader(r io.ReaderAt, size int64) ([]Finding, error) {
+	var fs findings
+
+	sr := io.NewSectionReader(r, 0, size)
+
+	h, err := readHeader(sr)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		fs.add(SeverityError, "truncated header: file is %d bytes", size)
+		return fs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if h.version != 4 && h.version != 5 {
+		fs.add(SeverityError, "unsupported version %d", h.version)
+		return fs, nil
+	}
+	if err := Encoding(h.encoding).Validate(); err != nil {
+		fs.add(SeverityError, "%v", err)
+	}
+
+	numberOfResources := h.resources
+	indexEnd := h.indexEnd()
+	if indexEnd > uint64(size) {
+		fs.add(SeverityError, "truncated index: %d resources and %d aliases need %d bytes, file is %d bytes", h.resources, h.aliases, indexEnd, size)
+		return fs, nil
+	}
+
+	resInfos, aliasInfos, err := readIndex(sr, h)
+	if err != nil {
+		return nil, err
+	}
+
+	last := resInfos[numberOfResources]
+	if last.id != 0 {
+		fs.add(SeverityError, "terminator entry has id %d, expected 0", last.id)
+	}
+
+	seen := make(map[uint16]bool, numberOfResources)
+	for i := uint32(0); i < numberOfResources; i++ {
+		ri, next := resInfos[i], resInfos[i+1]
+
+		if seen[ri.id] {
+			fs.addId(SeverityError, ri.id, "duplicate id")
+		}
+		seen[ri.id] = true
+
+		if i > 0 && ri.id < resInfos[i-1].id {
+			fs.addId(SeverityError, ri.id, "ids are not sorted, lookups by binary search will fail")
+		}
+		if ri.id == 0 {
+			fs.addId(SeverityWarning, ri.id, "id 0 is reserved for the index terminator")
+		}
+		if uint64(ri.offset) < indexEnd {
+			fs.addId(SeverityError, ri.id, "data offset %d overlaps header or index ending at %d", ri.offset, indexEnd)
+		}
+		if next.offset < ri.offset {
+			fs.addId(SeverityError, ri.id, "next offset %d is before offset %d, ranges overlap", next.offset, ri.offset)
+		} else if next.offset == ri.offset {
+			fs.addId(SeverityInfo, ri.id, "empty resource")
+		}
+		if uint64(ri.offset) > uint64(size) {
+			fs.addId(SeverityError, ri.id, "data offset %d beyond end of file", ri.offset)
+		}
+	}
+
+	for i, ai := range aliasInfos {
+		if seen[ai.id] {
+			fs.addId(SeverityError, ai.id, "duplicate id")
+		}
+		seen[ai.id] = true
+
+		if i > 0 && ai.id < aliasInfos[i-1].id {
+			fs.addId(SeverityError, ai.id, "alias ids are not sorted, lookups by binary search will fail")
+		}
+		if uint32(ai.index) >= numberOfResources {
+			fs.addId(SeverityError, ai.id, "alias entry index %d out of range", ai.index)
+		}
+	}
+
+	first := resInfos[0].offset
+	if uint64(first) > indexEnd {
+		fs.add(SeverityWarning, "%d unreferenced bytes between index and data", uint64(first)-indexEnd)
+	}
+	if uint64(last.offset) > uint64(size) {
+		fs.add(SeverityError, "data ends at %d beyond end of file (%d bytes), file is truncated", last.offset, size)
+	} else if uint64(last.offset) < uint64(size) {
+		fs.add(SeverityWarning, "%d unreferenced trailing bytes after data", uint64(size)-uint64(last.offset))
+	}
+
+	if uint64(size) > math.MaxUint32 {
+		fs.add(SeverityError, "file size %d overflows 32-bit offsets", size)
+	}
+
+	return fs, nil
+}
@@ -0,0 +1,103 @@
+package pak_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/pak"
+)
+
+// Returns finding whose message contains msg, false if there is none
+func findFinding(fs []pak.Finding, msg string) (pak.Finding, bool) {
+	for _, f := range fs {
+		if strings.Contains(f.Message, msg) {
+			return f, true
+		}
+	}
+	return pak.Finding{}, false
+}
+
+func TestValidateReader(t *testing.T) {
+	data := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+
+	tests := []struct {
+		name     string
+		raw      rawPak
+		msg      string // "" for no findings
+		severity pak.Severity
+	}{
+		{"valid", rawPak{version: 5, encoding: 1, ids: []uint16{1, 2, 3}, data: data, aliases: [][2]uint16{{4, 0}}}, "", 0},
+		{"dangling alias", rawPak{version: 5, encoding: 1, ids: []uint16{1, 2, 3}, data: data, aliases: [][2]uint16{{4, 3}}}, "alias entry index 3 out of range", pak.SeverityError},
+		{"id 0", rawPak{version: 5, encoding: 1, ids: []uint16{0, 2, 3}, data: data}, "id 0 is reserved", pak.SeverityWarning},
+		{"index data gap", rawPak{version: 5, encoding: 1, ids: []uint16{1, 2, 3}, data: data, padding: []byte{0, 0, 0}}, "3 unreferenced bytes between index and data", pak.SeverityWarning},
+		{"trailing bytes", rawPak{version: 4, encoding: 1, ids: []uint16{1, 2, 3}, data: data, trailer: []byte("xy")}, "2 unreferenced trailing bytes", pak.SeverityWarning},
+		{"unknown encoding", rawPak{version: 5, encoding: 7, ids: []uint16{1, 2, 3}, data: data}, "unsupported encoding 7", pak.SeverityError},
+		{"unsorted ids", rawPak{version: 4, encoding: 1, ids: []uint16{2, 1, 3}, data: data}, "ids are not sorted", pak.SeverityError},
+		{"duplicate id", rawPak{version: 5, encoding: 1, ids: []uint16{1, 2, 3}, data: data, aliases: [][2]uint16{{2, 0}}}, "duplicate id", pak.SeverityError},
+		{"unsupported version", rawPak{version: 3, encoding: 1}, "unsupported version 3", pak.SeverityError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.raw.bytes()
+			fs, err := pak.ValidateReader(bytes.NewReader(b), int64(len(b)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.msg == "" {
+				if len(fs) != 0 {
+					t.Errorf("findings %v, want none", fs)
+				}
+				return
+			}
+			f, ok := findFinding(fs, tt.msg)
+			if !ok || f.Severity != tt.severity {
+				t.Errorf("findings %v, want %s finding %q", fs, tt.severity, tt.msg)
+			}
+
+			// Whatever readers reject is an error
+			_, err = pak.Read(bytes.NewReader(b))
+			if err != nil && !pak.HasErrors(fs) {
+				t.Errorf("Read error %v without validation errors", err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	res := func() map[uint16][]byte {
+		return map[uint16][]byte{1: []byte("one"), 10: []byte("one"), 11: []byte("one")}
+	}
+
+	tests := []struct {
+		name     string
+		p        *pak.PakFile
+		msg      string // "" for no findings
+		severity pak.Severity
+	}{
+		{"valid", &pak.PakFile{Version: 5, Encoding: 1, Resourses: res(), Aliases: map[uint16]uint16{10: 1}}, "", 0},
+		{"dangling alias", &pak.PakFile{Version: 5, Encoding: 1, Resourses: map[uint16][]byte{1: []byte("one")}, Aliases: map[uint16]uint16{10: 1}}, "has no entry in resources", pak.SeverityWarning},
+		{"alias of alias", &pak.PakFile{Version: 5, Encoding: 1, Resourses: res(), Aliases: map[uint16]uint16{10: 1, 11: 10}}, "alias target 10 is an alias itself", pak.SeverityWarning},
+		{"aliases in version 4", &pak.PakFile{Version: 4, Encoding: 1, Resourses: res(), Aliases: map[uint16]uint16{10: 1}}, "aliases need version 5", pak.SeverityInfo},
+		{"id 0", &pak.PakFile{Version: 5, Encoding: 1, Resourses: map[uint16][]byte{0: []byte("zero")}}, "id 0 is reserved", pak.SeverityWarning},
+		{"unknown encoding", &pak.PakFile{Version: 5, Encoding: 7, Resourses: res()}, "unsupported encoding 7", pak.SeverityError},
+		{"unsupported version", &pak.PakFile{Version: 3, Encoding: 1}, "unsupported version 3", pak.SeverityError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := tt.p.Validate()
+			if tt.msg == "" {
+				if len(fs) != 0 {
+					t.Errorf("findings %v, want none", fs)
+				}
+				return
+			}
+			f, ok := findFinding(fs, tt.msg)
+			if !ok || f.Severity != tt.severity {
+				t.Errorf("findings %v, want %s finding %q", fs, tt.severity, tt.msg)
+			}
+		})
+	}
+}
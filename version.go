@@ -0,0 +1,101 @@
+package pak
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pak format version, 4 or 5
+type Version uint32
+
+// Text encoding declared by a pak
+type Encoding uint8
+
+const (
+	EncodingBinary Encoding = iota
+	EncodingUTF8
+	EncodingUTF16
+)
+
+// Returns version number
+func (v Version) String() string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// Reports error for versions other than 4 and 5
+func (v Version) Validate() error {
+	if v != 4 && v != 5 {
+		return fmt.Errorf("unsupported version %d", v)
+	}
+	return nil
+}
+
+// Returns version number as text
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Parses version number, optionally prefixed with "v"
+func (v *Version) UnmarshalText(text []byte) error {
+	s := strings.TrimPrefix(string(text), "v")
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return fmt.Errorf("bad pak version %q", text)
+	}
+	version := Version(n)
+	err = version.Validate()
+	if err != nil {
+		return err
+	}
+	*v = version
+	return nil
+}
+
+// Returns name of encoding
+func (e Encoding) String() string {
+	switch e {
+	case EncodingBinary:
+		return "binary"
+	case EncodingUTF8:
+		return "utf-8"
+	case EncodingUTF16:
+		return "utf-16"
+	}
+	return fmt.Sprintf("encoding(%d)", uint8(e))
+}
+
+// Reports error for encodings Chromium does not define
+func (e Encoding) Validate() error {
+	if e > EncodingUTF16 {
+		return fmt.Errorf("unsupported encoding %d", e)
+	}
+	return nil
+}
+
+// Returns name of encoding as text
+func (e Encoding) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// Parses encoding name as returned by String, or its number
+func (e *Encoding) UnmarshalText(text []byte) error {
+	s := strings.ToLower(string(text))
+	for encoding := EncodingBinary; encoding <= EncodingUTF16; encoding++ {
+		if s == encoding.String() || s == strings.ReplaceAll(encoding.String(), "-", "") {
+			*e = encoding
+			return nil
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return fmt.Errorf("bad pak encoding %q", text)
+	}
+	encoding := Encoding(n)
+	err = encoding.Validate()
+	if err != nil {
+		return err
+	}
+	*e = encoding
+	return nil
+}
@@ -0,0 +1,108 @@
+//go:build !js
+
+package pak
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Options controlling how a directory is watched
+type WatchOptions struct {
+	Interval time.Duration // polling interval, zero means one second
+	Pack     *PackOptions
+
+	// OnBuild, if set, is called after every rebuild attempt with its error
+	OnBuild func(err error)
+}
+
+// Modification state of a file
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Returns state of regular files in dir
+func dirState(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]fileState, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue // removed meanwhile
+		}
+		state[e.Name()] = fileState{fi.Size(), fi.ModTime()}
+	}
+	return state, nil
+}
+
+func sameState(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, s := range a {
+		if t, ok := b[name]; !ok || !s.modTime.Equal(t.modTime) || s.size != t.size {
+			return false
+		}
+	}
+	return true
+}
+
+// Packs dir into out, see PackDir, and repacks it whenever files in dir
+// change until ctx is done. Changes are detected by polling. Failed builds
+// are reported to OnBuild and leave out unchanged, watching goes on. Returns
+// ctx.Err() when done, or an error if dir cannot be listed.
+func Watch(ctx context.Context, dir, out string, opts *WatchOptions) error {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	build := func() {
+		p, err := PackDir(dir, opts.Pack)
+		if err == nil {
+			err = WriteFileWithOptions(out, p, &WriteOptions{Atomic: true})
+		}
+		if opts.OnBuild != nil {
+			opts.OnBuild(err)
+		}
+	}
+
+	state, err := dirState(dir)
+	if err != nil {
+		return err
+	}
+	build()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := dirState(dir)
+		if err != nil {
+			return err
+		}
+		if sameState(state, current) {
+			continue
+		}
+		state = current
+		build()
+	}
+}
@@ -0,0 +1,224 @@
+package pak
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// References found in WebUI sources
+type WebUIRefs struct {
+	Paths   []string          // URLs and paths of src, href, url(), imports and @import
+	Strings []string          // keys of $i18n{}, $i18nRaw{} and $i18nPolymer{}
+	Imports map[string]string // import map entries of <script type="importmap">
+}
+
+var (
+	webuiAttrRe    = regexp.MustCompile(`\b(?:src|href)\s*=\s*["']([^"']+)["']`)
+	webuiURLRe     = regexp.MustCompile(`url\(\s*["']?([^"')]+)["']?\s*\)`)
+	webuiImportRe  = regexp.MustCompile(`(?:\bfrom|\bimport|@import)\s*\(?\s*["']([^"']+)["']`)
+	webuiI18nRe    = regexp.MustCompile(`\$i18n(?:Raw|Polymer)?\{(\w+)\}`)
+	webuiImportMap = regexp.MustCompile(`(?s)<script[^>]*type\s*=\s*["']importmap["'][^>]*>(.*?)</script>`)
+)
+
+// Scans HTML, JS or CSS source for references to other resources and
+// strings. Matching is textual, so references built at run time are missed
+// and ones inside comments are found.
+func FindWebUIRefs(src []byte) *WebUIRefs {
+	refs := &WebUIRefs{}
+	s := string(src)
+
+	seen := make(map[string]bool)
+	for _, re := range []*regexp.Regexp{webuiAttrRe, webuiURLRe, webuiImportRe} {
+		for _, m := range re.FindAllStringSubmatch(s, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				refs.Paths = append(refs.Paths, m[1])
+			}
+		}
+	}
+	for _, m := range webuiI18nRe.FindAllStringSubmatch(s, -1) {
+		if !seen["$"+m[1]] {
+			seen["$"+m[1]] = true
+			refs.Strings = append(refs.Strings, m[1])
+		}
+	}
+	for _, m := range webuiImportMap.FindAllStringSubmatch(s, -1) {
+		var im struct {
+			Imports map[string]string `json:"imports"`
+		}
+		if json.Unmarshal([]byte(m[1]), &im) != nil {
+			continue
+		}
+		for k, v := range im.Imports {
+			if refs.Imports == nil {
+				refs.Imports = make(map[string]string)
+			}
+			refs.Imports[k] = v
+		}
+	}
+	return refs
+}
+
+// Resolves references of WebUI sources to resource ids
+type WebUIMap struct {
+	Paths   map[string]uint16 // paths relative to the WebUI root, e.g. "js/app.js"
+	Shared  map[string]uint16 // paths under chrome://resources/, e.g. "js/cr.js"
+	Strings map[string]uint16 // $i18n{} keys
+}
+
+// Returns map of files included by .grd at the paths WebUI serves them at:
+// their resource_path or, without one, their path relative to the .grd.
+// Shared, if set, is the .grd of chrome://resources. Strings are mapped by
+// message name; $i18n{} keys differ from those and are to be added to
+// Strings by the caller.
+func NewWebUIMap(g, shared *Grd, symbols SymbolTable) *WebUIMap {
+	m := &WebUIMap{Paths: make(map[string]uint16), Shared: make(map[string]uint16), Strings: make(map[string]uint16)}
+	add := func(g *Grd, paths map[string]uint16) {
+		for _, e := range g.Entries {
+			resId, ok := symbols[e.Name]
+			if !ok {
+				continue
+			}
+			if e.File != "" {
+				paths[e.servedPath()] = resId
+			} else if e.Kind == "message" {
+				m.Strings[e.Name] = resId
+			}
+		}
+	}
+	add(g, m.Paths)
+	if shared != nil {
+		add(shared, m.Shared)
+	}
+	return m
+}
+
+// Resources and strings reachable from root resources
+type Reachability struct {
+	Resources  []uint16 // ids of the pak, roots included, ascending
+	Strings    []uint16 // ids of strings used, found in locale paks, ascending
+	Unresolved []string // references not resolved to a resource or string
+}
+
+// Follows references of WebUI sources from roots through the pak and
+// returns everything reached. Resources with no path in m are scanned but
+// relative references from them cannot be resolved.
+func (m *WebUIMap) Reachable(p *PakFile, roots []uint16) *Reachability {
+	paths := make(map[uint16]string)
+	for name, resId := range m.Paths {
+		paths[resId] = name
+	}
+	for name, resId := range m.Shared {
+		paths[resId] = "//resources/" + name
+	}
+
+	r := &Reachability{}
+	reached := make(map[uint16]bool)
+	usedStrings := make(map[uint16]bool)
+	unresolved := make(map[string]bool)
+	imports := make(map[string]string)
+
+	queue := append([]uint16(nil), roots...)
+	for len(queue) > 0 {
+		resId := queue[0]
+		queue = queue[1:]
+		if reached[resId] || !p.Has(resId) {
+			continue
+		}
+		reached[resId] = true
+
+		data, err := p.Load(resId)
+		if err != nil {
+			continue
+		}
+		data, err = Decompress(data)
+		if err != nil || !utf8.Valid(data) {
+			continue
+		}
+
+		refs := FindWebUIRefs(data)
+		for k, v := range refs.Imports {
+			imports[k] = v
+		}
+		for _, ref := range refs.Paths {
+			target, ok, external := m.resolve(ref, paths[resId], imports)
+			if ok {
+				queue = append(queue, target)
+			} else if !external {
+				unresolved[ref] = true
+			}
+		}
+		for _, key := range refs.Strings {
+			if resId, ok := m.Strings[key]; ok {
+				usedStrings[resId] = true
+			} else {
+				unresolved["$i18n{"+key+"}"] = true
+			}
+		}
+	}
+
+	for resId := range reached {
+		r.Resources = append(r.Resources, resId)
+	}
+	for resId := range usedStrings {
+		r.Strings = append(r.Strings, resId)
+	}
+	for ref := range unresolved {
+		r.Unresolved = append(r.Unresolved, ref)
+	}
+	sortIds(r.Resources)
+	sortIds(r.Strings)
+	sort.Strings(r.Unresolved)
+	return r
+}
+
+// Resolves reference made from resource at base, "//resources/" prefixed
+// for shared ones. Reports external for references to other origins and
+// data, which need no resource.
+func (m *WebUIMap) resolve(ref, base string, imports map[string]string) (resId uint16, ok, external bool) {
+	ref, _, _ = strings.Cut(ref, "#")
+	ref, _, _ = strings.Cut(ref, "?")
+	if ref == "" {
+		return 0, false, true
+	}
+
+	// Bare module specifiers go through import maps, keys ending in / map
+	// prefixes
+	if to, ok := imports[ref]; ok {
+		ref = to
+	} else {
+		for from, to := range imports {
+			if strings.HasSuffix(from, "/") && strings.HasPrefix(ref, from) {
+				ref = to + strings.TrimPrefix(ref, from)
+				break
+			}
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(ref, "chrome://resources/"):
+		ref = "//resources/" + strings.TrimPrefix(ref, "chrome://resources/")
+	case strings.HasPrefix(ref, "//resources/"):
+	case strings.Contains(ref, ":") || strings.HasPrefix(ref, "//"):
+		return 0, false, true
+	case strings.HasPrefix(ref, "/"):
+		ref = strings.TrimPrefix(ref, "/")
+	default:
+		if rest, shared := strings.CutPrefix(base, "//resources/"); shared {
+			ref = "//resources/" + path.Join(path.Dir(rest), ref)
+		} else {
+			ref = path.Join(path.Dir(base), ref)
+		}
+	}
+
+	if rest, shared := strings.CutPrefix(ref, "//resources/"); shared {
+		resId, ok = m.Shared[path.Clean(rest)]
+	} else {
+		resId, ok = m.Paths[path.Clean(ref)]
+	}
+	return resId, ok, false
+}